@@ -79,6 +79,15 @@ func (e *eventwriter) WriteNullType(val ion.Type) error {
 	})
 }
 
+func (e *eventwriter) WriteNullWithTypeAndAnnotations(val ion.Type, annotations ...string) error {
+	for _, a := range annotations {
+		if err := e.Annotation(ion.NewSymbolTokenFromString(a)); err != nil {
+			return err
+		}
+	}
+	return e.WriteNullType(val)
+}
+
 func (e *eventwriter) WriteBool(val bool) error {
 	return e.write(event{
 		EventType: scalar,
@@ -175,6 +184,32 @@ func (e *eventwriter) WriteBlob(val []byte) error {
 	})
 }
 
+func (e *eventwriter) WriteValue(v interface{}) error {
+	return ion.MarshalTo(e, v)
+}
+
+func (e *eventwriter) WriteFieldIf(cond bool, name string, v interface{}) error {
+	if !cond {
+		return nil
+	}
+	if err := e.FieldName(ion.NewSymbolTokenFromString(name)); err != nil {
+		return err
+	}
+	return e.WriteValue(v)
+}
+
+func (e *eventwriter) WriteListFromChan(ch <-chan interface{}) error {
+	if err := e.BeginList(); err != nil {
+		return err
+	}
+	for v := range ch {
+		if err := e.WriteValue(v); err != nil {
+			return err
+		}
+	}
+	return e.EndList()
+}
+
 func (e *eventwriter) BeginList() error {
 	err := e.write(event{
 		EventType: containerStart,
@@ -244,10 +279,18 @@ func (e *eventwriter) Finish() error {
 	return e.enc.Finish()
 }
 
+func (e *eventwriter) Flush() error {
+	return e.enc.Flush()
+}
+
 func (e *eventwriter) IsInStruct() bool {
 	return e.inStruct[e.depth] == true
 }
 
+func (e *eventwriter) Reset(out io.Writer) {
+	*e = *NewEventWriter(out).(*eventwriter)
+}
+
 func stringify(val interface{}) string {
 	bs, err := ion.MarshalText(val)
 	if err != nil {
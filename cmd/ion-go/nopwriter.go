@@ -16,6 +16,7 @@
 package main
 
 import (
+	"io"
 	"math/big"
 
 	"github.com/amzn/ion-go/ion"
@@ -48,6 +49,10 @@ func (nopwriter) WriteNullType(ion.Type) error {
 	return nil
 }
 
+func (nopwriter) WriteNullWithTypeAndAnnotations(ion.Type, ...string) error {
+	return nil
+}
+
 func (nopwriter) WriteBool(bool) error {
 	return nil
 }
@@ -96,6 +101,20 @@ func (nopwriter) WriteBlob([]byte) error {
 	return nil
 }
 
+func (nopwriter) WriteValue(interface{}) error {
+	return nil
+}
+
+func (nopwriter) WriteFieldIf(bool, string, interface{}) error {
+	return nil
+}
+
+func (nopwriter) WriteListFromChan(ch <-chan interface{}) error {
+	for range ch {
+	}
+	return nil
+}
+
 func (nopwriter) BeginList() error {
 	return nil
 }
@@ -124,6 +143,13 @@ func (nopwriter) Finish() error {
 	return nil
 }
 
+func (nopwriter) Flush() error {
+	return nil
+}
+
 func (nopwriter) IsInStruct() bool {
 	return false
 }
+
+func (nopwriter) Reset(io.Writer) {
+}
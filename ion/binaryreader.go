@@ -18,31 +18,120 @@ package ion
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"math"
 )
 
+// A NonCanonicalEncoding describes a part of a binary Ion stream that is valid
+// but is not encoded as a canonical writer would have encoded it, such as a
+// length padded to more bytes than it needs.
+type NonCanonicalEncoding struct {
+	Msg    string
+	Offset uint64
+}
+
+func (e NonCanonicalEncoding) String() string {
+	return fmt.Sprintf("%v (offset %v)", e.Msg, e.Offset)
+}
+
 // A binaryReader reads binary Ion.
 type binaryReader struct {
 	reader
 
 	bits bitstream
 	cat  Catalog
+
+	// Assumed is set, with the version to assume, if input that doesn't start
+	// with a version marker is to be read anyway.
+	assumed      bool
+	major, minor int
 }
 
-func newBinaryReaderBuf(in *bufio.Reader, cat Catalog) Reader {
+func newBinaryReaderBuf(in *bufio.Reader, cat Catalog, interner Interner) Reader {
 	r := &binaryReader{
 		cat: cat,
 	}
+	r.interner = interner
 	r.bits.Init(in)
 	return r
 }
 
+// Reset discards the reader's state and starts reading from in.
+func (r *binaryReader) Reset(in io.Reader) {
+	r.resetBuf(resetBuf(r.bits.in, in))
+}
+
+// ResetBuf discards the reader's state and starts reading from br.
+func (r *binaryReader) resetBuf(br *bufio.Reader) {
+	r.reader.reset()
+
+	r.bits = bitstream{
+		in:         br,
+		stack:      bitstack{arr: r.bits.stack.arr[:0]},
+		scratch:    r.bits.scratch,
+		stopAtText: r.bits.stopAtText,
+
+		onNonCanonical: r.bits.onNonCanonical,
+	}
+
+	if r.assumed {
+		r.assumeVersion()
+	} else if bs, _ := br.Peek(1); len(bs) > 0 && !isBinary(br) {
+		r.err = &UsageError{"Reader.Reset", "input is not binary Ion"}
+	}
+}
+
+// WithAssumedVersion makes the reader read input without a version marker as
+// the given version.
+func (r *binaryReader) WithAssumedVersion(major, minor int) Reader {
+	if r.bits.Pos() != 0 {
+		if r.err == nil {
+			r.err = &UsageError{"Reader.WithAssumedVersion", "cannot assume a version after reading has started"}
+		}
+		return r
+	}
+
+	r.assumed = true
+	r.major, r.minor = major, minor
+	r.assumeVersion()
+	return r
+}
+
+// AssumeVersion starts reading with the assumed version, unless the input
+// starts with a version marker of its own.
+func (r *binaryReader) assumeVersion() {
+	if isBinary(r.bits.in) {
+		return
+	}
+	if r.major != 1 || r.minor != 0 {
+		r.err = &UnsupportedVersionError{r.major, r.minor, 0}
+		return
+	}
+	r.setSymbolTable(V1SystemSymbolTable)
+}
+
+// ResetBytes discards the reader's state and starts reading from in.
+func (r *binaryReader) ResetBytes(in []byte) {
+	r.inBytes.Reset(in)
+	r.Reset(&r.inBytes)
+}
+
 // Next moves the reader to the next value.
 func (r *binaryReader) Next() bool {
-	if r.eof || r.err != nil {
+	if r.err != nil {
+		return false
+	}
+	if r.peeked != nil {
+		return r.nextPeeked()
+	}
+	if r.eof {
 		return false
 	}
 
 	r.clear()
+	if r.bits.keepTape {
+		r.bits.clearTape()
+	}
 
 	done := false
 	for !done {
@@ -55,6 +144,65 @@ func (r *binaryReader) Next() bool {
 	return !r.eof
 }
 
+// PeekType returns the type of the next value without moving to it.
+func (r *binaryReader) PeekType() (Type, error) {
+	return r.peekType(r.Next)
+}
+
+// OnNonCanonical sets the function called with each non-canonical encoding.
+func (r *binaryReader) OnNonCanonical(fn func(NonCanonicalEncoding)) {
+	r.bits.onNonCanonical = fn
+}
+
+// BytesConsumed returns the number of bytes of input consumed so far.
+func (r *binaryReader) BytesConsumed() int64 {
+	return int64(r.bits.Pos())
+}
+
+// KeepRaw starts or stops keeping the input that rawValue needs.
+func (r *binaryReader) keepRaw(keep bool) {
+	r.bits.keepTape = keep
+	r.bits.clearTape()
+}
+
+// RawValue returns the current value as it appeared in the input, reading
+// the rest of it if it is a container.
+func (r *binaryReader) rawValue() ([]byte, bool, error) {
+	if r.err != nil {
+		return nil, true, r.err
+	}
+	if r.valueType == NoType || !r.bits.keepTape || uint64(r.start) < r.bits.tapeStart {
+		return nil, true, fmt.Errorf("ion: the current value was not kept raw")
+	}
+
+	if err := r.bits.SkipValue(); err != nil {
+		r.err = err
+		return nil, true, err
+	}
+	return r.bits.taped(uint64(r.start)), true, nil
+}
+
+// SwitchInput returns the rest of the input if the reader stopped at a text
+// version marker, and nil otherwise.
+func (r *binaryReader) switchInput() *bufio.Reader {
+	if !r.bits.atText || r.err != nil {
+		return nil
+	}
+	return r.bits.in
+}
+
+// Resume clears the end-of-input state at the top level so that reading can
+// continue after more input has been made available.
+func (r *binaryReader) resume() {
+	if r.err == nil && r.ctx.peek() == ctxAtTopLevel {
+		r.eof = false
+		if r.peeked != nil && r.peeked.eof {
+			// Peeking found nothing more, but there may be now.
+			r.peeked = nil
+		}
+	}
+}
+
 // Next consumes the next raw value from the stream, returning true if it
 // represents a user-facing value and false if it does not.
 func (r *binaryReader) next() (bool, error) {
@@ -63,6 +211,11 @@ func (r *binaryReader) next() (bool, error) {
 	}
 
 	code := r.bits.Code()
+	if code == bitcodeAnnotation || (code != bitcodeFieldID && len(r.annotationIDs) == 0) {
+		// The value starts at its annotation wrapper, if it has one.
+		r.start = int64(r.bits.start)
+	}
+
 	switch code {
 	case bitcodeEOF:
 		r.eof = true
@@ -99,7 +252,7 @@ func (r *binaryReader) next() (bool, error) {
 	case bitcodeInt, bitcodeNegInt:
 		r.valueType = IntType
 		if !r.bits.IsNull() {
-			val, err := r.bits.ReadInt()
+			val, err := r.bits.ReadInt(&r.bigInt)
 			if err != nil {
 				return false, err
 			}
@@ -158,6 +311,9 @@ func (r *binaryReader) next() (bool, error) {
 	case bitcodeString:
 		r.valueType = StringType
 		if !r.bits.IsNull() {
+			if err := r.checkBinaryValueSize(StringType); err != nil {
+				return false, err
+			}
 			val, err := r.bits.ReadString()
 			if err != nil {
 				return false, err
@@ -169,6 +325,9 @@ func (r *binaryReader) next() (bool, error) {
 	case bitcodeClob:
 		r.valueType = ClobType
 		if !r.bits.IsNull() {
+			if err := r.checkBinaryValueSize(ClobType); err != nil {
+				return false, err
+			}
 			val, err := r.bits.ReadBytes()
 			if err != nil {
 				return false, err
@@ -180,6 +339,9 @@ func (r *binaryReader) next() (bool, error) {
 	case bitcodeBlob:
 		r.valueType = BlobType
 		if !r.bits.IsNull() {
+			if err := r.checkBinaryValueSize(BlobType); err != nil {
+				return false, err
+			}
 			val, err := r.bits.ReadBytes()
 			if err != nil {
 				return false, err
@@ -209,15 +371,15 @@ func (r *binaryReader) next() (bool, error) {
 		}
 
 		// If it's a local symbol table, install it and keep going.
-		if r.ctx.peek() == ctxAtTopLevel && isIonSymbolTable(r.annotations) {
+		if r.ctx.peek() == ctxAtTopLevel && r.isIonSymbolTable() {
 			if r.IsNull() {
 				r.clear()
-				r.lst = V1SystemSymbolTable
+				r.setSymbolTable(V1SystemSymbolTable)
 				return false, nil
 			}
-			st, err := readLocalSymbolTable(r, r.cat)
+			st, err := readLocalSymbolTable(r, r.cat, r.interner)
 			if err == nil {
-				r.lst = st
+				r.setSymbolTable(st)
 				return false, nil
 			}
 			return false, err
@@ -232,6 +394,36 @@ func isIonSymbolTable(as []SymbolToken) bool {
 	return len(as) > 0 && as[0].Text != nil && *as[0].Text == "$ion_symbol_table"
 }
 
+// IsIonSymbolTable returns true if the current value's first annotation is
+// $ion_symbol_table, without resolving the rest.
+func (r *binaryReader) isIonSymbolTable() bool {
+	if len(r.annotationIDs) == 0 {
+		return false
+	}
+	text, ok := r.SymbolTable().FindByID(r.annotationIDs[0])
+	return ok && text == "$ion_symbol_table"
+}
+
+// CheckSID returns an error if id is out of range for the current symbol table.
+func (r *binaryReader) checkSID(id uint64) error {
+	if max := r.SymbolTable().MaxID(); id > max {
+		return &SymbolIDOutOfRangeError{int64(id), max}
+	}
+	return nil
+}
+
+// CheckBinaryValueSize passes the length of the current string or lob to the
+// OnValueSize function, first rejecting a length too large for an int, which
+// no input could hold and which would otherwise reach fn as a negative size.
+func (r *binaryReader) checkBinaryValueSize(t Type) error {
+	size := r.bits.Len()
+	if size > math.MaxInt {
+		msg := fmt.Sprintf("%v length %v is too large", t, size)
+		return &SyntaxError{msg, r.bits.Pos()}
+	}
+	return r.checkValueSize(t, int(size))
+}
+
 // ReadBVM reads a BVM, validates it, and resets the local symbol table.
 func (r *binaryReader) readBVM() error {
 	major, minor, err := r.bits.ReadBVM()
@@ -243,7 +435,7 @@ func (r *binaryReader) readBVM() error {
 	case 1:
 		switch minor {
 		case 0:
-			r.lst = V1SystemSymbolTable
+			r.setSymbolTable(V1SystemSymbolTable)
 			return nil
 		}
 	}
@@ -255,30 +447,36 @@ func (r *binaryReader) readBVM() error {
 	}
 }
 
-// ReadFieldName reads and resolves a field name.
+// ReadFieldName reads a field name's symbol ID. It is resolved to a symbol
+// token only if FieldName is called.
 func (r *binaryReader) readFieldName() error {
 	id, err := r.bits.ReadFieldID()
 	if err != nil {
 		return err
 	}
-
-	st, err := NewSymbolTokenBySID(r.SymbolTable(), int64(id))
-	if err != nil {
+	if err := r.checkSID(id); err != nil {
 		return err
 	}
 
-	r.fieldName = &st
+	r.fieldID = int64(id)
+	r.lazyField = true
 	return nil
 }
 
-// ReadAnnotations reads and resolves a set of annotations.
+// ReadAnnotations reads a set of annotation symbol IDs. They are resolved to
+// symbol tokens only if Annotations is called.
 func (r *binaryReader) readAnnotations() error {
-	as, err := r.bits.ReadAnnotations(r.SymbolTable())
+	ids, err := r.bits.ReadAnnotationIDs(r.annotationIDs[:0])
 	if err != nil {
 		return err
 	}
+	for _, id := range ids {
+		if err := r.checkSID(id); err != nil {
+			return err
+		}
+	}
 
-	r.annotations = as
+	r.annotationIDs = ids
 
 	return nil
 }
@@ -311,6 +509,7 @@ func (r *binaryReader) StepOut() error {
 	if r.ctx.peek() == ctxAtTopLevel {
 		return &UsageError{"Reader.StepOut", "cannot step out of top-level datagram"}
 	}
+	r.peeked = nil
 
 	if err := r.bits.StepOut(); err != nil {
 		return err
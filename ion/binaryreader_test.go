@@ -16,9 +16,14 @@
 package ion
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"math"
 	"math/big"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -251,6 +256,77 @@ func TestReadBinaryFieldNames(t *testing.T) {
 	_nextF(t, r, &SymbolToken{}, true, true)
 }
 
+func TestReadBinaryFieldNameSIDs(t *testing.T) {
+	r := readBinary([]byte{
+		0xDC,             // {
+		0x84, 0x21, 0x01, // name: 1
+		0xEF, 0x21, 0x01, // bar: 1
+		0xEE, 0x21, 0x01, // foo: 1
+		0x80, 0x21, 0x01, // $0: 1
+		// }
+	})
+	require.True(t, r.Next())
+	_, err := r.FieldNameSID()
+	assert.Error(t, err)
+
+	require.NoError(t, r.StepIn())
+	var sids []int64
+	for r.Next() {
+		sid, err := r.FieldNameSID()
+		require.NoError(t, err)
+		sids = append(sids, sid)
+	}
+	require.NoError(t, r.Err())
+	assert.Equal(t, []int64{4, 111, 110, 0}, sids)
+	require.NoError(t, r.StepOut())
+}
+
+func TestReadBinaryNonCanonical(t *testing.T) {
+	test := func(name string, ion []byte, expected ...NonCanonicalEncoding) {
+		t.Run(name, func(t *testing.T) {
+			var got []NonCanonicalEncoding
+			r := NewReaderBytes(append([]byte{0xE0, 0x01, 0x00, 0xEA}, ion...))
+			r.OnNonCanonical(func(e NonCanonicalEncoding) {
+				got = append(got, e)
+			})
+
+			var walk func()
+			walk = func() {
+				for r.Next() {
+					if r.Type() == StructType || r.Type() == ListType {
+						require.NoError(t, r.StepIn())
+						walk()
+						require.NoError(t, r.StepOut())
+					}
+				}
+			}
+			walk()
+			require.NoError(t, r.Err())
+			assert.Equal(t, expected, got)
+		})
+	}
+
+	canonical, err := MarshalBinary(map[string]interface{}{"a": []int{1, 2}, "b": "a string longer than 14 bytes"})
+	require.NoError(t, err)
+	test("canonical", canonical[4:])
+
+	test("short length", []byte{0x8E, 0x83, 'a', 'b', 'c'},
+		NonCanonicalEncoding{"length 3 written as a VarUInt rather than in the type descriptor", 4})
+	test("padded length", []byte{0x8E, 0x00, 0x8E, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n'},
+		NonCanonicalEncoding{"length 14 padded to 2 bytes", 5})
+	test("padded short length", []byte{0x8E, 0x00, 0x83, 'a', 'b', 'c'},
+		NonCanonicalEncoding{"length 3 written as a VarUInt rather than in the type descriptor", 4},
+		NonCanonicalEncoding{"length 3 padded to 2 bytes", 5})
+	test("nop padding", []byte{0x00, 0x21, 0x01, 0xB3, 0x01, 0xFF, 0x20},
+		NonCanonicalEncoding{"NOP padding", 4},
+		NonCanonicalEncoding{"NOP padding", 8})
+	test("padded field ID", []byte{0xD4, 0x00, 0x84, 0x21, 0x01},
+		NonCanonicalEncoding{"field ID 4 padded to 2 bytes", 5})
+	test("ordered struct", []byte{0xD1, 0x86, 0x84, 0x21, 0x01, 0x84, 0x21, 0x02})
+	test("unordered ordered struct", []byte{0xD1, 0x86, 0x85, 0x21, 0x01, 0x84, 0x21, 0x02},
+		NonCanonicalEncoding{"field $4 after $5 in an ordered struct", 9})
+}
+
 func TestReadBinaryNullFieldName(t *testing.T) {
 	r := readBinary([]byte{
 		0xDE, 0x8F, // {
@@ -458,6 +534,7 @@ func TestReadBinaryNulls(t *testing.T) {
 	})
 
 	_null(t, r, NullType)
+	assert.Equal(t, TypedNull, r.NullForm())
 	_nullAF(t, r, NullType, nil, []SymbolToken{{Text: newString("$ion"), LocalSID: 1}})
 	_nullAF(t, r, NullType, nil, []SymbolToken{NewSymbolTokenFromString("foo"), NewSymbolTokenFromString("bar")})
 	_eof(t, r)
@@ -469,6 +546,172 @@ func TestReadEmptyBinary(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestReadBinaryLazyAnnotations(t *testing.T) {
+	bs := annotatedStructs(t, 10)
+	r := NewReaderBytes(bs)
+
+	// Annotations and field names are resolved on demand, even after the
+	// value itself has been read.
+	require.True(t, r.Next())
+	require.NoError(t, r.StepIn())
+	require.True(t, r.Next())
+	require.NoError(t, r.StepIn())
+	require.True(t, r.Next())
+	val, err := r.Int64Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), *val)
+	fn, err := r.FieldName()
+	require.NoError(t, err)
+	assert.Equal(t, "f", *fn.Text)
+	as, err := r.Annotations()
+	require.NoError(t, err)
+	assert.Equal(t, "c", *as[0].Text)
+	require.NoError(t, r.StepOut())
+	as, err = r.Annotations()
+	require.NoError(t, err)
+	assert.Nil(t, as)
+
+	scan := func(annotations bool) float64 {
+		return testing.AllocsPerRun(10, func() {
+			r.ResetBytes(bs)
+			scanAnnotatedStructs(r, annotations)
+		})
+	}
+	assert.Less(t, scan(false), scan(true))
+}
+
+func BenchmarkReadBinaryAnnotations(b *testing.B) {
+	bs := annotatedStructs(b, 1000)
+	r := NewReaderBytes(bs)
+
+	bench := func(name string, annotations bool) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r.ResetBytes(bs)
+				scanAnnotatedStructs(r, annotations)
+			}
+		})
+	}
+	bench("ignore", false)
+	bench("read", true)
+}
+
+// AnnotatedStructs returns a binary list of n annotated structs, each with a
+// single annotated field.
+func annotatedStructs(t require.TestingT, n int) []byte {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.BeginList())
+	for i := 0; i < n; i++ {
+		require.NoError(t, w.Annotations(NewSymbolTokenFromString("a"), NewSymbolTokenFromString("b")))
+		require.NoError(t, w.BeginStruct())
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("f")))
+		require.NoError(t, w.Annotations(NewSymbolTokenFromString("c")))
+		require.NoError(t, w.WriteInt(int64(i%100)))
+		require.NoError(t, w.EndStruct())
+	}
+	require.NoError(t, w.EndList())
+	require.NoError(t, w.Finish())
+	return buf.Bytes()
+}
+
+// ScanAnnotatedStructs reads the values written by annotatedStructs, reading
+// their annotations and field names only if asked to.
+func scanAnnotatedStructs(r Reader, annotations bool) {
+	for r.Next() {
+		_ = r.StepIn()
+		for r.Next() {
+			if annotations {
+				_, _ = r.Annotations()
+			}
+			_ = r.StepIn()
+			for r.Next() {
+				if annotations {
+					_, _ = r.FieldName()
+					_, _ = r.Annotations()
+				}
+				_, _ = r.Int64Value()
+			}
+			_ = r.StepOut()
+		}
+		_ = r.StepOut()
+	}
+}
+
+func TestReadBinaryShortReads(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.Annotations(NewSymbolTokenFromString("doc")))
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("name")))
+	require.NoError(t, w.WriteString(strings.Repeat("a long string ", 100)))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("data")))
+	require.NoError(t, w.WriteBlob(bytes.Repeat([]byte{0xE0, 0x01, 0x00, 0xEA}, 5000)))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("values")))
+	require.NoError(t, w.BeginList())
+	require.NoError(t, w.WriteInt(-42))
+	require.NoError(t, w.WriteBigInt(new(big.Int).Lsh(big.NewInt(1), 100)))
+	require.NoError(t, w.WriteFloat(1.5))
+	require.NoError(t, w.WriteDecimal(MustParseDecimal("123.456d-7")))
+	require.NoError(t, w.WriteTimestamp(NewTimestamp(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), TimestampPrecisionSecond, TimezoneUTC)))
+	require.NoError(t, w.WriteSymbolFromString("sym"))
+	require.NoError(t, w.EndList())
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.Finish())
+
+	w = NewBinaryWriter(&buf)
+	require.NoError(t, w.WriteSymbolFromString("another"))
+	require.NoError(t, w.Finish())
+	bs := buf.Bytes()
+
+	toText := func(in io.Reader) string {
+		out := strings.Builder{}
+		tw := NewTextWriter(&out)
+		writeFromReaderToWriter(t, NewReader(in), tw)
+		require.NoError(t, tw.Finish())
+		return out.String()
+	}
+
+	expected := toText(bytes.NewReader(bs))
+	assert.Equal(t, expected, toText(iotest.OneByteReader(bytes.NewReader(bs))))
+	assert.Equal(t, expected, toText(iotest.HalfReader(bytes.NewReader(bs))))
+	assert.Equal(t, expected, toText(iotest.DataErrReader(iotest.OneByteReader(bytes.NewReader(bs)))))
+	assert.Equal(t, expected, toText(&stutterReader{in: bytes.NewReader(bs)}))
+
+	// Input that ends part way through a value is an error, not a short value,
+	// whether the value is read or skipped over.
+	for _, truncated := range [][]byte{
+		{0xE0, 0x01, 0x00, 0xEA, 0x8A, 'a', 'b'},
+		{0xE0, 0x01, 0x00, 0xEA, 0x8A, 'a', 'b', 0x20},
+		{0xE0, 0x01, 0x00, 0xEA, 0xE4, 0x81, 0x84},
+	} {
+		r := NewReader(iotest.OneByteReader(bytes.NewReader(truncated)))
+		for r.Next() {
+		}
+		var eof *UnexpectedEOFError
+		assert.True(t, errors.As(r.Err(), &eof), "% X: %v", truncated, r.Err())
+	}
+}
+
+// A stutterReader returns no bytes from every other call to Read, and at most
+// three from the rest, as a slow network connection might.
+type stutterReader struct {
+	in    io.Reader
+	calls int
+}
+
+func (r *stutterReader) Read(p []byte) (int, error) {
+	r.calls++
+	if r.calls%2 == 0 {
+		return 0, nil
+	}
+	if len(p) > 3 {
+		p = p[:3]
+	}
+	return r.in.Read(p)
+}
+
 func readBinary(ion []byte) Reader {
 	prefix := []byte{
 		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
@@ -488,3 +731,74 @@ func readBinary(ion []byte) Reader {
 	}
 	return NewReaderBytes(append(prefix, ion...))
 }
+
+func TestReadBinaryAssumedVersion(t *testing.T) {
+	type rec struct {
+		ID   int    `ion:"id"`
+		Name string `ion:"name,symbol"`
+	}
+
+	data, err := MarshalBinary(rec{1, "one"})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xE0, 0x01, 0x00, 0xEA}, data[:4])
+	fragment := data[4:]
+
+	decode := func(r Reader) rec {
+		var v rec
+		require.NoError(t, NewDecoder(r).DecodeTo(&v))
+		return v
+	}
+
+	r := NewReaderBytes(fragment).WithAssumedVersion(1, 0)
+	assert.Equal(t, rec{1, "one"}, decode(r))
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	// It is kept by Reset.
+	r.ResetBytes(fragment)
+	assert.Equal(t, rec{1, "one"}, decode(r))
+
+	// Input with a version marker is read as usual.
+	r.ResetBytes(data)
+	assert.Equal(t, rec{1, "one"}, decode(r))
+	assert.Equal(t, rec{1, "one"}, decode(NewReaderBytes(data).WithAssumedVersion(1, 0)))
+
+	// Without it, the fragment isn't recognized as binary.
+	var v rec
+	assert.Error(t, Unmarshal(fragment, &v))
+
+	// A fragment holding only system symbols needs no symbol table.
+	r = NewReaderBytes([]byte{0x21, 0x07, 0x71, 0x04}).WithAssumedVersion(1, 0)
+	require.True(t, r.Next())
+	n, err := r.Int64Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), *n)
+	require.True(t, r.Next())
+	sym, err := r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, "name", *sym.Text)
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	r = NewReaderBytes(nil).WithAssumedVersion(1, 0)
+	assert.False(t, r.Next())
+	assert.NoError(t, r.Err())
+
+	r = NewReaderBytes(fragment).WithAssumedVersion(1, 1)
+	assert.False(t, r.Next())
+	var uve *UnsupportedVersionError
+	require.True(t, errors.As(r.Err(), &uve), "%v", r.Err())
+	assert.Equal(t, 1, uve.Minor)
+
+	// It can't be set once reading has started, or on a text-only reader.
+	r = NewReaderBytes(data)
+	require.True(t, r.Next())
+	r.WithAssumedVersion(1, 0)
+	assert.False(t, r.Next())
+	assert.Error(t, r.Err())
+
+	tr := NewTriviaReader(bytes.NewReader(fragment))
+	tr.WithAssumedVersion(1, 0)
+	assert.False(t, tr.Next())
+	assert.Error(t, tr.Err())
+}
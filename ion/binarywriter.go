@@ -21,7 +21,6 @@ import (
 	"io"
 	"math"
 	"math/big"
-	"time"
 )
 
 // A binaryWriter writes binary ion.
@@ -31,8 +30,14 @@ type binaryWriter struct {
 
 	lst  SymbolTable
 	lstb SymbolTableBuilder
+	sts  []SharedSymbolTable
 
 	wroteLST bool
+	finished bool
+
+	// Scratch is reused to serialize each scalar value, and the field name
+	// and annotations before it, which are copied out as they are written.
+	scratch []byte
 }
 
 // NewBinaryWriter creates a new binary writer that will construct a
@@ -43,6 +48,7 @@ func NewBinaryWriter(out io.Writer, sts ...SharedSymbolTable) Writer {
 			out: out,
 		},
 		lstb: NewSymbolTableBuilder(sts...),
+		sts:  sts,
 	}
 	w.bufs.push(&datagram{})
 	return w
@@ -50,6 +56,12 @@ func NewBinaryWriter(out io.Writer, sts ...SharedSymbolTable) Writer {
 
 // NewBinaryWriterLST creates a new binary writer with a pre-built local
 // symbol table.
+//
+// Annotations the table carries after $ion_symbol_table are only written if
+// they are system symbols, such as name; any others are dropped, since a
+// binary reader reads them before the table takes effect, when there is no
+// local symbol to give them. SymbolTable.WriteTo keeps them all, so a text
+// writer can still write them.
 func NewBinaryWriterLST(out io.Writer, lst SymbolTable) Writer {
 	return &binaryWriter{
 		writer: writer{
@@ -61,12 +73,25 @@ func NewBinaryWriterLST(out io.Writer, lst SymbolTable) Writer {
 
 // WriteNull writes an untyped null.
 func (w *binaryWriter) WriteNull() error {
-	return w.writeValue("Writer.WriteNull", []byte{0x0F})
+	return w.writeValue("Writer.WriteNull", func(buf []byte) []byte {
+		return append(buf, 0x0F)
+	})
 }
 
 // WriteNullType writes a typed null.
 func (w *binaryWriter) WriteNullType(t Type) error {
-	return w.writeValue("Writer.WriteNullType", []byte{binaryNulls[t]})
+	return w.writeValue("Writer.WriteNullType", func(buf []byte) []byte {
+		return append(buf, binaryNulls[t])
+	})
+}
+
+// WriteNullWithTypeAndAnnotations writes a typed null with the given
+// annotations.
+func (w *binaryWriter) WriteNullWithTypeAndAnnotations(t Type, annotations ...string) error {
+	if w.err != nil {
+		return w.err
+	}
+	return writeNullWithTypeAndAnnotations(w, t, annotations)
 }
 
 // WriteBool writes a bool.
@@ -75,15 +100,13 @@ func (w *binaryWriter) WriteBool(val bool) error {
 	if val {
 		b = 0x11
 	}
-	return w.writeValue("Writer.WriteBool", []byte{b})
+	return w.writeValue("Writer.WriteBool", func(buf []byte) []byte {
+		return append(buf, b)
+	})
 }
 
 // WriteInt writes an integer.
 func (w *binaryWriter) WriteInt(val int64) error {
-	if val == 0 {
-		return w.writeValue("Writer.WriteInt", []byte{0x20})
-	}
-
 	code := byte(0x20)
 	mag := uint64(val)
 
@@ -92,30 +115,27 @@ func (w *binaryWriter) WriteInt(val int64) error {
 		mag = uint64(-val)
 	}
 
-	length := uintLen(mag)
-	bufLength := length + tagLen(length)
-
-	buf := make([]byte, 0, bufLength)
-	buf = appendTag(buf, code, length)
-	buf = appendUint(buf, mag)
-
-	return w.writeValue("Writer.WriteInt", buf)
+	return w.writeValue("Writer.WriteInt", func(buf []byte) []byte {
+		return appendIntValue(buf, code, mag)
+	})
 }
 
 // WriteUint writes an unsigned integer.
 func (w *binaryWriter) WriteUint(val uint64) error {
-	if val == 0 {
-		return w.writeValue("Writer.WriteUint", []byte{0x20})
-	}
-
-	length := uintLen(val)
-	bufLength := length + tagLen(length)
+	return w.writeValue("Writer.WriteUint", func(buf []byte) []byte {
+		return appendIntValue(buf, 0x20, val)
+	})
+}
 
-	buf := make([]byte, 0, bufLength)
-	buf = appendTag(buf, 0x20, length)
-	buf = appendUint(buf, val)
+// AppendIntValue appends an int with the given type code and magnitude.
+func appendIntValue(buf []byte, code byte, mag uint64) []byte {
+	if mag == 0 {
+		return append(buf, 0x20)
+	}
 
-	return w.writeValue("Writer.WriteUint", buf)
+	length := uintLen(mag)
+	buf = appendTag(buf, code, length)
+	return appendUint(buf, mag)
 }
 
 // WriteBigInt writes a big integer.
@@ -163,87 +183,73 @@ func (w *binaryWriter) writeBigInt(val *big.Int) error {
 	if err := w.writeTag(code, bl); err != nil {
 		return err
 	}
-	return w.write(bs)
+	return w.emit(atom(bs))
 }
 
 // WriteFloat writes a floating-point value.
 func (w *binaryWriter) WriteFloat(val float64) error {
-	if val == 0 && !math.Signbit(val) {
-		// Positive zero is represented as just one byte.
-		return w.writeValue("Writer.WriteFloat", []byte{0x40})
-	} else if math.IsNaN(val) {
-		return w.writeValue("Writer.WriteFloat", []byte{0x44, 0x7F, 0xC0, 0x00, 0x00})
-	}
-
-	var bs []byte
-
-	// Can this be losslessly represented as a float32?
-	if val == float64(float32(val)) {
-		bs = make([]byte, 5)
-		bs[0] = 0x44
-
-		bits := math.Float32bits(float32(val))
-		binary.BigEndian.PutUint32(bs[1:], bits)
-	} else {
-		bs = make([]byte, 9)
-		bs[0] = 0x48
+	return w.writeValue("Writer.WriteFloat", func(buf []byte) []byte {
+		if val == 0 && !math.Signbit(val) {
+			// Positive zero is represented as just one byte.
+			return append(buf, 0x40)
+		} else if math.IsNaN(val) {
+			return append(buf, 0x44, 0x7F, 0xC0, 0x00, 0x00)
+		}
 
-		bits := math.Float64bits(val)
-		binary.BigEndian.PutUint64(bs[1:], bits)
-	}
+		// Can this be losslessly represented as a float32?
+		if val == float64(float32(val)) {
+			buf = append(buf, 0x44, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(buf[len(buf)-4:], math.Float32bits(float32(val)))
+			return buf
+		}
 
-	return w.writeValue("Writer.WriteFloat", bs)
+		buf = append(buf, 0x48, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(buf[len(buf)-8:], math.Float64bits(val))
+		return buf
+	})
 }
 
 // WriteDecimal writes a decimal value.
 func (w *binaryWriter) WriteDecimal(val *Decimal) error {
-	coef, exp := val.CoEx()
-
-	// If the value is positive 0. (aka 0d0) then L is zero, there are no length or
-	// representation fields, and the entire value is encoded as the single byte 0x50.
-	if coef.Sign() == 0 && int64(exp) == 0 && !val.isNegZero {
-		return w.writeValue("Writer.WriteDecimal", []byte{0x50})
+	if !val.isValid() {
+		return &UsageError{"Writer.WriteDecimal", "decimal has no value"}
 	}
+	coef, exp := val.CoEx()
 
-	// Otherwise, length or representation fields are present and must be considered.
-	vlength := varIntLen(int64(exp))
-
-	if val.isNegZero {
-		vlength++
-	} else {
-		vlength += bigIntLen(coef)
-	}
+	return w.writeValue("Writer.WriteDecimal", func(buf []byte) []byte {
+		// If the value is positive 0. (aka 0d0) then L is zero, there are no length or
+		// representation fields, and the entire value is encoded as the single byte 0x50.
+		if coef.Sign() == 0 && int64(exp) == 0 && !val.isNegZero {
+			return append(buf, 0x50)
+		}
 
-	bufLength := vlength + tagLen(vlength)
-	buf := make([]byte, 0, bufLength)
+		// Otherwise, length or representation fields are present and must be considered.
+		vlength := varIntLen(int64(exp))
 
-	buf = appendTag(buf, 0x50, vlength)
-	buf = appendVarInt(buf, int64(exp))
+		if val.isNegZero {
+			vlength++
+		} else {
+			vlength += bigIntLen(coef)
+		}
 
-	if val.isNegZero {
-		buf = append(buf, 0x80)
-	} else {
-		buf = appendBigInt(buf, coef)
-	}
+		buf = appendTag(buf, 0x50, vlength)
+		buf = appendVarInt(buf, int64(exp))
 
-	return w.writeValue("Writer.WriteDecimal", buf)
+		if val.isNegZero {
+			return append(buf, 0x80)
+		}
+		return appendBigInt(buf, coef)
+	})
 }
 
 // WriteTimestamp writes a timestamp value.
 func (w *binaryWriter) WriteTimestamp(val Timestamp) error {
-	_, offset := val.dateTime.Zone()
-	offset /= 60
-	val.dateTime = val.dateTime.In(time.UTC)
-
-	vlength := timestampLen(offset, val)
-	bufLength := vlength + tagLen(vlength)
-
-	buf := make([]byte, 0, bufLength)
-
-	buf = appendTag(buf, 0x60, vlength)
-	buf = appendTimestamp(buf, offset, val)
+	offset, val := utcTimestamp(val)
 
-	return w.writeValue("Writer.WriteTimestamp", buf)
+	return w.writeValue("Writer.WriteTimestamp", func(buf []byte) []byte {
+		buf = appendTag(buf, 0x60, timestampLen(offset, val))
+		return appendTimestamp(buf, offset, val)
+	})
 }
 
 // WriteSymbol writes a symbol value given a SymbolToken.
@@ -276,30 +282,18 @@ func (w *binaryWriter) WriteSymbolFromString(val string) error {
 }
 
 func (w *binaryWriter) writeSymbolFromID(api string, id uint64) error {
-	vlength := uintLen(id)
-	bufLength := vlength + tagLen(vlength)
-	buf := make([]byte, 0, bufLength)
-
-	buf = appendTag(buf, 0x70, vlength)
-	buf = appendUint(buf, id)
-
-	return w.writeValue(api, buf)
+	return w.writeValue(api, func(buf []byte) []byte {
+		buf = appendTag(buf, 0x70, uintLen(id))
+		return appendUint(buf, id)
+	})
 }
 
 // WriteString writes a string.
 func (w *binaryWriter) WriteString(val string) error {
-	if len(val) == 0 {
-		return w.writeValue("Writer.WriteString", []byte{0x80})
-	}
-
-	vlength := uint64(len(val))
-	bufLength := vlength + tagLen(vlength)
-	buf := make([]byte, 0, bufLength)
-
-	buf = appendTag(buf, 0x80, vlength)
-	buf = append(buf, val...)
-
-	return w.writeValue("Writer.WriteString", buf)
+	return w.writeValue("Writer.WriteString", func(buf []byte) []byte {
+		buf = appendTag(buf, 0x80, uint64(len(val)))
+		return append(buf, val...)
+	})
 }
 
 // WriteClob writes a clob.
@@ -336,6 +330,30 @@ func (w *binaryWriter) WriteBlob(val []byte) error {
 	return w.err
 }
 
+// WriteValue marshals a Go value and writes it.
+func (w *binaryWriter) WriteValue(v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	return MarshalTo(w, v)
+}
+
+// WriteFieldIf writes a field with the given name and value if cond is true.
+func (w *binaryWriter) WriteFieldIf(cond bool, name string, v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	return writeFieldIf(w, cond, name, v)
+}
+
+// WriteListFromChan writes a list of the values received from ch.
+func (w *binaryWriter) WriteListFromChan(ch <-chan interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	return writeListFromChan(w, ch)
+}
+
 func (w *binaryWriter) writeLob(code byte, val []byte) error {
 	vlength := uint64(len(val))
 
@@ -352,7 +370,7 @@ func (w *binaryWriter) writeLob(code byte, val []byte) error {
 	if err := w.writeTag(code, vlength); err != nil {
 		return err
 	}
-	return w.write(val)
+	return w.emit(atom(val))
 }
 
 // BeginList begins writing a list.
@@ -422,18 +440,75 @@ func (w *binaryWriter) Finish() error {
 			panic("at top level but too many bufseqs")
 		}
 
-		lst := w.lstb.Build()
-		if err := w.writeLST(lst); err != nil {
-			return err
-		}
-		if w.err = w.emit(seq); w.err != nil {
-			return w.err
+		// Write out this datagram, unless it's an empty one following another.
+		if seq.Len() > 0 || !w.finished {
+			lst := w.lstb.Build()
+			if err := w.writeLST(lst); err != nil {
+				return err
+			}
+			if w.err = w.emit(seq); w.err != nil {
+				return w.err
+			}
 		}
+
+		// Anything written from here on is a new datagram, with its own
+		// local symbol table.
+		w.lstb = NewSymbolTableBuilder(w.sts...)
+		w.bufs.push(&datagram{})
 	}
 
+	w.finished = true
 	return nil
 }
 
+// Reset discards the writer's state and directs its output to out.
+func (w *binaryWriter) Reset(out io.Writer) {
+	w.writer.reset(out)
+	w.bufs.arr = w.bufs.arr[:0]
+	w.wroteLST = false
+	w.finished = false
+
+	if w.lst == nil {
+		w.lstb = NewSymbolTableBuilder(w.sts...)
+		w.bufs.push(&datagram{})
+	}
+}
+
+// Flush writes out any completed top-level values and flushes the underlying
+// io.Writer. Binary values are only written once their length is known, so
+// nothing from a top-level value that is still being written (e.g. a
+// container that has not been ended yet) is flushed.
+//
+// If the writer is building its own local symbol table, the values flushed so
+// far are preceded by a version marker and the symbol table as it stands, and
+// the next batch will be preceded by a new version marker and the (possibly
+// grown) symbol table in turn.
+func (w *binaryWriter) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.lst == nil && w.ctx.peek() == ctxAtTopLevel {
+		seq := w.bufs.peek()
+		if seq != nil && seq.Len() > 0 {
+			w.bufs.pop()
+
+			lst := w.lstb.Build()
+			if w.err = w.writeLST(lst); w.err != nil {
+				return w.err
+			}
+			if w.err = w.emit(seq); w.err != nil {
+				return w.err
+			}
+
+			w.bufs.push(&datagram{})
+		}
+	}
+
+	w.err = w.flushOut()
+	return w.err
+}
+
 // Emit emits the given node. If we're currently at the top level, that
 // means actually emitting to the output stream. If not, we emit append
 // to the current bufseq.
@@ -446,13 +521,22 @@ func (w *binaryWriter) emit(node bufnode) error {
 	return nil
 }
 
-// Write emits the given bytes as an atom.
+// Write emits the given bytes, copying them if they're to be buffered, so
+// that the caller may reuse them.
 func (w *binaryWriter) write(bs []byte) error {
-	return w.emit(atom(bs))
+	s := w.bufs.peek()
+	if s == nil {
+		_, err := w.out.Write(bs)
+		return err
+	}
+	s.AppendBytes(bs)
+	return nil
 }
 
-// WriteValue writes a serialized value to the output stream.
-func (w *binaryWriter) writeValue(api string, val []byte) error {
+// WriteValue writes a scalar value to the output stream, serializing it
+// with appendValue, which appends it to the given buffer and returns the
+// result.
+func (w *binaryWriter) writeValue(api string, appendValue func(buf []byte) []byte) error {
 	if w.err != nil {
 		return w.err
 	}
@@ -460,7 +544,9 @@ func (w *binaryWriter) writeValue(api string, val []byte) error {
 		return w.err
 	}
 
-	if w.err = w.write(val); w.err != nil {
+	buf := appendValue(w.scratch[:0])
+	w.keepScratch(buf)
+	if w.err = w.write(buf); w.err != nil {
 		return w.err
 	}
 
@@ -468,6 +554,14 @@ func (w *binaryWriter) writeValue(api string, val []byte) error {
 	return w.err
 }
 
+// KeepScratch keeps buf, which grew from the scratch buffer, for reuse,
+// unless it has grown too big to be worth holding on to.
+func (w *binaryWriter) keepScratch(buf []byte) {
+	if cap(buf) > cap(w.scratch) && cap(buf) <= 1024 {
+		w.scratch = buf[:0]
+	}
+}
+
 // WriteTag writes out a type+length tag. Use me when you've already got the value to
 // be written as a []byte and don't want to copy it.
 func (w *binaryWriter) writeTag(code byte, length uint64) error {
@@ -484,7 +578,32 @@ func (w *binaryWriter) writeLST(lst SymbolTable) error {
 	if err := w.write([]byte{0xE0, 0x01, 0x00, 0xEA}); err != nil {
 		return err
 	}
-	return lst.WriteTo(w)
+	return systemLSTAnnotations(lst).WriteTo(w)
+}
+
+// SystemLSTAnnotations returns the table without any annotations that can't
+// be written in binary, which are dropped as they were before tables kept
+// their annotations. They're read before the table takes effect, straight
+// after the version marker, so they have to be system symbols.
+func systemLSTAnnotations(st SymbolTable) SymbolTable {
+	t, ok := st.(*lst)
+	if !ok {
+		return st
+	}
+
+	var as []string
+	for _, a := range t.annotations {
+		if _, ok := V1SystemSymbolTable.FindByName(a); ok {
+			as = append(as, a)
+		}
+	}
+	if len(as) == len(t.annotations) {
+		return st
+	}
+
+	c := *t
+	c.annotations = as
+	return &c
 }
 
 // BeginValue begins the process of writing a value by writing out
@@ -522,8 +641,8 @@ func (w *binaryWriter) beginValue(api string) error {
 			return &UsageError{api, "field name symbol token does not have defined text or symbol id."}
 		}
 
-		buf := make([]byte, 0, 10)
-		buf = appendVarUint(buf, id)
+		buf := appendVarUint(w.scratch[:0], id)
+		w.keepScratch(buf)
 		if err := w.write(buf); err != nil {
 			return err
 		}
@@ -537,7 +656,9 @@ func (w *binaryWriter) beginValue(api string) error {
 		var err error
 		for i, a := range as {
 			if a.Text != nil {
-				id, err = w.resolve(api, *a.Text)
+				// The text is taken as is, so '' and '$0' are written as
+				// symbols with that text rather than as symbol IDs.
+				id, err = w.resolveFromSymbolTable(api, *a.Text)
 				if err != nil {
 					return err
 				}
@@ -551,13 +672,11 @@ func (w *binaryWriter) beginValue(api string) error {
 			idlen += varUintLen(id)
 		}
 
-		bufLength := idlen + varUintLen(idlen)
-		buf := make([]byte, 0, bufLength)
-
-		buf = appendVarUint(buf, idlen)
+		buf := appendVarUint(w.scratch[:0], idlen)
 		for _, id := range ids {
 			buf = appendVarUint(buf, id)
 		}
+		w.keepScratch(buf)
 
 		// https://github.com/amzn/ion-go/issues/120
 		w.bufs.push(&container{code: 0xE0})
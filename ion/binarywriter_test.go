@@ -19,6 +19,8 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
 	"math/big"
 	"strings"
@@ -344,6 +346,229 @@ func TestWriteBinaryNulls(t *testing.T) {
 	})
 }
 
+func TestWriteBinaryNullWithTypeAndAnnotations(t *testing.T) {
+	eval := []byte{
+		0xE3, 0x81, 0xEE, 0x2F, // foo::null.int
+		0xE4, 0x82, 0xEE, 0xEF, 0x0F, // foo::bar::null
+		0xDF, // null.struct
+	}
+
+	testBinaryWriter(t, eval, func(w Writer) {
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(IntType, "foo"))
+		assert.NoError(t, w.Annotation(NewSymbolTokenFromString("foo")))
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(NullType, "bar"))
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(StructType))
+	})
+}
+
+func TestNullWithTypeAndAnnotationsRoundTrip(t *testing.T) {
+	types := []Type{NullType, BoolType, IntType, FloatType, DecimalType, TimestampType, SymbolType,
+		StringType, ClobType, BlobType, ListType, SexpType, StructType}
+
+	test := func(name string, newWriter func(io.Writer) Writer) {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			w := newWriter(&buf)
+			require.NoError(t, w.BeginStruct())
+			for _, typ := range types {
+				require.NoError(t, w.FieldName(NewSymbolTokenFromString("f")))
+				require.NoError(t, w.WriteNullWithTypeAndAnnotations(typ, "foo", typ.String()))
+			}
+			require.NoError(t, w.EndStruct())
+			for _, typ := range types {
+				require.NoError(t, w.WriteNullWithTypeAndAnnotations(typ, "bar"))
+			}
+			require.NoError(t, w.Finish())
+
+			check := func(r Reader, typ Type, annotations ...string) {
+				require.True(t, r.Next(), "%v: %v", typ, r.Err())
+				assert.Equal(t, typ, r.Type())
+				assert.True(t, r.IsNull())
+				as, err := r.Annotations()
+				require.NoError(t, err)
+				var texts []string
+				for _, a := range as {
+					texts = append(texts, *a.Text)
+				}
+				assert.Equal(t, annotations, texts)
+			}
+
+			r := NewReaderBytes(buf.Bytes())
+			require.True(t, r.Next())
+			require.NoError(t, r.StepIn())
+			for _, typ := range types {
+				check(r, typ, "foo", typ.String())
+			}
+			assert.False(t, r.Next())
+			require.NoError(t, r.StepOut())
+			for _, typ := range types {
+				check(r, typ, "bar")
+			}
+			assert.False(t, r.Next())
+			require.NoError(t, r.Err())
+		})
+	}
+
+	test("text", func(out io.Writer) Writer { return NewTextWriter(out) })
+	test("binary", func(out io.Writer) Writer { return NewBinaryWriter(out) })
+}
+
+func TestWriteBinaryFlush(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+
+	assert.NoError(t, w.WriteSymbolFromString("foo"))
+	assert.Equal(t, 0, buf.Len())
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0xE9, 0x81, 0x83, 0xD6, // $ion_symbol_table::{
+		0x87, 0xB4, 0x83, 'f', 'o', 'o', // symbols:["foo"]
+		// }
+		0x71, 0x0A, // foo
+	}, buf.Bytes())
+
+	// A partially-written container is not flushed.
+	assert.NoError(t, w.BeginList())
+	assert.NoError(t, w.WriteSymbolFromString("bar"))
+	flushed := buf.Len()
+	require.NoError(t, w.Flush())
+	assert.Equal(t, flushed, buf.Len())
+
+	assert.NoError(t, w.EndList())
+	require.NoError(t, w.Finish())
+
+	r := NewReaderBytes(buf.Bytes())
+	assert.True(t, r.Next())
+	val, err := r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", *val.Text)
+
+	assert.True(t, r.Next())
+	require.NoError(t, r.StepIn())
+	assert.True(t, r.Next())
+	val, err = r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", *val.Text)
+	require.NoError(t, r.StepOut())
+
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+}
+
+func TestWriteBinaryValue(t *testing.T) {
+	type payload struct {
+		ID   int      `ion:"id"`
+		Tags []string `ion:"tags"`
+	}
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("header")))
+	require.NoError(t, w.WriteString("v1"))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("payload")))
+	require.NoError(t, w.WriteValue(&payload{42, []string{"a", "b"}}))
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.Finish())
+
+	var res struct {
+		Header  string  `ion:"header"`
+		Payload payload `ion:"payload"`
+	}
+	require.NoError(t, Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, "v1", res.Header)
+	assert.Equal(t, payload{42, []string{"a", "b"}}, res.Payload)
+
+	// Values that can't be marshaled report an error.
+	w = NewBinaryWriter(&bytes.Buffer{})
+	assert.Error(t, w.WriteValue(make(chan int)))
+}
+
+func TestWriteBinaryFieldIf(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.WriteFieldIf(true, "name", "x"))
+	require.NoError(t, w.WriteFieldIf(false, "id", 42))
+	require.NoError(t, w.WriteFieldIf(true, "size", 7))
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.Finish())
+
+	var res map[string]interface{}
+	require.NoError(t, Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, map[string]interface{}{"name": "x", "size": 7}, res)
+}
+
+func TestWriteBinaryListFromChan(t *testing.T) {
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for i := 0; i < 100; i++ {
+			ch <- i
+		}
+	}()
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.WriteListFromChan(ch))
+	require.NoError(t, w.Finish())
+
+	var res []int
+	require.NoError(t, Unmarshal(buf.Bytes(), &res))
+	require.Len(t, res, 100)
+	assert.Equal(t, 99, res[99])
+}
+
+func TestWriteBinaryReset(t *testing.T) {
+	doc := func(w Writer) {
+		require.NoError(t, w.BeginStruct())
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("name")))
+		require.NoError(t, w.WriteSymbolFromString("value"))
+		require.NoError(t, w.EndStruct())
+		require.NoError(t, w.WriteInt(42))
+		require.NoError(t, w.Finish())
+	}
+
+	shared := NewSharedSymbolTable("shared", 1, []string{"name"})
+
+	test := func(name string, newWriter func(out io.Writer) Writer) {
+		t.Run(name, func(t *testing.T) {
+			fresh := bytes.Buffer{}
+			doc(newWriter(&fresh))
+
+			// Leave the writer with collected symbols, an unfinished
+			// container, and pending annotations before resetting it.
+			w := newWriter(&bytes.Buffer{})
+			require.NoError(t, w.WriteSymbolFromString("other"))
+			require.NoError(t, w.BeginList())
+			require.NoError(t, w.Annotation(NewSymbolTokenFromString("ann")))
+
+			reused := bytes.Buffer{}
+			w.Reset(&reused)
+			doc(w)
+			assert.Equal(t, fmtbytes(fresh.Bytes()), fmtbytes(reused.Bytes()))
+
+			// And again, after a clean finish.
+			again := bytes.Buffer{}
+			w.Reset(&again)
+			doc(w)
+			assert.Equal(t, fmtbytes(fresh.Bytes()), fmtbytes(again.Bytes()))
+		})
+	}
+
+	test("builder", func(out io.Writer) Writer {
+		return NewBinaryWriter(out)
+	})
+	test("shared", func(out io.Writer) Writer {
+		return NewBinaryWriter(out, shared)
+	})
+	test("lst", func(out io.Writer) Writer {
+		return NewBinaryWriterLST(out, NewLocalSymbolTable(nil, []string{"name", "value", "other", "ann"}))
+	})
+}
+
 func testBinaryWriter(t *testing.T, eval []byte, f func(w Writer)) {
 	val := writeBinary(t, f)
 
@@ -402,3 +627,18 @@ func writeBinary(t *testing.T, f func(w Writer)) []byte {
 
 	return buf.Bytes()
 }
+
+func BenchmarkWriteScalars(b *testing.B) {
+	bench := func(name string, w func(io.Writer) Writer) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := writeScalars(w(ioutil.Discard), 10000); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	bench("text", func(out io.Writer) Writer { return NewTextWriter(out) })
+	bench("binary", func(out io.Writer) Writer { return NewBinaryWriter(out) })
+}
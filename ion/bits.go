@@ -17,6 +17,7 @@ package ion
 
 import (
 	"math/big"
+	"time"
 )
 
 // uintLen pre-calculates the length, in bytes, of the given uint value.
@@ -258,6 +259,14 @@ func appendTag(b []byte, code byte, length uint64) []byte {
 	return appendVarUint(b, length)
 }
 
+// utcTimestamp splits a timestamp into its offset in minutes and its UTC time,
+// the form in which binary Ion encodes it.
+func utcTimestamp(ts Timestamp) (int, Timestamp) {
+	_, offset := ts.dateTime.Zone()
+	ts.dateTime = ts.dateTime.In(time.UTC)
+	return offset / 60, ts
+}
+
 // timestampLen pre-calculates the length, in bytes, of the given timestamp value.
 func timestampLen(offset int, utc Timestamp) uint64 {
 	var ret uint64
@@ -305,30 +314,34 @@ func appendTimestamp(b []byte, offset int, utc Timestamp) []byte {
 		b = appendVarInt(b, int64(offset))
 	}
 
-	// We expect at least Year precision.
-	b = appendVarUint(b, uint64(utc.dateTime.Year()))
+	// We expect at least Year precision. Date and Clock each work out all of
+	// their fields at once, which is cheaper than asking for them one by one.
+	year, month, day := utc.dateTime.Date()
+	hour, minute, second := utc.dateTime.Clock()
+
+	b = appendVarUint(b, uint64(year))
 
 	switch utc.precision {
 	case TimestampPrecisionMonth:
-		b = appendVarUint(b, uint64(utc.dateTime.Month()))
+		b = appendVarUint(b, uint64(month))
 	case TimestampPrecisionDay:
-		b = appendVarUint(b, uint64(utc.dateTime.Month()))
-		b = appendVarUint(b, uint64(utc.dateTime.Day()))
+		b = appendVarUint(b, uint64(month))
+		b = appendVarUint(b, uint64(day))
 	case TimestampPrecisionMinute:
-		b = appendVarUint(b, uint64(utc.dateTime.Month()))
-		b = appendVarUint(b, uint64(utc.dateTime.Day()))
+		b = appendVarUint(b, uint64(month))
+		b = appendVarUint(b, uint64(day))
 
 		// The hour and minute is considered as a single component.
-		b = appendVarUint(b, uint64(utc.dateTime.Hour()))
-		b = appendVarUint(b, uint64(utc.dateTime.Minute()))
+		b = appendVarUint(b, uint64(hour))
+		b = appendVarUint(b, uint64(minute))
 	case TimestampPrecisionSecond, TimestampPrecisionNanosecond:
-		b = appendVarUint(b, uint64(utc.dateTime.Month()))
-		b = appendVarUint(b, uint64(utc.dateTime.Day()))
+		b = appendVarUint(b, uint64(month))
+		b = appendVarUint(b, uint64(day))
 
 		// The hour and minute is considered as a single component.
-		b = appendVarUint(b, uint64(utc.dateTime.Hour()))
-		b = appendVarUint(b, uint64(utc.dateTime.Minute()))
-		b = appendVarUint(b, uint64(utc.dateTime.Second()))
+		b = appendVarUint(b, uint64(hour))
+		b = appendVarUint(b, uint64(minute))
+		b = appendVarUint(b, uint64(second))
 	}
 
 	if utc.precision == TimestampPrecisionNanosecond && utc.numFractionalSeconds > 0 {
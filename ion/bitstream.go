@@ -112,9 +112,30 @@ type bitstream struct {
 	state bss
 	stack bitstack
 
-	code bitcode
-	null bool
-	len  uint64
+	code  bitcode
+	null  bool
+	len   uint64
+	start uint64
+
+	scratch []byte
+
+	// If stopAtText is set, Next stops as if at the end of the input when it
+	// finds a text version marker at the top level, leaving the marker unread
+	// for a text reader to pick up; atText records that it did.
+	stopAtText bool
+	atText     bool
+
+	// If onNonCanonical is set, it is called with each valid encoding the
+	// stream reads that a canonical writer would not have produced. Ordered
+	// records whether the current value is an ordered struct.
+	onNonCanonical func(NonCanonicalEncoding)
+	ordered        bool
+
+	// If keepTape is set, every byte read is recorded in tape, which starts
+	// at offset tapeStart in the input, for readers that keep raw values.
+	keepTape  bool
+	tape      bytes.Buffer
+	tapeStart uint64
 }
 
 // Init initializes this stream with the given bufio.Reader.
@@ -173,7 +194,15 @@ func (b *bitstream) Next() error {
 		return nil
 	}
 
+	// A text version marker means the stream switches to text here.
+	if b.stopAtText && b.stack.empty() && b.atTextIVM() {
+		b.atText = true
+		b.code = bitcodeEOF
+		return nil
+	}
+
 	// Otherwise it's time to read a value. Read the tag byte.
+	b.start = b.pos
 	c, err := b.read()
 	if err != nil {
 		return err
@@ -191,7 +220,8 @@ func (b *bitstream) Next() error {
 	// Structs with a length code of 1 are a special case. Their length is always encoded
 	// as a VarUInt and their field names appear in ascending symbol ID order.
 	if code == bitcodeStruct && length == 1 {
-		length, _, err = b.readVarUintLen(b.remaining())
+		var lengthOfLength uint64
+		length, lengthOfLength, err = b.readVarUintLen(b.remaining())
 		if err != nil {
 			return err
 		}
@@ -199,6 +229,8 @@ func (b *bitstream) Next() error {
 			// Ordered structs must have at least one symbol/value pair.
 			return &SyntaxError{"ordered structs cannot be empty", b.pos - 1}
 		}
+		b.checkVarUintLen("length", length, lengthOfLength, b.pos-lengthOfLength)
+		b.ordered = true
 	}
 
 	if code == bitcodeNone {
@@ -207,6 +239,10 @@ func (b *bitstream) Next() error {
 
 	b.state = bssOnValue
 
+	if code == bitcodeNull && length != 0x0F {
+		b.nonCanonical("NOP padding", b.pos-1)
+	}
+
 	if code == bitcodeAnnotation {
 		switch length {
 		case 0:
@@ -256,6 +292,11 @@ func (b *bitstream) Next() error {
 			return err
 		}
 		rem -= lenghtOfRemaining
+
+		if length < 0x0E {
+			b.nonCanonical(fmt.Sprintf("length %v written as a VarUInt rather than in the type descriptor", length), pos-1)
+		}
+		b.checkVarUintLen("length", length, lenghtOfRemaining, pos)
 	}
 
 	if length > rem {
@@ -310,7 +351,7 @@ func (b *bitstream) StepIn() {
 		panic(fmt.Sprintf("StepIn called with b.code=%v", b.code))
 	}
 
-	b.stack.push(b.code, b.pos+b.len)
+	b.stack.push(b.code, b.pos+b.len, b.ordered)
 	b.clear()
 }
 
@@ -379,19 +420,30 @@ func (b *bitstream) ReadFieldID() (uint64, error) {
 		panic("not a field ID")
 	}
 
-	id, err := b.readVarUint()
+	pos := b.pos
+	id, idlen, err := b.readVarUintLen(b.remaining())
 	if err != nil {
 		return 0, err
 	}
 
+	if b.onNonCanonical != nil {
+		b.checkVarUintLen("field ID", id, idlen, pos)
+		if top := &b.stack.arr[len(b.stack.arr)-1]; top.ordered {
+			if int64(id) < top.lastID {
+				b.nonCanonical(fmt.Sprintf("field $%v after $%v in an ordered struct", id, top.lastID), pos)
+			}
+			top.lastID = int64(id)
+		}
+	}
+
 	b.state = bssBeforeValue
 	b.code = bitcodeNone
 
 	return id, nil
 }
 
-// ReadAnnotations reads a set of annotation IDs and returns a set of SymbolTokens.
-func (b *bitstream) ReadAnnotations(symbolTable SymbolTable) ([]SymbolToken, error) {
+// ReadAnnotationIDs reads a set of annotation IDs, appending them to ids.
+func (b *bitstream) ReadAnnotationIDs(ids []uint64) ([]uint64, error) {
 	if b.code != bitcodeAnnotation {
 		panic("not an annotation")
 	}
@@ -415,19 +467,13 @@ func (b *bitstream) ReadAnnotations(symbolTable SymbolTable) ([]SymbolToken, err
 		return nil, &SyntaxError{"malformed annotation", b.pos - lengthOfAnnotFieldLength}
 	}
 
-	var as []SymbolToken
 	for annotFieldLength > 0 {
 		id, idlen, err := b.readVarUintLen(annotFieldLength)
 		if err != nil {
 			return nil, err
 		}
 
-		token, err := NewSymbolTokenBySID(symbolTable, int64(id))
-		if err != nil {
-			return nil, err
-		}
-
-		as = append(as, token)
+		ids = append(ids, id)
 
 		annotFieldLength -= idlen
 	}
@@ -440,7 +486,7 @@ func (b *bitstream) ReadAnnotations(symbolTable SymbolTable) ([]SymbolToken, err
 	b.state = bssBeforeValue
 	b.clear()
 
-	return as, nil
+	return ids, nil
 }
 
 func (b *bitstream) validateAnnotatedValue(remainingLength uint64) error {
@@ -505,13 +551,26 @@ func (b *bitstream) validateAnnotatedValue(remainingLength uint64) error {
 	return nil
 }
 
-// ReadInt reads an integer value.
-func (b *bitstream) ReadInt() (interface{}, error) {
+// ClearTape empties the tape, so that it starts at the current position.
+func (b *bitstream) clearTape() {
+	b.tape.Reset()
+	b.tapeStart = b.pos
+}
+
+// Taped returns the bytes of input read from the given offset on, which must
+// be on the tape.
+func (b *bitstream) taped(from uint64) []byte {
+	return b.tape.Bytes()[from-b.tapeStart:]
+}
+
+// ReadInt reads an integer value. It returns an int64 if the value fits in
+// one, and otherwise fills in and returns m.
+func (b *bitstream) ReadInt(m *intMagnitude) (interface{}, error) {
 	if b.code != bitcodeInt && b.code != bitcodeNegInt {
 		panic("not an integer")
 	}
 
-	bs, err := b.readN(b.len)
+	bs, err := b.readScratch(b.len)
 	if err != nil {
 		return "", err
 	}
@@ -538,13 +597,14 @@ func (b *bitstream) ReadInt() (interface{}, error) {
 		ret = i
 
 	default:
-		// Need to go big.Int.
-		i := new(big.Int).SetBytes(bs)
-		isZero = i.BitLen() == 0
-		if b.code == bitcodeNegInt {
-			i = i.Neg(i)
+		// Keep the magnitude as it is, for the reader to go big.Int if asked.
+		for len(bs) > 0 && bs[0] == 0 {
+			bs = bs[1:]
 		}
-		ret = i
+		isZero = len(bs) == 0
+		m.mag = append(m.mag[:0], bs...)
+		m.neg = b.code == bitcodeNegInt
+		ret = m
 	}
 
 	// Zero is always stored as positive; negative zero is illegal.
@@ -623,7 +683,7 @@ func (b *bitstream) ReadTimestamp() (Timestamp, error) {
 	}
 	length -= olength
 
-	ts := []int{1, 1, 1, 0, 0, 0}
+	ts := [6]int{1, 1, 1, 0, 0, 0}
 	precision := TimestampNoPrecision
 	for i := 0; length > 0 && i < 6 && precision < TimestampPrecisionSecond; i++ {
 		val, vlength, err := b.readVarUintLen(length)
@@ -645,6 +705,9 @@ func (b *bitstream) ReadTimestamp() (Timestamp, error) {
 			precision++
 		}
 	}
+	if precision == TimestampNoPrecision {
+		return Timestamp{}, &SyntaxError{"invalid timestamp - no year", b.pos}
+	}
 
 	nsecs := 0
 	overflow := false
@@ -662,7 +725,7 @@ func (b *bitstream) ReadTimestamp() (Timestamp, error) {
 		}
 	}
 
-	timestamp, err := tryCreateTimestamp(ts, nsecs, overflow, offset, osign, precision, fractionPrecision)
+	timestamp, err := tryCreateTimestamp(ts[:], nsecs, overflow, offset, osign, precision, fractionPrecision)
 	if err != nil {
 		return Timestamp{}, err
 	}
@@ -716,9 +779,15 @@ func (b *bitstream) readNsecs(length uint64) (int, bool, uint8, error) {
 // varInt, followed by an integer coefficient taking up the remaining bytes.
 func (b *bitstream) readDecimal(length uint64) (*Decimal, error) {
 	exp := int64(0)
-	coef := new(big.Int)
 	negZero := false
 
+	// Allocate the decimal and its coefficient together.
+	dc := &struct {
+		d Decimal
+		n big.Int
+	}{}
+	coef := &dc.n
+
 	if length > 0 {
 		val, _, vlength, err := b.readVarIntLen(length)
 		if err != nil {
@@ -742,7 +811,8 @@ func (b *bitstream) readDecimal(length uint64) (*Decimal, error) {
 		negZero = coef.Sign() == 0
 	}
 
-	return NewDecimal(coef, int32(exp), negZero), nil
+	dc.d = Decimal{n: coef, scale: -int32(exp), isNegZero: negZero}
+	return &dc.d, nil
 }
 
 // ReadSymbolID reads a symbol value.
@@ -777,7 +847,7 @@ func (b *bitstream) ReadString() (string, error) {
 		panic("not a string")
 	}
 
-	bs, err := b.readN(b.len)
+	bs, err := b.readScratch(b.len)
 	if err != nil {
 		return "", err
 	}
@@ -820,12 +890,30 @@ func (b *bitstream) clear() {
 	b.code = bitcodeNone
 	b.null = false
 	b.len = 0
+	b.ordered = false
+}
+
+// NonCanonical passes a non-canonical encoding at the given offset to the
+// onNonCanonical function, if there is one.
+func (b *bitstream) nonCanonical(msg string, offset uint64) {
+	if b.onNonCanonical != nil {
+		b.onNonCanonical(NonCanonicalEncoding{msg, offset})
+	}
+}
+
+// CheckVarUintLen reports a VarUInt of the given value that was n bytes long,
+// starting at the given offset, if it could have been shorter.
+func (b *bitstream) checkVarUintLen(what string, val, n, offset uint64) {
+	if n > varUintLen(val) {
+		b.nonCanonical(fmt.Sprintf("%v %v padded to %v bytes", what, val, n), offset)
+	}
 }
 
 // ReadBigInt reads a fixed-length integer of the given length and stores
 // the value in the given big.Int.
 func (b *bitstream) readBigInt(length uint64, ret *big.Int) error {
-	bs, err := b.readN(length)
+	// SetBytes copies, so there's no need for a fresh buffer.
+	bs, err := b.readScratch(length)
 	if err != nil {
 		return err
 	}
@@ -1019,9 +1107,29 @@ func (b *bitstream) readN(n uint64) ([]byte, error) {
 		return nil, nil
 	}
 
-	bs := make([]byte, n)
+	return b.readInto(make([]byte, n))
+}
+
+// ReadScratch reads the next n bytes of input into a buffer that is reused by
+// later calls, for bytes that are only needed until then.
+func (b *bitstream) readScratch(n uint64) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	if uint64(cap(b.scratch)) < n {
+		b.scratch = make([]byte, n)
+	}
+	return b.readInto(b.scratch[:n])
+}
+
+// ReadInto fills bs with the next len(bs) bytes of input.
+func (b *bitstream) readInto(bs []byte) ([]byte, error) {
 	actual, err := io.ReadFull(b.in, bs)
 	b.pos += uint64(actual)
+	if b.keepTape {
+		b.tape.Write(bs[:actual])
+	}
 
 	if err == io.EOF || err == io.ErrUnexpectedEOF {
 		return nil, &UnexpectedEOFError{b.pos}
@@ -1051,8 +1159,6 @@ func (b *bitstream) read1() (int, error) {
 // that easier to reason about.
 func (b *bitstream) read() (int, error) {
 	c, err := b.in.ReadByte()
-	b.pos++
-
 	if err == io.EOF {
 		return -1, nil
 	}
@@ -1060,16 +1166,39 @@ func (b *bitstream) read() (int, error) {
 		return 0, &IOError{err}
 	}
 
+	b.pos++
+	if b.keepTape {
+		b.tape.WriteByte(c)
+	}
 	return int(c), nil
 }
 
+// AtTextIVM returns true if the next bytes of input are a text version marker.
+func (b *bitstream) atTextIVM() bool {
+	const ivm = "$ion_1_0"
+	bs, _ := b.in.Peek(len(ivm) + 1)
+	if len(bs) < len(ivm) || string(bs[:len(ivm)]) != ivm {
+		return false
+	}
+	return len(bs) == len(ivm) || !isIdentifierPart(int(bs[len(ivm)]))
+}
+
 // Skip skips n bytes of input from the underlying stream.
 func (b *bitstream) skip(n uint64) error {
-	actual, err := b.in.Discard(int(n))
+	var actual int
+	var err error
+	if b.keepTape {
+		var copied int64
+		copied, err = io.CopyN(&b.tape, b.in, int64(n))
+		actual = int(copied)
+	} else {
+		actual, err = b.in.Discard(int(n))
+	}
 	b.pos += uint64(actual)
 
 	if err == io.EOF {
-		return nil
+		// The value we were skipping over was cut short.
+		return &UnexpectedEOFError{b.pos}
 	}
 	if err != nil {
 		return &IOError{err}
@@ -1081,8 +1210,11 @@ func (b *bitstream) skip(n uint64) error {
 // PeekAtOffset returns the data at a certain offset without advancing the reader.
 func (b *bitstream) peekAtOffset(offset int) (byte, error) {
 	data, err := b.in.Peek(offset + 1)
+	if err == io.EOF {
+		return 0, &UnexpectedEOFError{b.pos + uint64(len(data))}
+	}
 	if err != nil {
-		return 0, err
+		return 0, &IOError{err}
 	}
 
 	return data[offset], nil
@@ -1093,6 +1225,10 @@ func (b *bitstream) peekAtOffset(offset int) (byte, error) {
 type bitnode struct {
 	code bitcode
 	end  uint64
+
+	// For an ordered struct, the last field ID read from it, if any.
+	ordered bool
+	lastID  int64
 }
 
 // A stack of bitnodes representing container values that we're currently
@@ -1115,8 +1251,8 @@ func (b *bitstack) peek() bitnode {
 }
 
 // Push pushes a bitnode onto the stack.
-func (b *bitstack) push(code bitcode, end uint64) {
-	b.arr = append(b.arr, bitnode{code, end})
+func (b *bitstack) push(code bitcode, end uint64, ordered bool) {
+	b.arr = append(b.arr, bitnode{code, end, ordered, -1})
 }
 
 // Pop pops a bitnode from the stack.
@@ -68,9 +68,9 @@ func TestBitstream(t *testing.T) {
 	}
 
 	next(bitcodeAnnotation, false, 31)
-	as, err := b.ReadAnnotations(V1SystemSymbolTable)
+	as, err := b.ReadAnnotationIDs(nil)
 	require.NoError(t, err)
-	if len(as) != 1 || as[0].LocalSID != 3 { // $ion_symbol_table
+	if len(as) != 1 || as[0] != 3 { // $ion_symbol_table
 		t.Errorf("expected [3], got %v", as)
 	}
 
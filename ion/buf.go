@@ -37,6 +37,8 @@ type bufnode interface {
 type bufseq interface {
 	bufnode
 	Append(n bufnode)
+	// AppendBytes appends a copy of the given serialized bytes.
+	AppendBytes(bs []byte)
 }
 
 var _ bufnode = atom([]byte{})
@@ -58,16 +60,32 @@ func (a atom) EmitTo(w io.Writer) error {
 // A datagram is a sequence of nodes that will be emitted one
 // after another. Most notably, used to buffer top-level values
 // when we haven't yet finalized the local symbol table.
+//
+// Bytes appended one after another, as a run of scalars is, are copied into
+// a single buffer rather than each becoming a node of its own, so that a long
+// stream of scalars costs no more than the bytes it takes.
 type datagram struct {
 	len      uint64
 	children []bufnode
+
+	// Tail holds the bytes appended since the last child node.
+	tail []byte
 }
 
 func (d *datagram) Append(n bufnode) {
+	if len(d.tail) > 0 {
+		d.children = append(d.children, atom(d.tail))
+		d.tail = nil
+	}
 	d.len += n.Len()
 	d.children = append(d.children, n)
 }
 
+func (d *datagram) AppendBytes(bs []byte) {
+	d.len += uint64(len(bs))
+	d.tail = append(d.tail, bs...)
+}
+
 func (d *datagram) Len() uint64 {
 	return d.len
 }
@@ -79,6 +97,11 @@ func (d *datagram) EmitTo(w io.Writer) error {
 		}
 	}
 
+	if len(d.tail) > 0 {
+		if _, err := w.Write(d.tail); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -68,24 +68,26 @@ func (c *basicCatalog) FindLatest(name string) SharedSymbolTable {
 	return c.latest[name]
 }
 
-// A System is a reader factory wrapping a catalog.
+// A System is a reader factory wrapping a catalog and, optionally, an
+// Interner through which the text of all symbols read is passed.
 type System struct {
-	Catalog Catalog
+	Catalog  Catalog
+	Interner Interner
 }
 
-// NewReader creates a new reader using this system's catalog.
+// NewReader creates a new reader using this system's catalog and interner.
 func (s System) NewReader(in io.Reader) Reader {
-	return NewReaderCat(in, s.Catalog)
+	return newReader(in, s.Catalog, s.Interner)
 }
 
-// NewReaderString creates a new reader using this system's catalog.
+// NewReaderString creates a new reader using this system's catalog and interner.
 func (s System) NewReaderString(in string) Reader {
-	return NewReaderCat(strings.NewReader(in), s.Catalog)
+	return newReader(strings.NewReader(in), s.Catalog, s.Interner)
 }
 
-// NewReaderBytes creates a new reader using this system's catalog.
+// NewReaderBytes creates a new reader using this system's catalog and interner.
 func (s System) NewReaderBytes(in []byte) Reader {
-	return NewReaderCat(bytes.NewReader(in), s.Catalog)
+	return newReader(bytes.NewReader(in), s.Catalog, s.Interner)
 }
 
 // Unmarshal unmarshals Ion data using this system's catalog.
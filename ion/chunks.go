@@ -0,0 +1,373 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import "io"
+
+// A scanMode is what a chunks is scanning through in text input.
+type scanMode uint8
+
+const (
+	scanCode scanMode = iota
+	scanString
+	scanQuotedSymbol
+	scanLongString
+	scanLineComment
+	scanBlockComment
+)
+
+// A scanToken is the kind of the last top-level token a chunks has seen, as
+// far as it matters for whether the next token can continue the same value.
+type scanToken uint8
+
+const (
+	scanOther scanToken = iota
+	scanColon
+	scanLong
+)
+
+// Chunks is an io.Reader over a growable sequence of bytes. It returns io.EOF
+// when it runs out, but may be appended to afterwards.
+//
+// Only whole top-level values are returned, so that a reader never mistakes
+// the part of a value in one chunk for all of it. Chunks scans the input for
+// the ends of top-level values, and holds back any bytes after the last one
+// it knows is complete until more input shows where it ends, or until the
+// input is marked as ended. In text, a value is only known to be complete once
+// the next one starts, unless it ends with a closing delimiter, since a
+// number, say, or an annotation, could carry on in the next chunk.
+type chunks struct {
+	buf    []byte
+	ready  int
+	binary bool
+
+	// Eof is set once the input has ended, after which err, if set, is
+	// returned in place of io.EOF.
+	eof bool
+	err error
+
+	// Scanned is the offset in buf the scan has reached. In text, depth is
+	// the depth of container nesting there, and gap is set if a token
+	// starting there at the top level would be separate from the one before.
+	scanned int
+	mode    scanMode
+	depth   int
+	inLob   bool
+	gap     bool
+	prev    scanToken
+}
+
+// Reset discards the input and starts again with first as its first chunk,
+// which decides whether it is scanned as text or binary.
+func (c *chunks) reset(first []byte) {
+	*c = chunks{
+		buf:    append(c.buf[:0], first...),
+		binary: len(first) >= 4 && first[0] == 0xE0 && first[3] == 0xEA,
+		gap:    true,
+	}
+	c.scan()
+}
+
+func (c *chunks) Append(b []byte) {
+	c.buf = append(c.buf, b...)
+	c.scan()
+}
+
+// End marks the end of the input, so that everything held back can be read.
+func (c *chunks) end() {
+	c.eof = true
+	c.ready = len(c.buf)
+}
+
+func (c *chunks) Read(p []byte) (int, error) {
+	if c.ready == 0 {
+		if c.eof && c.err != nil {
+			return 0, c.err
+		}
+		return 0, io.EOF
+	}
+
+	n := copy(p, c.buf[:c.ready])
+	c.buf = c.buf[n:]
+	c.ready -= n
+	c.scanned -= n
+	return n, nil
+}
+
+// Scan moves ready on past every top-level value in buf known to be complete.
+func (c *chunks) scan() {
+	switch {
+	case c.eof:
+		c.ready = len(c.buf)
+	case c.binary:
+		c.scanBinary()
+	default:
+		c.scanText()
+	}
+}
+
+// ScanBinary moves past each whole top-level value or version marker.
+func (c *chunks) scanBinary() {
+	for c.scanned < len(c.buf) {
+		n, ok := binaryValueLen(c.buf[c.scanned:])
+		if !ok {
+			return
+		}
+		c.scanned += n
+		c.ready = c.scanned
+	}
+}
+
+// BinaryValueLen returns the length of the binary value or version marker at
+// the start of bs, and whether bs holds all of it. An invalid value's length
+// is taken to be all of bs, for the reader to report.
+func binaryValueLen(bs []byte) (int, bool) {
+	if bs[0] == 0xE0 {
+		return 4, len(bs) >= 4
+	}
+
+	code, length := parseTag(int(bs[0]))
+	switch {
+	case code == bitcodeNone:
+		return len(bs), true
+
+	case code == bitcodeFalse || length == 0x0F:
+		// Bools and nulls keep everything in the type descriptor.
+		return 1, true
+
+	case length == 0x0E || (code == bitcodeStruct && length == 1):
+		// The length follows as a VarUInt.
+		length = 0
+		for i := 1; ; i++ {
+			if i == len(bs) {
+				return 0, false
+			}
+			if i > 8 {
+				return len(bs), true
+			}
+			length = length<<7 | uint64(bs[i]&0x7F)
+			if bs[i]&0x80 != 0 {
+				n := uint64(i+1) + length
+				return int(n), n <= uint64(len(bs))
+			}
+		}
+	}
+
+	return int(length) + 1, int(length)+1 <= len(bs)
+}
+
+// ScanText moves past each top-level text value that has been followed by
+// the start of another or that ended with a closing delimiter. It stops
+// short of any character it can't make sense of without the ones after it.
+func (c *chunks) scanText() {
+	bs := c.buf
+	for i := c.scanned; i < len(bs); i = c.scanned {
+		b := bs[i]
+		need := func(n int) bool {
+			return i+n < len(bs)
+		}
+
+		switch c.mode {
+		case scanLineComment:
+			if b == '\n' || b == '\r' {
+				c.mode = scanCode
+			}
+			c.scanned++
+
+		case scanBlockComment:
+			if b == '*' {
+				if !need(1) {
+					return
+				}
+				if bs[i+1] == '/' {
+					c.mode = scanCode
+					c.scanned++
+				}
+			}
+			c.scanned++
+
+		case scanString, scanQuotedSymbol:
+			quote := byte('"')
+			if c.mode == scanQuotedSymbol {
+				quote = '\''
+			}
+			switch b {
+			case '\\':
+				if !need(1) {
+					return
+				}
+				c.scanned++
+			case quote:
+				c.mode = scanCode
+				if c.depth == 0 && !c.inLob {
+					c.gap = true
+					c.prev = scanOther
+					if quote == '"' {
+						// Nothing can follow a string to carry it on.
+						c.ready = i + 1
+					}
+				}
+			}
+			c.scanned++
+
+		case scanLongString:
+			switch b {
+			case '\\':
+				if !need(1) {
+					return
+				}
+				c.scanned++
+			case '\'':
+				if !need(2) {
+					return
+				}
+				if bs[i+1] == '\'' && bs[i+2] == '\'' {
+					c.mode = scanCode
+					c.scanned += 2
+					if c.depth == 0 && !c.inLob {
+						// Another long string may follow to be joined on.
+						c.gap = true
+						c.prev = scanLong
+					}
+				}
+			}
+			c.scanned++
+
+		case scanCode:
+			if !c.scanCode(bs, i) {
+				return
+			}
+		}
+	}
+}
+
+// ScanCode scans the character at bs[i], outside any string or comment,
+// returning false if it needs more input to do so.
+func (c *chunks) scanCode(bs []byte, i int) bool {
+	b := bs[i]
+	need := func(n int) bool {
+		return i+n < len(bs)
+	}
+
+	if c.inLob {
+		switch b {
+		case '"':
+			c.mode = scanString
+		case '\'':
+			if !need(2) {
+				return false
+			}
+			if bs[i+1] == '\'' && bs[i+2] == '\'' {
+				c.mode = scanLongString
+				c.scanned += 2
+			}
+		case '}':
+			if !need(1) {
+				return false
+			}
+			if bs[i+1] == '}' {
+				c.inLob = false
+				c.scanned++
+				c.closed(i + 2)
+			}
+		}
+		c.scanned++
+		return true
+	}
+
+	if isWhitespace(int(b)) {
+		if c.depth == 0 {
+			c.gap = true
+		}
+		c.scanned++
+		return true
+	}
+
+	switch b {
+	case '/', '{':
+		if !need(1) {
+			return false
+		}
+	case '\'':
+		if !need(2) {
+			return false
+		}
+	}
+
+	if b == '/' && (bs[i+1] == '/' || bs[i+1] == '*') {
+		c.mode = scanLineComment
+		if bs[i+1] == '*' {
+			c.mode = scanBlockComment
+		}
+		if c.depth == 0 {
+			c.gap = true
+		}
+		c.scanned += 2
+		return true
+	}
+
+	if c.depth == 0 {
+		// A token that starts a new top-level value ends the one before.
+		long := b == '\'' && bs[i+1] == '\'' && bs[i+2] == '\''
+		if c.gap && b != ':' && c.prev != scanColon && !(long && c.prev == scanLong) {
+			c.ready = i
+		}
+		c.gap = false
+		c.prev = scanOther
+		if b == ':' {
+			c.prev = scanColon
+		}
+	}
+
+	switch b {
+	case '"':
+		c.mode = scanString
+	case '\'':
+		c.mode = scanQuotedSymbol
+		if bs[i+1] == '\'' && bs[i+2] == '\'' {
+			c.mode = scanLongString
+			c.scanned += 2
+		}
+	case '{':
+		if bs[i+1] == '{' {
+			c.inLob = true
+			c.scanned++
+		} else {
+			c.depth++
+		}
+	case '[', '(':
+		c.depth++
+	case '}', ']', ')':
+		if c.depth > 0 {
+			c.depth--
+			if c.depth == 0 {
+				c.closed(i + 1)
+			}
+		}
+	}
+	c.scanned++
+	return true
+}
+
+// Closed records that a container or lob that ends just before end has been
+// closed, which completes it if it was at the top level.
+func (c *chunks) closed(end int) {
+	if c.depth == 0 {
+		c.ready = end
+		c.gap = true
+		c.prev = scanOther
+	}
+}
@@ -16,6 +16,7 @@
 package ion
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"time"
@@ -68,4 +69,49 @@ var timestampType = reflect.TypeOf(Timestamp{})
 var nativeTimeType = reflect.TypeOf(time.Time{})
 var decimalType = reflect.TypeOf(Decimal{})
 var bigIntType = reflect.TypeOf(big.Int{})
+var ratType = reflect.TypeOf(big.Rat{})
 var symbolType = reflect.TypeOf(SymbolToken{})
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+var emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// GoTypeAnnotations maps the annotations written by EncodeTypeAnnotations to
+// the Go types they stand for.
+var goTypeAnnotations = map[string]reflect.Type{
+	"$go_int8":    reflect.TypeOf(int8(0)),
+	"$go_int16":   reflect.TypeOf(int16(0)),
+	"$go_int32":   reflect.TypeOf(int32(0)),
+	"$go_int64":   reflect.TypeOf(int64(0)),
+	"$go_uint":    reflect.TypeOf(uint(0)),
+	"$go_uint8":   reflect.TypeOf(uint8(0)),
+	"$go_uint16":  reflect.TypeOf(uint16(0)),
+	"$go_uint32":  reflect.TypeOf(uint32(0)),
+	"$go_uint64":  reflect.TypeOf(uint64(0)),
+	"$go_uintptr": reflect.TypeOf(uintptr(0)),
+	"$go_float32": reflect.TypeOf(float32(0)),
+	"$go_time":    nativeTimeType,
+}
+
+// GoTypeAnnotationFor returns the annotation to write for a value of the given
+// type when encoding with EncodeTypeAnnotations, if it needs one.
+func goTypeAnnotationFor(t reflect.Type) (string, bool) {
+	if t == nativeTimeType {
+		return "$go_time", true
+	}
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32:
+		return "$go_" + t.Kind().String(), true
+	}
+	return "", false
+}
+
+// IsGoTypeAnnotation returns true if the given annotation is one written by
+// EncodeTypeAnnotations.
+func isGoTypeAnnotation(a SymbolToken) bool {
+	if a.Text == nil {
+		return false
+	}
+	_, ok := goTypeAnnotations[*a.Text]
+	return ok
+}
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // A ParseError is returned if ParseDecimal is called with a parameter that
@@ -57,6 +58,102 @@ func NewDecimalInt(n int64) *Decimal {
 	return NewDecimal(big.NewInt(n), 0, false)
 }
 
+// NewDecimalFromFloat creates a new decimal holding the shortest decimal
+// representation of f that converts back to f exactly. Unlike floats, Ion
+// decimals have no NaN or infinite values, so an error is returned if f is
+// not finite.
+func NewDecimalFromFloat(f float64) (*Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("ion: cannot represent non-finite float %v as a decimal", f)
+	}
+	return ParseDecimal(strings.Replace(strconv.FormatFloat(f, 'g', -1, 64), "e", "d", 1))
+}
+
+// DecimalFromDuration creates a new decimal holding the number of seconds in
+// the given duration, e.g. 1.5 for 1500 milliseconds. It keeps as many digits
+// after the decimal point, up to nine for nanoseconds, as the duration needs,
+// so a whole number of seconds has none.
+func DecimalFromDuration(dur time.Duration) *Decimal {
+	n := int64(dur)
+	exp := int32(-9)
+	for exp < 0 && n%10 == 0 {
+		n /= 10
+		exp++
+	}
+	return NewDecimal(big.NewInt(n), exp, false)
+}
+
+// DecimalFromRat converts r to a decimal. If r's decimal expansion terminates,
+// the result is exact. Otherwise, if round is true, r is rounded half to even
+// at the given number of digits after the decimal point; if round is false,
+// it returns false.
+func decimalFromRat(r *big.Rat, digits int, round bool) (*Decimal, bool) {
+	num, den := r.Num(), r.Denom()
+
+	// The expansion terminates if the denominator has no prime factors other
+	// than 2 and 5, needing as many digits as the larger of their powers.
+	rest := new(big.Int).Set(den)
+	twos, fives := 0, 0
+	two, five, rem := big.NewInt(2), big.NewInt(5), new(big.Int)
+	for q := new(big.Int); ; twos++ {
+		if q.QuoRem(rest, two, rem); rem.Sign() != 0 {
+			break
+		}
+		rest.Set(q)
+	}
+	for q := new(big.Int); ; fives++ {
+		if q.QuoRem(rest, five, rem); rem.Sign() != 0 {
+			break
+		}
+		rest.Set(q)
+	}
+
+	if rest.IsInt64() && rest.Int64() == 1 {
+		scale := twos
+		if fives > scale {
+			scale = fives
+		}
+		mul := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+		mul.Quo(mul, den)
+		return NewDecimal(mul.Mul(mul, num), int32(-scale), false), true
+	}
+
+	if !round {
+		return nil, false
+	}
+
+	n := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n.Mul(n, num)
+	n.QuoRem(n, den, rem)
+
+	// Round away from zero past the halfway point, or at it if n is odd.
+	rem.Abs(rem).Lsh(rem, 1)
+	if c := rem.Cmp(den); c > 0 || (c == 0 && n.Bit(0) == 1) {
+		n.Add(n, big.NewInt(int64(num.Sign())))
+	}
+	return NewDecimal(n, int32(-digits), false), true
+}
+
+// MaxExpandedExponent is the largest exponent magnitude of a decimal that is
+// expanded out to all of its digits, as by PlainString and conversion to a
+// big.Rat; past it, the expansion alone would take more time and memory than
+// any real value needs, e.g. hundreds of megabytes for 1d-300000000.
+const maxExpandedExponent = 10000
+
+// Rat returns the exact value of the decimal as a rational number, or an
+// error if its exponent is too large to expand.
+func (d *Decimal) rat() (*big.Rat, error) {
+	if d.scale > maxExpandedExponent || d.scale < -maxExpandedExponent {
+		return nil, fmt.Errorf("ion: exponent of decimal %v is too large to convert exactly", d)
+	}
+	if d.scale <= 0 {
+		pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(-int64(d.scale)), nil)
+		return new(big.Rat).SetInt(pow.Mul(pow, d.n)), nil
+	}
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(d.n, pow), nil
+}
+
 // MustParseDecimal parses the given string into a decimal object,
 // panicking on error.
 func MustParseDecimal(in string) *Decimal {
@@ -103,7 +200,13 @@ func ParseDecimal(in string) (*Decimal, error) {
 		in = ipart + fpart
 	}
 
-	n, ok := new(big.Int).SetString(in, 10)
+	// Allocate the decimal and its coefficient together.
+	dc := &struct {
+		d Decimal
+		n big.Int
+	}{}
+
+	n, ok := dc.n.SetString(in, 10)
 	if !ok {
 		// Unfortunately this is all we get?
 		return nil, &ParseError{in, "cannot parse coefficient"}
@@ -111,7 +214,14 @@ func ParseDecimal(in string) (*Decimal, error) {
 
 	isNegZero := n.Sign() == 0 && len(in) > 0 && in[0] == '-'
 
-	return NewDecimal(n, exponent, isNegZero), nil
+	dc.d = Decimal{n: n, scale: -exponent, isNegZero: isNegZero}
+	return &dc.d, nil
+}
+
+// IsValid returns true if d holds a value that can be written as an Ion
+// decimal. A nil or zero-value Decimal has no coefficient, and so no value.
+func (d *Decimal) isValid() bool {
+	return d != nil && d.n != nil
 }
 
 // CoEx returns this decimal's coefficient and exponent.
@@ -198,6 +308,143 @@ func (d *Decimal) ShiftR(shift int) *Decimal {
 	}
 }
 
+// A RoundingMode determines how a decimal is rounded when Rescale must drop
+// nonzero digits from it.
+type RoundingMode uint8
+
+const (
+	// RoundUnnecessary forbids rounding: Rescale returns an error rather than
+	// drop any nonzero digit.
+	RoundUnnecessary RoundingMode = iota
+	// RoundDown rounds towards zero, truncating the dropped digits.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling
+	// RoundHalfUp rounds to the nearest value, and away from zero when the
+	// dropped digits are exactly half way there.
+	RoundHalfUp
+	// RoundHalfEven rounds to the nearest value, and to the one with an even
+	// last digit when the dropped digits are exactly half way there.
+	RoundHalfEven
+)
+
+// Rescale returns a decimal equal in value to this one, rounded if need be
+// with the given mode, whose exponent is the given exponent. Lowering the
+// exponent pads the coefficient with zeros, and is always exact; raising it
+// drops digits, and returns an error if any of them is nonzero and mode is
+// RoundUnnecessary. Rescaling a set of decimals to one exponent gives them a
+// common scale, e.g. for a fixed-scale column: 1.5 rescaled to exponent -2 is
+// 1.50, and 1.255 is 1.26 with RoundHalfEven.
+func (d *Decimal) Rescale(exponent int, mode RoundingMode) (*Decimal, error) {
+	// The scale is the negated exponent, so both must fit in an int32, which
+	// rules out math.MinInt32 as well.
+	if exponent > math.MaxInt32 || exponent <= math.MinInt32 {
+		return nil, fmt.Errorf("ion: exponent %v out of bounds", exponent)
+	}
+	if mode > RoundHalfEven {
+		return nil, fmt.Errorf("ion: invalid rounding mode %v", mode)
+	}
+
+	scale := -int64(exponent)
+	shift := int64(d.scale) - scale
+	if shift <= 0 {
+		u := d.upscale(int32(scale))
+		u.isNegZero = d.isNegZero
+		return u, nil
+	}
+
+	sign := d.n.Sign()
+	abs := new(big.Int).Abs(d.n)
+
+	// Dropping more digits than the coefficient has leaves zero, with the
+	// whole coefficient as the remainder, which is less than half.
+	q, r := new(big.Int), new(big.Int)
+	half := -1
+	if shift > int64(len(abs.String())) {
+		r.Set(abs)
+	} else {
+		div := new(big.Int).Exp(big.NewInt(10), big.NewInt(shift), nil)
+		q.QuoRem(abs, div, r)
+		half = new(big.Int).Lsh(r, 1).Cmp(div)
+	}
+
+	if r.Sign() != 0 {
+		var up bool
+		switch mode {
+		case RoundUnnecessary:
+			return nil, fmt.Errorf("ion: rescaling %v to exponent %v requires rounding", d, exponent)
+		case RoundUp:
+			up = true
+		case RoundFloor:
+			up = sign < 0
+		case RoundCeiling:
+			up = sign > 0
+		case RoundHalfUp:
+			up = half >= 0
+		case RoundHalfEven:
+			up = half > 0 || (half == 0 && q.Bit(0) == 1)
+		}
+		if up {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if sign < 0 {
+		q.Neg(q)
+	}
+	return &Decimal{
+		n:         q,
+		scale:     int32(scale),
+		isNegZero: d.isNegZero,
+	}, nil
+}
+
+// ToDuration converts a decimal number of seconds to a time.Duration. Digits
+// beyond nanoseconds are rounded half to even, as Rescale does, so 1.0000000005
+// is one second and 1.0000000015 is one second and two nanoseconds. It returns
+// an error if the result doesn't fit in a time.Duration, which holds about 292
+// years either side of zero.
+func (d *Decimal) ToDuration() (time.Duration, error) {
+	// Zero is zero seconds whatever its exponent, and rescaling a zero like
+	// 0d100000000 would build a power of ten with that many digits.
+	if d.n.Sign() == 0 {
+		return 0, nil
+	}
+
+	// Check the magnitude before rescaling, which for a large positive
+	// exponent would build a coefficient with that many digits.
+	if int64(len(new(big.Int).Abs(d.n).String()))-int64(d.scale) > 11 {
+		return 0, fmt.Errorf("ion: %v seconds overflows a time.Duration", d)
+	}
+
+	ns, err := d.Rescale(-9, RoundHalfEven)
+	if err != nil {
+		return 0, err
+	}
+	if !ns.n.IsInt64() {
+		return 0, fmt.Errorf("ion: %v seconds overflows a time.Duration", d)
+	}
+	return time.Duration(ns.n.Int64()), nil
+}
+
+// Float64 returns the float64 nearest to the decimal, or an infinity if its
+// magnitude is too large for a float64. It converts the decimal's digits and
+// exponent directly, so it is cheap even for a decimal with a huge exponent,
+// like 1d1000000000, whose exact value would have a billion digits.
+func (d *Decimal) Float64() float64 {
+	if d.isNegZero {
+		return math.Copysign(0, -1)
+	}
+	// ParseFloat rounds correctly, and saturates an out-of-range exponent to
+	// zero or an infinity rather than scaling the coefficient by it.
+	f, _ := strconv.ParseFloat(d.n.String()+"e"+strconv.FormatInt(-int64(d.scale), 10), 64)
+	return f
+}
+
 // https://github.com/amzn/ion-go/issues/118
 
 // Sign returns -1 if the value is less than 0, 0 if it is equal to zero,
@@ -390,3 +637,113 @@ func (d *Decimal) String() string {
 		return b.String()
 	}
 }
+
+// PlainString formats the decimal in plain notation, without an exponent,
+// e.g. 0.0001 rather than 1d-4 and 1200 rather than 12d2. Unlike String, the
+// result is meant for display and is not necessarily valid Ion text. A
+// decimal whose exponent is more than 10000 either side of zero is formatted
+// as String would instead, since its plain form would need that many zeros.
+func (d *Decimal) PlainString() string {
+	if d.scale > maxExpandedExponent || d.scale < -maxExpandedExponent {
+		return d.String()
+	}
+
+	str := new(big.Int).Abs(d.n).String()
+
+	b := strings.Builder{}
+	if d.n.Sign() < 0 || d.isNegZero {
+		b.WriteByte('-')
+	}
+
+	switch {
+	case d.scale == 0:
+		b.WriteString(str)
+
+	case d.scale < 0:
+		// Value is an upscaled integer; pad it out with zeros.
+		b.WriteString(str)
+		if d.n.Sign() != 0 {
+			b.WriteString(strings.Repeat("0", int(-d.scale)))
+		}
+
+	default:
+		// Value is a downscaled integer; put the decimal point in, padding
+		// with leading zeros if it has fewer digits than the scale.
+		idx := len(str) - int(d.scale)
+		if idx <= 0 {
+			b.WriteString("0.")
+			b.WriteString(strings.Repeat("0", -idx))
+			b.WriteString(str)
+		} else {
+			b.WriteString(str[:idx])
+			b.WriteByte('.')
+			b.WriteString(str[idx:])
+		}
+	}
+
+	return b.String()
+}
+
+// EngString formats the decimal in engineering notation, with an exponent
+// that is a multiple of three and one to three digits before the decimal
+// point, e.g. 12.5e-6 rather than 1.25d-5, and 1.5e3 rather than 15d2; the
+// exponent is left off if it is zero. Digits are neither added nor dropped,
+// except that zeros are added to fill out the integer part where the decimal
+// has too few digits, e.g. 1d2 is 100, and to keep the precision of a zero,
+// e.g. 0d-5 is 0.00e-3. Like PlainString, the result is meant
+// for display, or for other systems that expect engineering notation, and is
+// not valid Ion text.
+func (d *Decimal) EngString() string {
+	str := new(big.Int).Abs(d.n).String()
+	exp := -int64(d.scale)
+
+	b := strings.Builder{}
+	if d.n.Sign() < 0 || d.isNegZero {
+		b.WriteByte('-')
+	}
+
+	var eng int64
+	if d.n.Sign() == 0 {
+		// Keep the zero's precision by rounding its exponent up to a multiple
+		// of three and making up the difference with fractional zeros.
+		eng = ceilDiv(exp, 3) * 3
+		b.WriteByte('0')
+		if eng > exp {
+			b.WriteByte('.')
+			b.WriteString(strings.Repeat("0", int(eng-exp)))
+		}
+	} else {
+		// The exponent of the first digit, as in scientific notation, rounded
+		// down to a multiple of three.
+		adjusted := int64(len(str)) - 1 + exp
+		eng = floorDiv(adjusted, 3) * 3
+
+		intDigits := int(adjusted-eng) + 1
+		if len(str) > intDigits {
+			b.WriteString(str[:intDigits])
+			b.WriteByte('.')
+			b.WriteString(str[intDigits:])
+		} else {
+			b.WriteString(str)
+			b.WriteString(strings.Repeat("0", intDigits-len(str)))
+		}
+	}
+
+	if eng != 0 {
+		b.WriteByte('e')
+		b.WriteString(strconv.FormatInt(eng, 10))
+	}
+	return b.String()
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func ceilDiv(a, b int64) int64 {
+	return -floorDiv(-a, b)
+}
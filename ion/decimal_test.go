@@ -16,9 +16,14 @@
 package ion
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"math/big"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -65,6 +70,41 @@ func TestDecimalToString(t *testing.T) {
 	test(-456, 4, "-4.56d-2")
 }
 
+func TestDecimalPlainString(t *testing.T) {
+	test := func(in string, expected string) {
+		t.Run(in, func(t *testing.T) {
+			assert.Equal(t, expected, MustParseDecimal(in).PlainString())
+		})
+	}
+
+	test("0.", "0")
+	test("-0.", "-0")
+	test("0d1", "0")
+	test("0d-1", "0.0")
+	test("-0d-3", "-0.000")
+
+	test("123.", "123")
+	test("-456.", "-456")
+	test("123d5", "12300000")
+	test("-456d5", "-45600000")
+
+	test("12.3", "12.3")
+	test("-4.56", "-4.56")
+	test("1d-4", "0.0001")
+	test("1.23d-2", "0.0123")
+	test("-4.56d-1", "-0.456")
+	test("1.000", "1.000")
+	test("1d-30", "0.000000000000000000000000000001")
+	test("1d30", "1000000000000000000000000000000")
+
+	// Exponents too large to pad out fall back to String's notation.
+	test("1d-10000", "0."+strings.Repeat("0", 9999)+"1")
+	test("1d10000", "1"+strings.Repeat("0", 10000))
+	test("1d-10001", "1d-10001")
+	test("-12d300000000", "-12d300000000")
+	test("1d-2147483648", "1d-2147483648")
+}
+
 func TestParseDecimal(t *testing.T) {
 	test := func(in string, n *big.Int, scale int32) {
 		t.Run(in, func(t *testing.T) {
@@ -93,6 +133,236 @@ func TestParseDecimal(t *testing.T) {
 	test("-0.12d4", big.NewInt(-12), -2)
 }
 
+func TestNewDecimalFromFloat(t *testing.T) {
+	test := func(in float64, expected string) {
+		t.Run(expected, func(t *testing.T) {
+			d, err := NewDecimalFromFloat(in)
+			require.NoError(t, err)
+			assert.Equal(t, expected, d.String())
+		})
+	}
+
+	test(0, "0.")
+	test(math.Copysign(0, -1), "-0.")
+	test(1.5, "1.5")
+	test(-0.001, "-1d-3")
+	test(1e21, "1d21")
+	test(1.25e-10, "1.25d-10")
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := NewDecimalFromFloat(f)
+		assert.Error(t, err, "%v", f)
+	}
+}
+
+func TestRescale(t *testing.T) {
+	test := func(in string, exp int, mode RoundingMode, expected string) {
+		t.Run(fmt.Sprintf("%v/%v/%v", in, exp, mode), func(t *testing.T) {
+			d, err := MustParseDecimal(in).Rescale(exp, mode)
+			require.NoError(t, err)
+			assert.Equal(t, expected, d.String())
+			_, actualExp := d.CoEx()
+			assert.Equal(t, int32(exp), actualExp)
+		})
+	}
+
+	// Upscaling pads with zeros and never rounds.
+	test("1.5", -2, RoundUnnecessary, "1.50")
+	test("-7", -3, RoundUnnecessary, "-7.000")
+	test("12d2", 0, RoundUnnecessary, "1200.")
+	test("-0.0", -3, RoundUnnecessary, "-0d-3")
+	test("1.50", -2, RoundUnnecessary, "1.50")
+
+	// Downscaling drops digits, which is fine if they are zero.
+	test("1.500", -1, RoundUnnecessary, "1.5")
+	test("1200", 2, RoundUnnecessary, "12d2")
+
+	for _, c := range []struct {
+		in       string
+		mode     RoundingMode
+		expected string
+	}{
+		{"1.25", RoundDown, "1.2"},
+		{"-1.25", RoundDown, "-1.2"},
+		{"1.21", RoundUp, "1.3"},
+		{"-1.21", RoundUp, "-1.3"},
+		{"1.29", RoundFloor, "1.2"},
+		{"-1.21", RoundFloor, "-1.3"},
+		{"1.21", RoundCeiling, "1.3"},
+		{"-1.29", RoundCeiling, "-1.2"},
+		{"1.25", RoundHalfUp, "1.3"},
+		{"-1.25", RoundHalfUp, "-1.3"},
+		{"1.24", RoundHalfUp, "1.2"},
+		{"1.25", RoundHalfEven, "1.2"},
+		{"1.35", RoundHalfEven, "1.4"},
+		{"1.251", RoundHalfEven, "1.3"},
+		{"-1.25", RoundHalfEven, "-1.2"},
+	} {
+		test(c.in, -1, c.mode, c.expected)
+	}
+
+	// Dropping every digit leaves zero, or one in the direction rounded.
+	test("0.004", 0, RoundHalfUp, "0.")
+	test("0.004", 0, RoundUp, "1.")
+	test("-0.004", 0, RoundFloor, "-1.")
+	test("9.9", 5, RoundHalfEven, "0d5")
+	test("9.9", 5, RoundCeiling, "1d5")
+
+	_, err := MustParseDecimal("1.25").Rescale(-1, RoundUnnecessary)
+	assert.Error(t, err)
+	_, err = MustParseDecimal("1").Rescale(math.MaxInt32+1, RoundDown)
+	assert.Error(t, err)
+	_, err = MustParseDecimal("1").Rescale(math.MinInt32, RoundDown)
+	assert.Error(t, err)
+	_, err = MustParseDecimal("1").Rescale(math.MinInt64, RoundDown)
+	assert.Error(t, err)
+	_, err = MustParseDecimal("1").Rescale(0, RoundHalfEven+1)
+	assert.Error(t, err)
+}
+
+func TestDecimalFloat64(t *testing.T) {
+	test := func(in string, expected float64) {
+		t.Run(in, func(t *testing.T) {
+			f := MustParseDecimal(in).Float64()
+			assert.Equal(t, expected, f)
+			assert.Equal(t, math.Signbit(expected), math.Signbit(f))
+		})
+	}
+
+	test("0", 0)
+	test("-0.0", math.Copysign(0, -1))
+	test("1.5", 1.5)
+	test("-12.25d-2", -0.1225)
+	test("0.1", 0.1)
+	test("123456789012345678901234567890", 123456789012345678901234567890)
+	test("17976931348623157d292", math.MaxFloat64)
+	test("5d-324", math.SmallestNonzeroFloat64)
+
+	// Huge exponents saturate without materializing the decimal's value.
+	test("1d2000000000", math.Inf(1))
+	test("-1d2000000000", math.Inf(-1))
+	test("1d-2000000000", 0)
+	test("-1d-2000000000", math.Copysign(0, -1))
+	test("1d400", math.Inf(1))
+	test("1d-400", 0)
+}
+
+func TestDecimalEngString(t *testing.T) {
+	test := func(in, expected string) {
+		t.Run(in, func(t *testing.T) {
+			d := MustParseDecimal(in)
+			s := d.EngString()
+			assert.Equal(t, expected, s)
+
+			// The exponent is a multiple of three, and the value is unchanged.
+			mantissa, exp := s, int64(0)
+			if i := strings.IndexByte(s, 'e'); i >= 0 {
+				var err error
+				mantissa = s[:i]
+				exp, err = strconv.ParseInt(s[i+1:], 10, 64)
+				require.NoError(t, err)
+			}
+			assert.Zero(t, exp%3)
+			if d.Sign() != 0 {
+				digits := strings.TrimPrefix(strings.SplitN(mantissa, ".", 2)[0], "-")
+				assert.True(t, len(digits) >= 1 && len(digits) <= 3 && digits[0] != '0', digits)
+			}
+
+			back := MustParseDecimal(strings.Replace(s, "e", "d", 1))
+			assert.True(t, d.Equal(back), "%v != %v", d, back)
+			assert.Equal(t, d.isNegZero, back.isNegZero)
+		})
+	}
+
+	test("0", "0")
+	test("-0", "-0")
+	test("0.0", "0.0")
+	test("0d-5", "0.00e-3")
+	test("-0d-3", "-0e-3")
+	test("0d2", "0.0e3")
+	test("1", "1")
+	test("12", "12")
+	test("123", "123")
+	test("1234", "1.234e3")
+	test("1.5", "1.5")
+	test("1d2", "100")
+	test("1d3", "1e3")
+	test("15d2", "1.5e3")
+	test("-15d2", "-1.5e3")
+	test("123.456d5", "12.3456e6")
+	test("1.25d-5", "12.5e-6")
+	test("0.001", "1e-3")
+	test("0.0123", "12.3e-3")
+	test("-0.5", "-500e-3")
+	test("12345678901234567890d-30", "12.345678901234567890e-12")
+	test("7d2147483647", "70e2147483646")
+}
+
+func TestDecimalDuration(t *testing.T) {
+	test := func(in string, expected time.Duration) {
+		t.Run(in, func(t *testing.T) {
+			d, err := MustParseDecimal(in).ToDuration()
+			require.NoError(t, err)
+			assert.Equal(t, expected, d)
+		})
+	}
+
+	test("0", 0)
+	test("-0.0", 0)
+	test("0d100000000", 0)
+	test("0d-2147483648", 0)
+	test("1.5", 1500*time.Millisecond)
+	test("-2.000000001", -2*time.Second-time.Nanosecond)
+	test("3d2", 5*time.Minute)
+	test("9223372036.854775807", time.Duration(math.MaxInt64))
+	test("-9223372036.854775808", time.Duration(math.MinInt64))
+
+	// Digits past nanoseconds are rounded half to even.
+	test("1.0000000004", time.Second)
+	test("1.0000000005", time.Second)
+	test("1.0000000015", time.Second+2*time.Nanosecond)
+	test("-1.0000000006", -time.Second-time.Nanosecond)
+	test("0.00000000049999", 0)
+
+	for _, in := range []string{"9223372036.854775808", "-9223372037", "1d10", "1d2000000000"} {
+		_, err := MustParseDecimal(in).ToDuration()
+		assert.Error(t, err, in)
+	}
+
+	for _, c := range []struct {
+		dur      time.Duration
+		expected string
+	}{
+		{0, "0."},
+		{time.Second, "1."},
+		{-1500 * time.Millisecond, "-1.5"},
+		{time.Nanosecond, "1d-9"},
+		{90 * time.Minute, "5400."},
+		{time.Duration(math.MaxInt64), "9223372036.854775807"},
+	} {
+		d := DecimalFromDuration(c.dur)
+		assert.Equal(t, c.expected, d.String())
+
+		back, err := d.ToDuration()
+		require.NoError(t, err)
+		assert.Equal(t, c.dur, back)
+	}
+}
+
+func TestWriteInvalidDecimal(t *testing.T) {
+	for _, val := range []*Decimal{nil, {}} {
+		assert.Error(t, NewTextWriter(&strings.Builder{}).WriteDecimal(val))
+		assert.Error(t, NewBinaryWriter(&bytes.Buffer{}).WriteDecimal(val))
+	}
+
+	_, err := MarshalText(struct{ D Decimal }{})
+	assert.Error(t, err)
+
+	var d Decimal
+	assert.Error(t, UnmarshalString("+inf", &d))
+	assert.Error(t, UnmarshalString("nan", &d))
+}
+
 func absF(d *Decimal) *Decimal { return d.Abs() }
 func negF(d *Decimal) *Decimal { return d.Neg() }
 
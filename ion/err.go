@@ -15,7 +15,11 @@
 
 package ion
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 // A UsageError is returned when you use a Reader or Writer in an inappropriate way.
 type UsageError struct {
@@ -101,3 +105,117 @@ type UnexpectedTokenError struct {
 func (e *UnexpectedTokenError) Error() string {
 	return fmt.Sprintf("ion: unexpected token '%v' (offset %v)", e.Token, e.Offset)
 }
+
+// A SymbolIDOutOfRangeError is returned when a Reader encounters a symbol ID,
+// as a value, field name, or annotation, that is greater than the max ID of the
+// current symbol table.
+type SymbolIDOutOfRangeError struct {
+	SID   int64
+	MaxID uint64
+}
+
+func (e *SymbolIDOutOfRangeError) Error() string {
+	return fmt.Sprintf("ion: symbol ID $%v is out of range for symbol table with max ID %v", e.SID, e.MaxID)
+}
+
+// An UnexpectedTypeError is returned by Reader.Expect, ExpectNonNull, and
+// ExpectOneOf when the current value is not of an expected type, or is a null
+// when a non-null value is expected. Offset is the offset, in bytes, at which
+// the value starts, including any annotations.
+type UnexpectedTypeError struct {
+	Expected []Type
+	NonNull  bool
+	Type     Type
+	IsNull   bool
+	Offset   uint64
+}
+
+func (e *UnexpectedTypeError) Error() string {
+	buf := strings.Builder{}
+	buf.WriteString("ion: expected ")
+	if e.NonNull {
+		buf.WriteString("non-null ")
+	}
+	for i, t := range e.Expected {
+		if i > 0 {
+			buf.WriteString(" or ")
+		}
+		buf.WriteString(t.String())
+	}
+
+	got := e.Type.String()
+	switch {
+	case e.Type == NoType:
+		got = "no value"
+	case e.IsNull && e.Type != NullType:
+		got = "null." + got
+	}
+	return fmt.Sprintf("%v, got %v (offset %v)", buf.String(), got, e.Offset)
+}
+
+// A CyclicReferenceError is returned when marshaling a Go value that refers to
+// itself, such as a struct holding a pointer to itself or a slice that holds
+// itself, which would otherwise encode forever. Type is the type of the pointer,
+// map, or slice through which the cycle was found.
+type CyclicReferenceError struct {
+	Type reflect.Type
+}
+
+func (e *CyclicReferenceError) Error() string {
+	return fmt.Sprintf("ion: cyclic reference through a %v", e.Type)
+}
+
+// A PathElement is one step on the path from a top-level value to a value
+// nested within it: either a struct field, or an element of a list or sexp.
+type PathElement struct {
+	// Field is the name of a struct field.
+	Field string
+	// Index is the position of a list or sexp element, or -1 for a struct field.
+	Index int
+}
+
+func (p PathElement) String() string {
+	if p.Index < 0 {
+		return p.Field
+	}
+	return fmt.Sprintf("[%v]", p.Index)
+}
+
+// A DecodeError is returned when a Decoder fails to decode a value nested
+// within the top-level value being decoded. Path leads from the top-level value
+// to the one that failed, and Err is the reason it failed.
+type DecodeError struct {
+	Path []PathElement
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("ion: cannot decode value at %v: %v", formatPath(e.Path), e.Err)
+}
+
+// Unwrap returns the reason the value failed to decode.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// FormatPath formats a path as, for example, a.b[2].c.
+func formatPath(path []PathElement) string {
+	buf := strings.Builder{}
+	for i, p := range path {
+		if i > 0 && p.Index < 0 {
+			buf.WriteByte('.')
+		}
+		buf.WriteString(p.String())
+	}
+	return buf.String()
+}
+
+// WithPathElement adds the given element to the front of the path of err,
+// wrapping it in a DecodeError if it isn't one already.
+func withPathElement(err error, p PathElement) error {
+	if de, ok := err.(*DecodeError); ok {
+		de.Path = append([]PathElement{p}, de.Path...)
+		return de
+	}
+	return &DecodeError{Path: []PathElement{p}, Err: err}
+}
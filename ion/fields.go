@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 // A field is a reflectively-accessed field of a struct type.
@@ -27,8 +28,10 @@ type field struct {
 	typ         reflect.Type
 	path        []int
 	omitEmpty   bool
+	required    bool
 	hint        Type
 	annotations bool
+	remaining   bool
 }
 
 func (f *field) setopts(opts string) {
@@ -45,14 +48,24 @@ func (f *field) setopts(opts string) {
 		switch o {
 		case "omitempty":
 			f.omitEmpty = true
+		case "required":
+			f.required = true
+		case "string":
+			f.hint = StringType
 		case "symbol":
 			f.hint = SymbolType
+		case "blob":
+			f.hint = BlobType
 		case "clob":
 			f.hint = ClobType
+		case "list":
+			f.hint = ListType
 		case "sexp":
 			f.hint = SexpType
 		case "annotations":
 			f.annotations = true
+		case "remaining":
+			f.remaining = true
 		}
 	}
 }
@@ -63,12 +76,69 @@ type fielder struct {
 	index  map[string]bool
 }
 
-// FieldsFor returns the fields of the given struct type.
-// https://github.com/amzn/ion-go/issues/117
-func fieldsFor(t reflect.Type) []field {
+// StructFields holds the fields of a struct type, indexed by name.
+type structFields struct {
+	list   []field
+	byName map[string]int
+
+	// Remaining is the field tagged `ion:",remaining"`, if any, which holds
+	// the Ion fields that match no other field. It is not in list.
+	remaining *field
+}
+
+// Find returns the field with the given name, or failing that the first whose
+// name matches it case-insensitively, or nil if there is none.
+func (s *structFields) find(name string) *field {
+	if i, ok := s.byName[name]; ok {
+		return &s.list[i]
+	}
+	for i := range s.list {
+		if strings.EqualFold(s.list[i].name, name) {
+			return &s.list[i]
+		}
+	}
+	return nil
+}
+
+// FieldCache maps struct types to their *structFields, so that each type is
+// inspected only once however many values of it are encoded or decoded.
+var fieldCache sync.Map
+
+// StructFieldsFor returns the fields of the given struct type. They are shared
+// by every caller, which must not modify them.
+func structFieldsFor(t reflect.Type) *structFields {
+	if s, ok := fieldCache.Load(t); ok {
+		return s.(*structFields)
+	}
+
 	fldr := fielder{index: map[string]bool{}}
 	fldr.inspect(t, nil)
-	return fldr.fields
+
+	s := &structFields{byName: make(map[string]int, len(fldr.fields))}
+	for i := range fldr.fields {
+		f := &fldr.fields[i]
+		if !f.remaining {
+			s.byName[f.name] = len(s.list)
+			s.list = append(s.list, *f)
+			continue
+		}
+		if s.remaining != nil {
+			panic(fmt.Sprintf("too many remaining fields in %v", t))
+		}
+		if f.typ.Kind() != reflect.Map || f.typ.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("remaining field %v of %v must be a map with string keys", f.name, t))
+		}
+		s.remaining = f
+	}
+
+	actual, _ := fieldCache.LoadOrStore(t, s)
+	return actual.(*structFields)
+}
+
+// FieldsFor returns the fields of the given struct type, which the caller must
+// not modify.
+func fieldsFor(t reflect.Type) []field {
+	return structFieldsFor(t).list
 }
 
 // Inspect recursively inspects a type to determine all of its fields.
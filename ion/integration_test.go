@@ -98,15 +98,6 @@ var malformedIonsSkipList = []string{
 	"minLongWithLenTooSmall.10n",
 	"nopPadTooShort.10n",
 	"nullDotCommentInt.ion",
-	"surrogate_1.ion",
-	"surrogate_10.ion",
-	"surrogate_2.ion",
-	"surrogate_4.ion",
-	"surrogate_5.ion",
-	"surrogate_6.ion",
-	"surrogate_7.ion",
-	"surrogate_8.ion",
-	"surrogate_9.ion",
 }
 
 var equivsSkipList = []string{
@@ -0,0 +1,59 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"sync"
+)
+
+// An Interner deduplicates symbol text. Readers pass the text of each symbol
+// they read (from local symbol tables, field names, annotations, and symbol
+// values) through their Interner, keeping the string it returns. Sharing an
+// Interner between the readers of many documents with overlapping symbols lets
+// them share a single copy of each symbol's text.
+//
+// Intern must be safe for concurrent use if the Interner is shared between
+// readers used from multiple goroutines.
+type Interner interface {
+	Intern(s string) string
+}
+
+// NewInterner returns an Interner, safe for concurrent use, that keeps one
+// copy of each distinct string it is given. It never forgets a string, so
+// its memory use grows with the number of distinct symbols seen.
+func NewInterner() Interner {
+	return &mapInterner{}
+}
+
+type mapInterner struct {
+	m sync.Map
+}
+
+func (i *mapInterner) Intern(s string) string {
+	if v, ok := i.m.Load(s); ok {
+		return v.(string)
+	}
+	v, _ := i.m.LoadOrStore(s, s)
+	return v.(string)
+}
+
+// Intern passes s through the given interner, if there is one.
+func intern(in Interner, s string) string {
+	if in == nil {
+		return s
+	}
+	return in.Intern(s)
+}
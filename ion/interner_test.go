@@ -0,0 +1,126 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingInterner struct {
+	Interner
+	seen map[string]int
+}
+
+func (c *countingInterner) Intern(s string) string {
+	c.seen[s]++
+	return c.Interner.Intern(s)
+}
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	assert.Equal(t, "foo", in.Intern(string([]byte("foo"))))
+	assert.Equal(t, "foo", in.Intern(string([]byte("foo"))))
+	assert.Equal(t, "bar", in.Intern("bar"))
+}
+
+func TestReaderInterner(t *testing.T) {
+	text := "$ion_symbol_table::{symbols:[\"foo\"]} a::{b:c, \"d\":'e'} $10"
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.Annotation(NewSymbolTokenFromString("a")))
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("b")))
+	require.NoError(t, w.WriteSymbolFromString("c"))
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.Finish())
+
+	test := func(name string, data []byte, expected map[string]int) {
+		t.Run(name, func(t *testing.T) {
+			in := &countingInterner{NewInterner(), map[string]int{}}
+			r := System{Interner: in}.NewReaderBytes(data)
+			require.NoError(t, validate(r))
+			assert.Equal(t, expected, in.seen)
+		})
+	}
+
+	test("text", []byte(text), map[string]int{
+		"$ion_symbol_table": 1, "symbols": 1, "foo": 1,
+		"a": 1, "b": 1, "c": 1, "d": 1, "e": 1, "$10": 1,
+	})
+	test("binary", buf.Bytes(), map[string]int{"a": 1, "b": 1, "c": 1})
+}
+
+func BenchmarkReaderInterner(b *testing.B) {
+	// Many small documents, each with its own local symbol table, sharing
+	// most of their symbols.
+	var docs [][]byte
+	for i := 0; i < 100; i++ {
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		for j := 0; j < 50; j++ {
+			name := fmt.Sprintf("a_fairly_long_field_name_%v", j)
+			require.NoError(b, w.BeginStruct())
+			require.NoError(b, w.FieldName(NewSymbolTokenFromString(name)))
+			require.NoError(b, w.WriteInt(int64(i)))
+			require.NoError(b, w.EndStruct())
+		}
+		require.NoError(b, w.Finish())
+		docs = append(docs, buf.Bytes())
+	}
+
+	bench := func(name string, newInterner func() Interner) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			// Report how much memory stays live holding on to every document's
+			// symbol table, which is what interning saves.
+			var before, after runtime.MemStats
+			retained := int64(0)
+
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				sys := System{Interner: newInterner()}
+				var tables []SymbolTable
+				for _, doc := range docs {
+					r := sys.NewReaderBytes(doc)
+					for r.Next() {
+					}
+					require.NoError(b, r.Err())
+					tables = append(tables, r.SymbolTable())
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += int64(after.HeapAlloc) - int64(before.HeapAlloc)
+				runtime.KeepAlive(tables)
+			}
+
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+
+	bench("without", func() Interner { return nil })
+	bench("shared", NewInterner)
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -30,7 +31,24 @@ type EncoderOpts uint
 
 const (
 	// EncodeSortMaps instructs the encoder to write map keys in sorted order.
-	EncodeSortMaps EncoderOpts = 1
+	EncodeSortMaps EncoderOpts = 1 << iota
+
+	// EncodeTypeAnnotations instructs the encoder to annotate values whose Go
+	// type would otherwise be lost when decoded into an interface{}, so that a
+	// Decoder with DecodeTypeAnnotations can reconstruct it. The annotations
+	// used are reserved, all starting with "$go_":
+	//
+	//     $go_int8, $go_int16, $go_int32, $go_int64   signed integers other than int
+	//     $go_uint, $go_uint8, ..., $go_uintptr       unsigned integers
+	//     $go_float32                                 float32
+	//     $go_time                                    time.Time
+	//
+	// Other values (bool, int, float64, string, []byte, ion.Decimal,
+	// ion.Timestamp, ion.SymbolToken, slices, maps, and structs) already decode
+	// back to their own Go type, or as near as an interface{} allows, and are not
+	// annotated. Named types are annotated with their underlying type, which is
+	// what they decode back to.
+	EncodeTypeAnnotations
 )
 
 // Marshaler is the interface implemented by types that can marshal themselves to Ion.
@@ -38,6 +56,21 @@ type Marshaler interface {
 	MarshalIon(w Writer) error
 }
 
+// IonTyper is the interface implemented by types that want to be encoded as a
+// particular Ion type without implementing Marshaler, e.g. a string type that
+// should be written as a symbol or a clob, or a slice type that should be written
+// as a sexp. It applies wherever the type's kind supports the returned type,
+// which may be any of the hints that may be given as struct tags: string,
+// symbol, or clob for a string; blob or clob for a []byte; and list or sexp
+// for any other slice or array. Any other type is ignored, and the value is
+// encoded as it would be without IonType.
+//
+// Both a Marshaler implementation and an explicit type hint, given via a struct
+// tag or EncodeAs, take precedence over IonType.
+type IonTyper interface {
+	IonType() Type
+}
+
 // MarshalText marshals values to text ion.
 //
 // Different Go types can be passed into MarshalText() to be marshalled to their corresponding Ion types. e.g.,
@@ -80,6 +113,49 @@ type Marshaler interface {
 //         t.Fatal(err)
 //     }
 //
+// A map field tagged `ion:",remaining"`, which holds the fields Unmarshal
+// found no other place for, is marshalled by writing its entries as fields of
+// the enclosing struct, after the struct's own fields. An entry whose key names
+// one of the struct's own fields is left out.
+//
+// Slices and arrays are marshalled as Ion lists by default. A slice or array
+// field tagged `ion:",sexp"` is marshalled as an Ion sexp instead, as are any
+// slices nested inside it, which makes it possible to round-trip sexp-based
+// DSLs through Go types. Elements are written in order; Ion gives sexps no
+// other semantics, so operators are just symbols and should be held in
+// SymbolTokens (or in a string type that implements IonTyper) to be written
+// back out as symbols rather than strings.
+//
+//     type expr struct {
+//         Expr []interface{} `ion:"expr,sexp"`
+//     }
+//
+//     plus := NewSymbolTokenFromString("+")
+//     val, err := MarshalText(expr{[]interface{}{plus, 1, 2}}) // {expr:(+ 1 2)}
+//
+// Both lists and sexps may be unmarshalled into a slice or array, whether or
+// not it is tagged `ion:",sexp"`.
+//
+// A json.Number, as produced by a json.Decoder with UseNumber, is marshalled
+// as an Ion int if its text is an integer, with neither a fraction nor an
+// exponent, and as an Ion decimal otherwise, so that no precision is lost to
+// float rounding: "12" becomes 12, while "12.0" becomes 12.0 and "1e3" becomes
+// 1d3.
+//
+// A big.Rat is marshalled as an Ion decimal if its decimal expansion
+// terminates; otherwise, there being no exact decimal for it, marshalling it
+// fails unless an Encoder is given a precision with WithRatPrecision.
+//
+// A net.IP, url.URL, netip.Addr, netip.AddrPort, or netip.Prefix is
+// marshalled as an Ion string holding its usual text form, such as "10.0.0.1"
+// or "https://example.com/a?b=c", rather than as a blob or struct, and may be
+// unmarshalled from a string or symbol; a nil net.IP becomes null.string.
+//
+// Ion has no way to represent a cyclic value, so marshalling a value that
+// refers to itself, through pointers, maps, or slices, fails with a
+// CyclicReferenceError. A value that merely shares a pointer in more than one
+// place is fine; it is written out in full at each.
+//
 func MarshalText(v interface{}) ([]byte, error) {
 	buf := bytes.Buffer{}
 	w := NewTextWriterOpts(&buf, TextWriterQuietFinish)
@@ -130,6 +206,33 @@ func MarshalBinaryLST(v interface{}, lst SymbolTable) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// EncodedBinarySize returns the number of bytes MarshalBinary would produce for
+// the given value, without keeping the bytes. The size is that of the whole
+// binary stream, so it includes the 4-byte version marker and the local symbol
+// table declaring the value's field names and symbols, not just the value.
+func EncodedBinarySize(v interface{}, ssts ...SharedSymbolTable) (int, error) {
+	n := byteCounter(0)
+	w := NewBinaryWriter(&n, ssts...)
+	e := Encoder{w: w}
+
+	if err := e.Encode(v); err != nil {
+		return 0, err
+	}
+	if err := e.Finish(); err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// A byteCounter is an io.Writer that counts, then discards, what's written to it.
+type byteCounter int
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
 // MarshalTo marshals the given value to the given writer. It does
 // not call Finish, so is suitable for encoding values inside of
 // a partially-constructed Ion value.
@@ -140,10 +243,50 @@ func MarshalTo(w Writer, v interface{}) error {
 	return e.Encode(v)
 }
 
+// WriteAll marshals each of the given values as a top-level Ion text value,
+// in order, and finishes the stream. It is the inverse of ReadAll.
+func WriteAll[T any](w io.Writer, vals []T) error {
+	e := NewTextEncoder(w)
+	for _, v := range vals {
+		if err := e.Encode(v); err != nil {
+			return err
+		}
+	}
+	return e.Finish()
+}
+
 // An Encoder writes Ion values to an output stream.
 type Encoder struct {
 	w    Writer
 	opts EncoderOpts
+
+	roundRats bool
+	ratDigits int
+	utc       bool
+
+	resetEvery int
+	records    int
+
+	typeNames *TypeNames
+
+	// Depth counts the pointers, maps, and slices being encoded, and seen
+	// holds those deeper than startDetectingCyclesAfter.
+	depth int
+	seen  map[reference]struct{}
+}
+
+// StartDetectingCyclesAfter is the depth of pointers, maps, and slices after
+// which the encoder starts checking for cycles. Below it, the check costs
+// more than it is worth; a cyclic value soon gets past it.
+const startDetectingCyclesAfter = 1000
+
+// A reference identifies a pointer, map, or slice being encoded. A slice is
+// identified by its length as well, since a slice and a shorter one starting
+// at the same element are different values.
+type reference struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
 }
 
 // NewEncoder creates a new encoder.
@@ -176,14 +319,33 @@ func NewBinaryEncoderLST(w io.Writer, lst SymbolTable) *Encoder {
 
 // Encode marshals the given value to Ion, writing it to the underlying writer.
 func (m *Encoder) Encode(v interface{}) error {
-	return m.encodeValue(reflect.ValueOf(v), NoType)
+	if err := m.encodeValue(reflect.ValueOf(v), NoType); err != nil {
+		return err
+	}
+	return m.endRecord()
 }
 
 // EncodeAs marshals the given value to Ion with the given type hint. Use it to
 // encode symbols, clobs, or sexps (which by default get encoded to strings, blobs,
 // and lists respectively).
 func (m *Encoder) EncodeAs(v interface{}, hint Type) error {
-	return m.encodeValue(reflect.ValueOf(v), hint)
+	if err := m.encodeValue(reflect.ValueOf(v), hint); err != nil {
+		return err
+	}
+	return m.endRecord()
+}
+
+// EndRecord counts a value just encoded, finishing the current datagram if
+// it's time to start a new one.
+func (m *Encoder) endRecord() error {
+	if m.resetEvery <= 0 {
+		return nil
+	}
+	m.records++
+	if m.records%m.resetEvery != 0 {
+		return nil
+	}
+	return m.w.Finish()
 }
 
 // Finish finishes writing the current Ion datagram.
@@ -191,7 +353,65 @@ func (m *Encoder) Finish() error {
 	return m.w.Finish()
 }
 
+// WithRatPrecision sets the number of digits after the decimal point to which
+// a big.Rat is rounded, half to even, when its decimal expansion does not
+// terminate, as 1/3's does not. Without it, such a rational can't be encoded
+// and Encode returns an error. A big.Rat whose expansion terminates, such as
+// 1/8, is always encoded exactly, as the Ion decimal 0.125, whatever the
+// precision; a negative number of digits restores the default.
+func (m *Encoder) WithRatPrecision(digits int) *Encoder {
+	m.roundRats = digits >= 0
+	m.ratDigits = digits
+	return m
+}
+
+// WithTimestampsUTC instructs the encoder to convert every time.Time and
+// Timestamp to UTC before writing it, so that a value's local offset is never
+// written out. Timestamps of day precision or less have no offset and are
+// written as is. It returns m.
+func (m *Encoder) WithTimestampsUTC() *Encoder {
+	m.utc = true
+	return m
+}
+
+// WithSymbolTableResetEvery instructs the encoder to finish the current
+// datagram after every n values it encodes, so that the output is a series of
+// independent segments; in binary, each starts with a version marker and its
+// own local symbol table, holding only the symbols its values use. A reader
+// can then start reading at the start of any segment, e.g. to resume or seek
+// within a large stream; the length of the output after every nth value is
+// such a boundary. The cost is size: each segment repeats the symbols it
+// uses, so the smaller n is, the larger the output. A value of zero or less,
+// the default, never resets. It returns m.
+func (m *Encoder) WithSymbolTableResetEvery(n int) *Encoder {
+	m.resetEvery = n
+	m.records = 0
+	return m
+}
+
+// WithStructTypeNames instructs the encoder to annotate every Go struct it
+// encodes as an Ion struct, nested ones included, with the name names gives
+// its type: the name it was registered under, or else its Go type name.
+// Anonymous structs, and structs encoded as other Ion types, such as
+// time.Time, are not annotated. A struct with an `ion:",annotations"` field
+// wraps a single value, and is written with its annotations followed by the
+// type name of that value, if it is a struct; a Decoder given the same names
+// leaves registered names out of the annotations it decodes into such a
+// field, so they are not written twice if the value is encoded again.
+// Passing nil turns annotating off. It returns m.
+func (m *Encoder) WithStructTypeNames(names *TypeNames) *Encoder {
+	m.typeNames = names
+	return m
+}
+
+// Flush flushes the values encoded so far to the underlying writer,
+// without finishing the current Ion datagram.
+func (m *Encoder) Flush() error {
+	return m.w.Flush()
+}
+
 var marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+var ionTyperType = reflect.TypeOf((*IonTyper)(nil)).Elem()
 
 // EncodeValue recursively encodes a value.
 func (m *Encoder) encodeValue(v reflect.Value, hint Type) error {
@@ -200,13 +420,57 @@ func (m *Encoder) encodeValue(v reflect.Value, hint Type) error {
 	}
 
 	t := v.Type()
-	if t.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(t).Implements(marshalerType) {
+	if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) && v.IsNil() {
+		// A nil pointer is null, even if its type implements Marshaler.
+		return m.w.WriteNull()
+	}
+	if t.Kind() == reflect.Interface {
+		// Look through the interface, in case it holds a nil pointer.
+		return m.encodeValue(v.Elem(), hint)
+	}
+	if t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(marshalerType) {
+		if !v.CanAddr() {
+			// Copy the value so the pointer method can be called on it,
+			// rather than encoding its fields.
+			c := reflect.New(t).Elem()
+			c.Set(v)
+			v = c
+		}
 		return v.Addr().Interface().(Marshaler).MarshalIon(m.w)
 	}
 	if t.Implements(marshalerType) {
 		return v.Interface().(Marshaler).MarshalIon(m.w)
 	}
 
+	// An explicit hint applies to the elements of a container as well as the
+	// container itself; one from IonType only applies to the value itself.
+	elemHint := hint
+	if hint == NoType && t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+		if t.Implements(ionTyperType) {
+			hint = v.Interface().(IonTyper).IonType()
+		} else if v.CanAddr() && reflect.PtrTo(t).Implements(ionTyperType) {
+			hint = v.Addr().Interface().(IonTyper).IonType()
+		}
+	}
+
+	if m.opts&EncodeTypeAnnotations != 0 {
+		if a, ok := goTypeAnnotationFor(t); ok {
+			if err := m.w.Annotation(NewSymbolTokenFromString(a)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if t == jsonNumberType {
+		return m.encodeJSONNumber(v.String())
+	}
+	if t == rawValueType {
+		return m.encodeRawValue(v)
+	}
+	if textTypes[t] {
+		return m.encodeTextValue(v, hint)
+	}
+
 	switch t.Kind() {
 	case reflect.Bool:
 		return m.w.WriteBool(v.Bool())
@@ -226,8 +490,11 @@ func (m *Encoder) encodeValue(v reflect.Value, hint Type) error {
 		return m.w.WriteFloat(v.Float())
 
 	case reflect.String:
-		if hint == SymbolType {
+		switch hint {
+		case SymbolType:
 			return m.w.WriteSymbolFromString(v.String())
+		case ClobType:
+			return m.w.WriteClob([]byte(v.String()))
 		}
 		return m.w.WriteString(v.String())
 
@@ -238,33 +505,154 @@ func (m *Encoder) encodeValue(v reflect.Value, hint Type) error {
 		return m.encodeStruct(v)
 
 	case reflect.Map:
-		return m.encodeMap(v, hint)
+		return m.encodeMap(v, elemHint)
 
 	case reflect.Slice:
-		return m.encodeSlice(v, hint)
+		return m.encodeSlice(v, hint, elemHint)
 
 	case reflect.Array:
-		return m.encodeArray(v, hint)
+		return m.encodeArray(v, hint, elemHint)
 
 	default:
 		return fmt.Errorf("ion: unsupported type: %v", v.Type().String())
 	}
 }
 
+// EncodeJSONNumber encodes a json.Number. One whose text is an integer, with
+// neither a fraction nor an exponent, is written as an Ion int; any other is
+// written as an Ion decimal, so that no precision is lost to float rounding.
+// An empty json.Number is written as 0, as encoding/json does.
+func (m *Encoder) encodeJSONNumber(s string) error {
+	if s == "" {
+		return m.w.WriteInt(0)
+	}
+	if !isValidJSONNumber(s) {
+		return fmt.Errorf("ion: invalid json.Number %q", s)
+	}
+
+	if !strings.ContainsAny(s, ".eE") {
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return fmt.Errorf("ion: invalid json.Number %q", s)
+		}
+		if i.IsInt64() {
+			return m.w.WriteInt(i.Int64())
+		}
+		return m.w.WriteBigInt(i)
+	}
+
+	dec, err := ParseDecimal(strings.NewReplacer("e+", "d", "E+", "d", "e", "d", "E", "d").Replace(s))
+	if err != nil {
+		return fmt.Errorf("ion: invalid json.Number %q", s)
+	}
+	return m.w.WriteDecimal(dec)
+}
+
+// IsValidJSONNumber returns true if s is a number as JSON defines it.
+func isValidJSONNumber(s string) bool {
+	digits := func(i int) int {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		i = digits(i)
+	default:
+		return false
+	}
+
+	if i < len(s) && s[i] == '.' {
+		j := digits(i + 1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := digits(i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+
+	return i == len(s)
+}
+
 // EncodePtr encodes an Ion null if the pointer is nil, and otherwise encodes the value that
 // the pointer is pointing to.
 func (m *Encoder) encodePtr(v reflect.Value, hint Type) error {
 	if v.IsNil() {
 		return m.w.WriteNull()
 	}
+	if err := m.enter(v); err != nil {
+		return err
+	}
+	defer m.leave(v)
+
 	return m.encodeValue(v.Elem(), hint)
 }
 
+// Enter notes that the encoder is descending through the given pointer, map,
+// or slice, returning a CyclicReferenceError if it is already within it.
+// Every successful call must be matched by a call to leave.
+func (m *Encoder) enter(v reflect.Value) error {
+	m.depth++
+	if m.depth <= startDetectingCyclesAfter {
+		return nil
+	}
+
+	ref := referenceTo(v)
+	if _, ok := m.seen[ref]; ok {
+		m.depth--
+		return &CyclicReferenceError{v.Type()}
+	}
+	if m.seen == nil {
+		m.seen = map[reference]struct{}{}
+	}
+	m.seen[ref] = struct{}{}
+	return nil
+}
+
+// Leave notes that the encoder has finished with a value passed to enter.
+func (m *Encoder) leave(v reflect.Value) {
+	if m.depth > startDetectingCyclesAfter {
+		delete(m.seen, referenceTo(v))
+	}
+	m.depth--
+}
+
+func referenceTo(v reflect.Value) reference {
+	ref := reference{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		ref.len = v.Len()
+	}
+	return ref
+}
+
 // EncodeMap encodes a map to the output writer as an Ion struct.
 func (m *Encoder) encodeMap(v reflect.Value, hint Type) error {
 	if v.IsNil() {
 		return m.w.WriteNull()
 	}
+	if err := m.enter(v); err != nil {
+		return err
+	}
+	defer m.leave(v)
 
 	err := m.w.BeginStruct()
 	if err != nil {
@@ -317,7 +705,7 @@ func keysFor(v reflect.Value) []mapkey {
 }
 
 // EncodeSlice encodes a slice to the output writer as an appropriate Ion type.
-func (m *Encoder) encodeSlice(v reflect.Value, hint Type) error {
+func (m *Encoder) encodeSlice(v reflect.Value, hint, elemHint Type) error {
 	elem := v.Type().Elem()
 	if elem.Kind() == reflect.Uint8 && !elem.Implements(marshalerType) {
 		return m.encodeBlob(v, hint)
@@ -326,8 +714,12 @@ func (m *Encoder) encodeSlice(v reflect.Value, hint Type) error {
 	if v.IsNil() {
 		return m.w.WriteNull()
 	}
+	if err := m.enter(v); err != nil {
+		return err
+	}
+	defer m.leave(v)
 
-	return m.encodeArray(v, hint)
+	return m.encodeArray(v, hint, elemHint)
 }
 
 // EncodeBlob encodes a []byte to the output writer as an Ion blob.
@@ -342,7 +734,7 @@ func (m *Encoder) encodeBlob(v reflect.Value, hint Type) error {
 }
 
 // EncodeArray encodes an array to the output writer as an Ion list (or sexp).
-func (m *Encoder) encodeArray(v reflect.Value, hint Type) error {
+func (m *Encoder) encodeArray(v reflect.Value, hint, elemHint Type) error {
 	if hint == SexpType {
 		err := m.w.BeginSexp()
 		if err != nil {
@@ -356,7 +748,7 @@ func (m *Encoder) encodeArray(v reflect.Value, hint Type) error {
 	}
 
 	for i := 0; i < v.Len(); i++ {
-		if err := m.encodeValue(v.Index(i), hint); err != nil {
+		if err := m.encodeValue(v.Index(i), elemHint); err != nil {
 			return err
 		}
 	}
@@ -386,6 +778,20 @@ func (m *Encoder) encodeStruct(v reflect.Value) error {
 	if t == decimalType {
 		return m.encodeDecimal(v)
 	}
+	if t == ratType {
+		return m.encodeRat(v)
+	}
+	if t == symbolType {
+		return m.w.WriteSymbol(v.Interface().(SymbolToken))
+	}
+
+	if m.typeNames != nil {
+		if name := m.typeNames.nameFor(t); name != "" {
+			if err := m.w.Annotation(NewSymbolTokenFromString(name)); err != nil {
+				return err
+			}
+		}
+	}
 
 	if err := m.w.BeginStruct(); err != nil {
 		return err
@@ -418,12 +824,62 @@ FieldLoop:
 		}
 	}
 
+	if err := m.encodeRemainingFields(v); err != nil {
+		return err
+	}
+
 	return m.w.EndStruct()
 }
 
+// EncodeRemainingFields writes the entries of v's `ion:",remaining"` map, if
+// it has one, as fields of the struct being written. Entries whose keys name
+// one of v's own fields are left out, so that no field is written twice.
+func (m *Encoder) encodeRemainingFields(v reflect.Value) error {
+	sfs := structFieldsFor(v.Type())
+	f := sfs.remaining
+	if f == nil {
+		return nil
+	}
+
+	mv := v
+	for _, i := range f.path {
+		if mv.Kind() == reflect.Ptr {
+			if mv.IsNil() {
+				return nil
+			}
+			mv = mv.Elem()
+		}
+		mv = mv.Field(i)
+	}
+	if mv.IsNil() {
+		return nil
+	}
+
+	keys := keysFor(mv)
+	if m.opts&EncodeSortMaps != 0 {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].s < keys[j].s })
+	}
+
+	for _, key := range keys {
+		if _, ok := sfs.byName[key.s]; ok {
+			continue
+		}
+		if err := m.w.FieldName(NewSymbolTokenFromString(key.s)); err != nil {
+			return err
+		}
+		if err := m.encodeValue(mv.MapIndex(key.v), f.hint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // encodeTimestamp encodes a timestamp to the output writer as an Ion timestamp.
 func (m *Encoder) encodeTimestamp(v reflect.Value) error {
 	t := v.Interface().(Timestamp)
+	if m.utc {
+		t = t.UTC()
+	}
 	return m.w.WriteTimestamp(t)
 }
 
@@ -431,25 +887,27 @@ func (m *Encoder) encodeTimestamp(v reflect.Value) error {
 func (m *Encoder) encodeTimeDate(v reflect.Value) error {
 	t := v.Interface().(time.Time)
 
-	// Get the time zone kind to build a Timestamp
-	zoneName, zoneOffset := t.Zone()
-	var kind TimezoneKind
-	if zoneName != "" && zoneOffset == 0 {
-		kind = TimezoneUTC
-	} else if zoneName != "" && zoneOffset != 0 {
-		kind = TimezoneLocal
-	} else {
-		kind = TimezoneUnspecified
-	}
-
 	// Time.Date has nano second component
-	timestamp := NewTimestampWithFractionalSeconds(t, TimestampPrecisionNanosecond, kind, maxFractionalPrecision)
+	timestamp := NewTimestampWithFractionalSeconds(t, TimestampPrecisionNanosecond, TimezoneKindForTime(t), maxFractionalPrecision)
+	if m.utc {
+		timestamp = timestamp.UTC()
+	}
 	return m.w.WriteTimestamp(timestamp)
 }
 
 // EncodeDecimal encodes an ion.Decimal to the output writer as an Ion decimal.
 func (m *Encoder) encodeDecimal(v reflect.Value) error {
-	d := v.Addr().Interface().(*Decimal)
+	d := v.Interface().(Decimal)
+	return m.w.WriteDecimal(&d)
+}
+
+// EncodeRat encodes a big.Rat to the output writer as an Ion decimal.
+func (m *Encoder) encodeRat(v reflect.Value) error {
+	r := v.Interface().(big.Rat)
+	d, ok := decimalFromRat(&r, m.ratDigits, m.roundRats)
+	if !ok {
+		return fmt.Errorf("ion: %v has no exact decimal representation; set a precision with Encoder.WithRatPrecision", r.RatString())
+	}
 	return m.w.WriteDecimal(d)
 }
 
@@ -17,7 +17,12 @@ package ion
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -314,6 +319,67 @@ func TestMarshalCustomMarshaler(t *testing.T) {
 	assert.Equal(t, eval, val)
 }
 
+// ptrMarshalMe implements Marshaler with a pointer receiver.
+type ptrMarshalMe struct {
+	n int
+}
+
+func (m *ptrMarshalMe) MarshalIon(w Writer) error {
+	return w.WriteSymbolFromString(fmt.Sprintf("n%v", m.n))
+}
+
+func TestMarshalPointers(t *testing.T) {
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			val, err := MarshalText(v)
+			require.NoError(t, err)
+			assert.Equal(t, eval, string(val))
+		})
+	}
+
+	i, s, b, f := 42, "str", true, 1.5
+	pi := &i
+
+	test(&i, "42")
+	test(&s, "\"str\"")
+	test(&b, "true")
+	test(&f, "1.5e+0")
+	test(&pi, "42")
+	test((*int)(nil), "null")
+	test((**int)(nil), "null")
+	test(&struct {
+		A int `ion:"a"`
+	}{1}, "{a:1}")
+
+	// Both value and pointer receivers are used, whatever the value is
+	// wrapped in, and a nil pointer is null rather than a call on nil.
+	m := four
+	test(&m, "FOUR")
+	test((*marshalMe)(nil), "null")
+	test(ptrMarshalMe{1}, "n1")
+	test(&ptrMarshalMe{2}, "n2")
+	test((*ptrMarshalMe)(nil), "null")
+	test([]ptrMarshalMe{{3}}, "[n3]")
+	test([]*ptrMarshalMe{{4}, nil}, "[n4,null]")
+	test(map[string]ptrMarshalMe{"a": {5}}, "{a:n5}")
+	test([]Marshaler{(*ptrMarshalMe)(nil), &m}, "[null,FOUR]")
+
+	type optional struct {
+		I   *int          `ion:"i"`
+		S   *string       `ion:"s"`
+		B   *bool         `ion:"b"`
+		P   **int         `ion:"p"`
+		M   *marshalMe    `ion:"m"`
+		PM  *ptrMarshalMe `ion:"pm"`
+		VM  ptrMarshalMe  `ion:"vm"`
+		Sub *optional     `ion:"sub,omitempty"`
+	}
+
+	test(optional{}, "{i:null,s:null,b:null,p:null,m:null,pm:null,vm:n0}")
+	test(optional{I: &i, S: &s, B: &b, P: &pi, M: &m, PM: &ptrMarshalMe{6}, VM: ptrMarshalMe{7}, Sub: &optional{I: &i}},
+		"{i:42,s:\"str\",b:true,p:42,m:FOUR,pm:n6,vm:n7,sub:{i:42,s:null,b:null,p:null,m:null,pm:null,vm:n0}}")
+}
+
 func TestMarshalValuesWithAnnotation(t *testing.T) {
 	test := func(v interface{}, testName, eval string) {
 		t.Run(testName, func(t *testing.T) {
@@ -344,3 +410,451 @@ func TestMarshalValuesWithAnnotation(t *testing.T) {
 	test(buildValue([]int{3, 5, 7}), "list", "'symbols or string'::annotations::[3,5,7]")
 	test(buildValue(map[string]int{"b": 2, "a": 1}), "struct", "'symbols or string'::annotations::{a:1,b:2}")
 }
+
+func TestWriteAll(t *testing.T) {
+	buf := strings.Builder{}
+	require.NoError(t, WriteAll(&buf, []int{1, 2, 3}))
+	assert.Equal(t, "1\n2\n3\n", buf.String())
+
+	type item struct {
+		Name string `ion:"name"`
+		Qty  int    `ion:"qty"`
+	}
+
+	items := []item{{"apple", 3}, {"pear", 0}}
+
+	buf.Reset()
+	require.NoError(t, WriteAll(&buf, items))
+	assert.Equal(t, "{name:\"apple\",qty:3}\n{name:\"pear\",qty:0}\n", buf.String())
+
+	val, err := ReadAll[item](strings.NewReader(buf.String()))
+	require.NoError(t, err)
+	assert.Equal(t, items, val)
+}
+
+type enumSymbol string
+
+func (enumSymbol) IonType() Type { return SymbolType }
+
+type rawText string
+
+func (rawText) IonType() Type { return ClobType }
+
+type opcodes []enumSymbol
+
+func (*opcodes) IonType() Type { return SexpType }
+
+func TestMarshalIonTyper(t *testing.T) {
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			val, err := MarshalText(v)
+			require.NoError(t, err)
+			assert.Equal(t, eval, string(val))
+		})
+	}
+
+	test(enumSymbol("red"), "red")
+	test(rawText("hi"), `{{"hi"}}`)
+	test([]enumSymbol{"a", "b"}, "[a,b]")
+
+	type doc struct {
+		Color enumSymbol `ion:"color"`
+		Body  rawText    `ion:"body"`
+		Ops   opcodes    `ion:"ops"`
+		Text  rawText    `ion:"text,string"`
+	}
+	test(&doc{"red", "hi", opcodes{"push", "pop"}, "str"}, `{color:red,body:{{"hi"}},ops:(push pop),text:"str"}`)
+
+	// A Marshaler wins over IonType.
+	test(struct{ V marshalMe }{two}, "{V:TWO}")
+}
+
+func TestMarshalSexpRoundTrip(t *testing.T) {
+	type rule struct {
+		Name string        `ion:"name"`
+		When []interface{} `ion:"when,sexp"`
+		Then []SymbolToken `ion:"then,sexp"`
+		Args [2]int        `ion:"args,sexp"`
+	}
+
+	ion := `{name:"r1",when:(and (> x 1) (< y 2)),then:(emit alert),args:(1 2)}`
+
+	var r rule
+	require.NoError(t, UnmarshalString(ion, &r))
+	assert.Equal(t, "r1", r.Name)
+	assert.Equal(t, 3, len(r.When))
+	assert.Equal(t, []SymbolToken{NewSymbolTokenFromString("emit"), NewSymbolTokenFromString("alert")}, r.Then)
+	assert.Equal(t, [2]int{1, 2}, r.Args)
+
+	// Operators come back out quoted, which is equivalent.
+	val, err := MarshalText(r)
+	require.NoError(t, err)
+	assert.Equal(t, `{name:"r1",when:(and ('>' x 1) ('<' y 2)),then:(emit alert),args:(1 2)}`, string(val))
+
+	var r1 rule
+	require.NoError(t, UnmarshalString(string(val), &r1))
+	assert.Equal(t, r, r1)
+
+	// Lists decode into sexp fields too.
+	var r2 rule
+	require.NoError(t, UnmarshalString(`{then:[a, b]}`, &r2))
+	assert.Equal(t, []SymbolToken{NewSymbolTokenFromString("a"), NewSymbolTokenFromString("b")}, r2.Then)
+}
+
+func TestMarshalTypeAnnotations(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	val := map[string]interface{}{
+		"b":    true,
+		"i":    1,
+		"i8":   int8(-8),
+		"i64":  int64(64),
+		"u":    uint(1),
+		"u16":  uint16(16),
+		"u64":  uint64(math.MaxUint64),
+		"f32":  float32(1.5),
+		"f64":  1.5,
+		"d":    MustParseDecimal("1.50"),
+		"s":    "str",
+		"sym":  NewSymbolTokenFromString("sym"),
+		"time": now,
+		"list": []interface{}{int16(1), "two", float32(3)},
+		"map":  map[string]interface{}{"x": uint8(1)},
+	}
+
+	test := func(name string, w func(*bytes.Buffer) Writer) {
+		t.Run(name, func(t *testing.T) {
+			encode := func(v interface{}) []byte {
+				buf := bytes.Buffer{}
+				e := NewEncoderOpts(w(&buf), EncodeTypeAnnotations|EncodeSortMaps)
+				require.NoError(t, e.Encode(v))
+				require.NoError(t, e.Finish())
+				return buf.Bytes()
+			}
+			bs := encode(val)
+
+			var res interface{}
+			d := NewDecoderOpts(NewReaderBytes(bs), DecodeTypeAnnotations)
+			require.NoError(t, d.DecodeTo(&res))
+
+			m := res.(map[string]interface{})
+			for _, k := range []string{"b", "i", "i8", "i64", "u", "u16", "u64", "f32", "time"} {
+				assert.Equal(t, val[k], m[k], k)
+			}
+			assert.Equal(t, val["map"], m["map"])
+			list := m["list"].([]interface{})
+			assert.Equal(t, int16(1), list[0])
+			assert.Equal(t, float32(3), list[2])
+
+			// Nothing is lost re-encoding the decoded value.
+			assert.Equal(t, bs, encode(res))
+
+			// Decode, as opposed to DecodeTo, follows the annotations too.
+			d = NewDecoderOpts(NewReaderBytes(bs), DecodeTypeAnnotations)
+			res, err := d.Decode()
+			require.NoError(t, err)
+			assert.Equal(t, float32(1.5), res.(map[string]interface{})["f32"])
+		})
+	}
+
+	test("text", func(b *bytes.Buffer) Writer { return NewTextWriter(b) })
+	test("binary", func(b *bytes.Buffer) Writer { return NewBinaryWriter(b) })
+
+	text, err := func() (string, error) {
+		buf := strings.Builder{}
+		e := NewEncoderOpts(NewTextWriter(&buf), EncodeTypeAnnotations)
+		if err := e.Encode([]interface{}{1, int32(2), float32(3)}); err != nil {
+			return "", err
+		}
+		return buf.String(), e.Finish()
+	}()
+	require.NoError(t, err)
+	assert.Equal(t, "[1,$go_int32::2,$go_float32::3e+0]", text)
+
+	// Without DecodeTypeAnnotations, the annotations are ordinary annotations.
+	type annotated struct {
+		Value       int32
+		Annotations []SymbolToken `ion:",annotations"`
+	}
+	var a annotated
+	require.NoError(t, UnmarshalString("$go_int32::5", &a))
+	require.Len(t, a.Annotations, 1)
+	assert.Equal(t, "$go_int32", *a.Annotations[0].Text)
+
+	a = annotated{}
+	d := NewDecoderOpts(NewReaderString("foo::$go_int32::5"), DecodeTypeAnnotations)
+	require.NoError(t, d.DecodeTo(&a))
+	assert.Equal(t, int32(5), a.Value)
+	require.Len(t, a.Annotations, 1)
+	assert.Equal(t, "foo", *a.Annotations[0].Text)
+}
+
+func TestMarshalJSONNumber(t *testing.T) {
+	test := func(n json.Number, eval string) {
+		t.Run(string(n), func(t *testing.T) {
+			val, err := MarshalText(n)
+			require.NoError(t, err)
+			assert.Equal(t, eval, string(val))
+		})
+	}
+
+	test("", "0")
+	test("0", "0")
+	test("-12", "-12")
+	test("123456789012345678901234567890", "123456789012345678901234567890")
+	test("12.0", "12.0")
+	test("-0.10", "-1.0d-1")
+	test("1e3", "1d3")
+	test("1E+3", "1d3")
+	test("2.5e-3", "2.5d-3")
+	test("0.1", "1d-1")
+
+	val, err := MarshalText(map[string]json.Number{"a": "1", "b": "1.5"})
+	require.NoError(t, err)
+	assert.Equal(t, "{a:1,b:1.5}", string(val))
+
+	for _, n := range []json.Number{"abc", "1.", ".5", "+1", "01", "1e", "1d3", "0x10", " 1"} {
+		_, err := MarshalText(n)
+		assert.Error(t, err, string(n))
+	}
+}
+
+func TestMarshalRemainingFields(t *testing.T) {
+	type record struct {
+		ID    string         `ion:"id"`
+		Extra map[string]int `ion:",remaining"`
+	}
+
+	buf := strings.Builder{}
+	e := NewEncoderOpts(NewTextWriterOpts(&buf, TextWriterQuietFinish), EncodeSortMaps)
+	require.NoError(t, e.Encode(record{"a", map[string]int{"y": 2, "x": 1, "id": 3}}))
+	require.NoError(t, e.Encode(record{ID: "b"}))
+	require.NoError(t, e.Finish())
+	assert.Equal(t, "{id:\"a\",x:1,y:2}\n{id:\"b\"}", buf.String())
+
+	var r record
+	require.NoError(t, UnmarshalString(buf.String(), &r))
+	assert.Equal(t, record{"a", map[string]int{"x": 1, "y": 2}}, r)
+}
+
+func TestMarshalRat(t *testing.T) {
+	test := func(r string, digits int, eval string) {
+		t.Run(fmt.Sprintf("%v/%v", r, digits), func(t *testing.T) {
+			rat, ok := new(big.Rat).SetString(r)
+			require.True(t, ok)
+
+			buf := strings.Builder{}
+			e := NewTextEncoder(&buf).WithRatPrecision(digits)
+			require.NoError(t, e.Encode(rat))
+			require.NoError(t, e.Finish())
+			assert.Equal(t, eval, strings.TrimSpace(buf.String()))
+		})
+	}
+
+	// Terminating expansions are exact, whatever the precision.
+	test("0", -1, "0.")
+	test("3", -1, "3.")
+	test("-5/4", -1, "-1.25")
+	test("1/8", 1, "1.25d-1")
+	test("1/1024", -1, "9.765625d-4")
+	test("7/20", -1, "3.5d-1")
+
+	// Others are rounded half to even.
+	test("1/3", 4, "3.333d-1")
+	test("2/3", 4, "6.667d-1")
+	test("-2/3", 2, "-6.7d-1")
+	test("1/3", 0, "0.")
+	test("5/6", 1, "8d-1")
+	test("1/6", 0, "0.")
+	test("-7/3", 0, "-2.")
+
+	// Without a precision, they can't be marshalled.
+	_, err := MarshalText(big.NewRat(1, 3))
+	assert.Error(t, err)
+	_, err = MarshalBinary(struct{ R big.Rat }{*big.NewRat(2, 7)})
+	assert.Error(t, err)
+
+	val, err := MarshalText(struct{ R *big.Rat }{big.NewRat(3, 2)})
+	require.NoError(t, err)
+	assert.Equal(t, "{R:1.5}", string(val))
+
+	val, err = MarshalText(struct{ R *big.Rat }{})
+	require.NoError(t, err)
+	assert.Equal(t, "{R:null}", string(val))
+}
+
+func TestMarshalTimestampsUTC(t *testing.T) {
+	zone := time.FixedZone("IST", 5*60*60+30*60)
+	local := time.Date(2021, 1, 2, 9, 4, 5, 0, zone)
+
+	test := func(v interface{}, utc bool, eval string) {
+		t.Run(fmt.Sprintf("%v/%v", v, utc), func(t *testing.T) {
+			buf := strings.Builder{}
+			e := NewTextEncoder(&buf)
+			if utc {
+				e = e.WithTimestampsUTC()
+			}
+			require.NoError(t, e.Encode(v))
+			require.NoError(t, e.Finish())
+			assert.Equal(t, eval, strings.TrimSpace(buf.String()))
+		})
+	}
+
+	test(local, false, "2021-01-02T09:04:05.000000000+05:30")
+	test(local, true, "2021-01-02T03:34:05.000000000Z")
+	test(NewTimestamp(local, TimestampPrecisionSecond, TimezoneLocal), false, "2021-01-02T09:04:05+05:30")
+	test(NewTimestamp(local, TimestampPrecisionSecond, TimezoneLocal), true, "2021-01-02T03:34:05Z")
+	test(struct{ T *time.Time }{&local}, true, "{T:2021-01-02T03:34:05.000000000Z}")
+
+	// Dates have no offset to convert.
+	test(NewDateTimestamp(local, TimestampPrecisionDay), true, "2021-01-02T")
+}
+
+func TestMarshalCyclic(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+
+	test := func(name string, v interface{}) {
+		t.Run(name, func(t *testing.T) {
+			for _, marshal := range []func(interface{}) ([]byte, error){
+				MarshalText,
+				func(v interface{}) ([]byte, error) { return MarshalBinary(v) },
+			} {
+				_, err := marshal(v)
+				var cre *CyclicReferenceError
+				require.True(t, errors.As(err, &cre), "%v", err)
+			}
+		})
+	}
+
+	self := &node{Name: "self"}
+	self.Next = self
+	test("self", self)
+
+	a := &node{Name: "a"}
+	a.Next = &node{Name: "b", Next: &node{Name: "c", Next: a}}
+	test("loop", a)
+	test("in a struct", struct{ N *node }{a})
+
+	s := []interface{}{1, nil}
+	s[1] = s
+	test("slice", s)
+
+	m := map[string]interface{}{"a": 1}
+	m["m"] = m
+	test("map", m)
+	test("map in a slice", []interface{}{m})
+
+	// Sharing without a cycle is fine, and written out at each reference.
+	shared := &node{Name: "shared"}
+	val, err := MarshalText([]*node{shared, shared})
+	require.NoError(t, err)
+	assert.Equal(t, `[{Name:"shared",Next:null},{Name:"shared",Next:null}]`, string(val))
+
+	// Deep but acyclic values still encode, and the encoder can go on after
+	// finding a cycle.
+	var deep *node
+	for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+		deep = &node{Name: "n", Next: deep}
+	}
+	buf := strings.Builder{}
+	e := NewTextEncoder(&buf)
+	var cre *CyclicReferenceError
+	require.True(t, errors.As(e.Encode(self), &cre))
+	assert.Equal(t, reflect.TypeOf(self), cre.Type)
+	require.NoError(t, e.Encode(deep))
+	assert.Equal(t, 0, e.depth)
+}
+
+func TestEncodeSymbolTableResetEvery(t *testing.T) {
+	type rec struct {
+		ID   int    `ion:"id"`
+		Name string `ion:"name,symbol"`
+	}
+
+	buf := bytes.Buffer{}
+	e := NewBinaryEncoder(&buf).WithSymbolTableResetEvery(3)
+
+	// Offsets[i] is where the segment starting with record 3*i begins.
+	offsets := []int{0}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, e.Encode(rec{i, fmt.Sprintf("name%v", i)}))
+		if i%3 == 2 {
+			offsets = append(offsets, buf.Len())
+		}
+	}
+	require.NoError(t, e.Finish())
+	require.Len(t, offsets, 4)
+	bs := buf.Bytes()
+
+	for seg, off := range offsets {
+		d := NewDecoder(NewReaderBytes(bs[off:]))
+		for i := seg * 3; i < 10; i++ {
+			var r rec
+			require.NoError(t, d.DecodeTo(&r), "segment %v, record %v", seg, i)
+			assert.Equal(t, rec{i, fmt.Sprintf("name%v", i)}, r)
+		}
+		var r rec
+		assert.Equal(t, ErrNoInput, d.DecodeTo(&r))
+
+		// Each segment's symbol table only holds the symbols it uses: id and
+		// its records' names (name itself is a system symbol).
+		names := 3
+		if seg == 3 {
+			names = 1
+		}
+		rd := NewReaderBytes(bs[off:])
+		require.True(t, rd.Next())
+		assert.Equal(t, 9+1+names, int(rd.SymbolTable().MaxID()), "segment %v", seg)
+	}
+
+	// Resetting costs space.
+	plain := bytes.Buffer{}
+	e = NewBinaryEncoder(&plain)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, e.Encode(rec{i, fmt.Sprintf("name%v", i)}))
+	}
+	require.NoError(t, e.Finish())
+	assert.Less(t, plain.Len(), len(bs))
+}
+
+func TestEncodedBinarySize(t *testing.T) {
+	test := func(name string, v interface{}) {
+		t.Run(name, func(t *testing.T) {
+			bs, err := MarshalBinary(v)
+			require.NoError(t, err)
+
+			n, err := EncodedBinarySize(v)
+			require.NoError(t, err)
+			assert.Equal(t, len(bs), n)
+		})
+	}
+
+	test("nil", nil)
+	test("int", 42)
+	test("bigint", new(big.Int).Lsh(big.NewInt(1), 100))
+	test("float", 1.5)
+	test("string", strings.Repeat("x", 200))
+	test("symbol", NewSymbolTokenFromString("sym"))
+	test("decimal", MustParseDecimal("123.456"))
+	test("timestamp", time.Date(2021, 6, 1, 12, 30, 15, 0, time.UTC))
+	test("blob", make([]byte, 1000))
+	test("list", []interface{}{1, "two", 3.0, []int{4}})
+	test("map", map[string]interface{}{"a": 1, "b": []string{"x", "y"}})
+	test("struct", struct {
+		Name  string   `ion:"name"`
+		Tags  []string `ion:"tags"`
+		Count int      `ion:"count"`
+	}{"thing", []string{"a", "b"}, 3})
+
+	// The size covers the symbol table as well as the value.
+	small, err := EncodedBinarySize(map[string]int{"a": 1})
+	require.NoError(t, err)
+	large, err := EncodedBinarySize(map[string]int{strings.Repeat("a", 100): 1})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, large-small, 99)
+
+	_, err = EncodedBinarySize(make(chan int))
+	assert.Error(t, err)
+}
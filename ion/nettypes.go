@@ -0,0 +1,102 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+)
+
+var netIPType = reflect.TypeOf(net.IP(nil))
+var urlType = reflect.TypeOf(url.URL{})
+
+// TextTypes are the standard library's address types, which are encoded as
+// Ion strings holding their text rather than as blobs or structs. All but
+// url.URL implement encoding.TextMarshaler and encoding.TextUnmarshaler.
+var textTypes = map[reflect.Type]bool{
+	netIPType:                        true,
+	urlType:                          true,
+	reflect.TypeOf(netip.Addr{}):     true,
+	reflect.TypeOf(netip.AddrPort{}): true,
+	reflect.TypeOf(netip.Prefix{}):   true,
+}
+
+// EncodeTextValue writes one of the textTypes as a string, or as a symbol if
+// hinted to. A nil net.IP is written as null.string.
+func (m *Encoder) encodeTextValue(v reflect.Value, hint Type) error {
+	var text string
+	if v.Type() == urlType {
+		u := v.Interface().(url.URL)
+		text = u.String()
+	} else {
+		if v.Type() == netIPType && v.IsNil() {
+			return m.w.WriteNullType(StringType)
+		}
+		bs, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		text = string(bs)
+	}
+
+	if hint == SymbolType {
+		return m.w.WriteSymbolFromString(text)
+	}
+	return m.w.WriteString(text)
+}
+
+// DecodeTextValueTo sets v, one of the textTypes, by parsing the text of the
+// current Ion string or symbol.
+func (d *Decoder) decodeTextValueTo(v reflect.Value) error {
+	var text string
+	switch d.r.Type() {
+	case StringType:
+		val, err := d.r.StringValue()
+		if err != nil {
+			return err
+		}
+		text = *val
+	case SymbolType:
+		val, err := d.r.SymbolValue()
+		if err != nil {
+			return err
+		}
+		if val.Text == nil {
+			return fmt.Errorf("ion: cannot decode symbol with unknown text to %v", v.Type().String())
+		}
+		text = *val.Text
+	default:
+		return fmt.Errorf("ion: cannot decode %v to %v", d.r.Type(), v.Type().String())
+	}
+
+	if v.Type() == urlType {
+		u, err := url.Parse(text)
+		if err != nil {
+			return fmt.Errorf("ion: cannot decode %q to %v: %v", text, v.Type().String(), err)
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		return fmt.Errorf("ion: cannot decode %q to %v: %v", text, v.Type().String(), err)
+	}
+	return nil
+}
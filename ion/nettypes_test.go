@@ -0,0 +1,91 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalNetTypes(t *testing.T) {
+	type host struct {
+		IP       net.IP         `ion:"ip"`
+		IPv6     net.IP         `ion:"ipv6"`
+		NoIP     net.IP         `ion:"no_ip"`
+		Home     url.URL        `ion:"home"`
+		API      *url.URL       `ion:"api"`
+		NoURL    *url.URL       `ion:"no_url"`
+		Addr     netip.Addr     `ion:"addr"`
+		AddrPort netip.AddrPort `ion:"addr_port"`
+		Prefix   netip.Prefix   `ion:"prefix"`
+		Sym      netip.Addr     `ion:"sym,symbol"`
+	}
+
+	api, err := url.Parse("https://user@example.com:8443/v1/items?q=a+b&x=1#frag")
+	require.NoError(t, err)
+
+	v := host{
+		IP:       net.ParseIP("10.0.0.1"),
+		IPv6:     net.ParseIP("2001:db8::1"),
+		Home:     url.URL{Scheme: "http", Host: "example.com", Path: "/a b"},
+		API:      api,
+		Addr:     netip.MustParseAddr("fe80::1%eth0"),
+		AddrPort: netip.MustParseAddrPort("192.168.1.1:53"),
+		Prefix:   netip.MustParsePrefix("10.0.0.0/8"),
+		Sym:      netip.MustParseAddr("127.0.0.1"),
+	}
+
+	text, err := MarshalText(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{ip:"10.0.0.1",ipv6:"2001:db8::1",no_ip:null.string,home:"http://example.com/a%20b",`+
+		`api:"https://user@example.com:8443/v1/items?q=a+b&x=1#frag",no_url:null,addr:"fe80::1%eth0",`+
+		`addr_port:"192.168.1.1:53",prefix:"10.0.0.0/8",sym:'127.0.0.1'}`, string(text))
+
+	bin, err := MarshalBinary(v)
+	require.NoError(t, err)
+
+	for _, data := range [][]byte{text, bin} {
+		var out host
+		require.NoError(t, Unmarshal(data, &out))
+		assert.Equal(t, v, out)
+	}
+}
+
+func TestUnmarshalNetTypes(t *testing.T) {
+	var ip net.IP
+	require.NoError(t, UnmarshalString(`'::ffff:1.2.3.4'`, &ip))
+	assert.True(t, ip.Equal(net.IPv4(1, 2, 3, 4)))
+
+	var addrs []netip.Addr
+	require.NoError(t, UnmarshalString(`["1.2.3.4", "::1", null]`, &addrs))
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("1.2.3.4"), netip.IPv6Loopback(), {}}, addrs)
+
+	urls := map[string]*url.URL{}
+	require.NoError(t, UnmarshalString(`{a:"mailto:x@example.com", b:null.string}`, &urls))
+	assert.Equal(t, "x@example.com", urls["a"].Opaque)
+	assert.Nil(t, urls["b"])
+
+	assert.Error(t, UnmarshalString(`"not an ip"`, &ip))
+	assert.Error(t, UnmarshalString(`"1.2.3.4/33"`, new(netip.Prefix)))
+	assert.Error(t, UnmarshalString(`"http://[::1"`, new(url.URL)))
+	assert.Error(t, UnmarshalString(`{{AQIDBA==}}`, &ip))
+	assert.Error(t, UnmarshalString(`{host:"example.com"}`, new(url.URL)))
+}
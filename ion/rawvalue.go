@@ -0,0 +1,303 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// RawValue holds a single Ion value as it appeared in the input. A struct
+// field of type RawValue is filled, when decoding, with the bytes of the
+// matching Ion value, annotations included, while the struct's other fields
+// are decoded as usual; and when encoding, the value it holds is written out.
+// A RawValue with no bytes is encoded as null.
+//
+// Text is held verbatim, comments inside the value included, except that line
+// endings are normalized to "\n". Binary is held as the bytes of the value
+// alone, without a version marker or symbol table, so it can only be read with
+// the symbol table that was in effect for it, which is kept alongside.
+type RawValue struct {
+	Bytes       []byte
+	Binary      bool
+	SymbolTable SymbolTable
+}
+
+var rawValueType = reflect.TypeOf(RawValue{})
+
+// RawValueTypes maps types to whether they hold a RawValue, so that each type
+// is inspected only once.
+var rawValueTypes sync.Map
+
+// HasRawValue returns true if a value of the given type can hold a RawValue,
+// in which case the reader must keep the input for it while decoding.
+func hasRawValue(t reflect.Type) bool {
+	if has, ok := rawValueTypes.Load(t); ok {
+		return has.(bool)
+	}
+	has := holdsRawValue(t, map[reflect.Type]bool{})
+	rawValueTypes.Store(t, has)
+	return has
+}
+
+// HoldsRawValue returns true if t is or holds a RawValue, ignoring the types
+// already seen.
+func holdsRawValue(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == rawValueType {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsRawValue(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if holdsRawValue(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DecodeRawValueTo sets v, a RawValue, to the reader's current value as it
+// appeared in the input.
+func (d *Decoder) decodeRawValueTo(v reflect.Value) error {
+	rr, ok := d.r.(rawReader)
+	if !ok {
+		return fmt.Errorf("ion: cannot decode a RawValue from a %T", d.r)
+	}
+	bs, binary, err := rr.rawValue()
+	if err != nil {
+		return err
+	}
+
+	v.Set(reflect.ValueOf(RawValue{
+		Bytes:       append([]byte(nil), bs...),
+		Binary:      binary,
+		SymbolTable: d.r.SymbolTable(),
+	}))
+	return nil
+}
+
+// EncodeRawValue writes the value held by a RawValue.
+func (m *Encoder) encodeRawValue(v reflect.Value) error {
+	raw := v.Interface().(RawValue)
+	if len(raw.Bytes) == 0 {
+		return m.w.WriteNull()
+	}
+
+	in := bufio.NewReader(bytes.NewReader(raw.Bytes))
+	var r Reader
+	if raw.Binary {
+		b := newBinaryReaderBuf(in, nil, nil).(*binaryReader)
+		b.WithAssumedVersion(1, 0)
+		if raw.SymbolTable != nil {
+			b.setSymbolTable(raw.SymbolTable)
+		}
+		r = b
+	} else {
+		t := newTextReaderBuf(in, nil, nil).(*textReader)
+		if raw.SymbolTable != nil {
+			t.setSymbolTable(raw.SymbolTable)
+		}
+		r = t
+	}
+
+	if !r.Next() {
+		if err := r.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("ion: RawValue holds no value")
+	}
+	if err := copyValue(r, m.w); err != nil {
+		return err
+	}
+	if r.Next() {
+		return fmt.Errorf("ion: RawValue holds more than one value")
+	}
+	return r.Err()
+}
+
+// CopyValue writes the reader's current value, with its annotations, to w.
+func copyValue(r Reader, w Writer) error {
+	if err := copyAnnotations(r, w); err != nil {
+		return err
+	}
+
+	if r.IsNull() {
+		return w.WriteNullType(r.Type())
+	}
+
+	switch r.Type() {
+	case BoolType:
+		val, err := r.BoolValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteBool(*val)
+
+	case IntType:
+		val, err := r.BigIntValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteBigInt(val)
+
+	case FloatType:
+		val, err := r.FloatValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteFloat(*val)
+
+	case DecimalType:
+		val, err := r.DecimalValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteDecimal(val)
+
+	case TimestampType:
+		val, err := r.TimestampValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteTimestamp(*val)
+
+	case SymbolType:
+		val, err := r.SymbolValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteSymbol(portableToken(*val))
+
+	case StringType:
+		val, err := r.StringValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteString(*val)
+
+	case ClobType:
+		val, err := r.ByteValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteClob(val)
+
+	case BlobType:
+		val, err := r.ByteValue()
+		if err != nil {
+			return err
+		}
+		return w.WriteBlob(val)
+
+	case ListType, SexpType, StructType:
+		return copyContainer(r, w, func(_ int, name *SymbolToken) error {
+			if name != nil {
+				if err := w.FieldName(portableToken(*name)); err != nil {
+					return err
+				}
+			}
+			return copyValue(r, w)
+		})
+	}
+	return fmt.Errorf("ion: cannot copy value of type %v", r.Type())
+}
+
+// CopyAnnotations writes the reader's current value's annotations, if it has
+// any, to w.
+func copyAnnotations(r Reader, w Writer) error {
+	as, err := r.Annotations()
+	if err != nil {
+		return err
+	}
+	if len(as) == 0 {
+		return nil
+	}
+	for i := range as {
+		as[i] = portableToken(as[i])
+	}
+	return w.Annotations(as...)
+}
+
+// CopyContainer writes the reader's current container value to w, calling elem
+// to write each of its elements with the reader positioned on it. elem is
+// given the element's index and, in a struct, its field name, which elem is
+// responsible for writing.
+func copyContainer(r Reader, w Writer, elem func(i int, name *SymbolToken) error) error {
+	typ := r.Type()
+	if err := r.StepIn(); err != nil {
+		return err
+	}
+
+	var err error
+	switch typ {
+	case ListType:
+		err = w.BeginList()
+	case SexpType:
+		err = w.BeginSexp()
+	default:
+		err = w.BeginStruct()
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := 0; r.Next(); i++ {
+		var name *SymbolToken
+		if typ == StructType {
+			if name, err = r.FieldName(); err != nil {
+				return err
+			}
+		}
+		if err := elem(i, name); err != nil {
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	if err := r.StepOut(); err != nil {
+		return err
+	}
+	switch typ {
+	case ListType:
+		return w.EndList()
+	case SexpType:
+		return w.EndSexp()
+	default:
+		return w.EndStruct()
+	}
+}
+
+// PortableToken drops the symbol ID of a token whose text is known, since the
+// ID is only meaningful in the symbol table the token was read with.
+func portableToken(st SymbolToken) SymbolToken {
+	if st.Text != nil {
+		st.LocalSID = SymbolIDUnknown
+	}
+	return st
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRawValue(t *testing.T) {
+	type event struct {
+		ID      int      `ion:"id"`
+		Payload RawValue `ion:"payload"`
+		Tags    []string `ion:"tags"`
+		Extra   RawValue `ion:"extra"`
+	}
+
+	payload := `meta::{a:[1,  2.5, /* c */ "x", sym], b:null.int, c:{{aGVsbG8=}}}`
+	in := `{id:7, payload:` + payload + `, tags:["t"], extra:null.string}`
+
+	t.Run("text", func(t *testing.T) {
+		var e event
+		require.NoError(t, UnmarshalString(in, &e))
+		assert.Equal(t, 7, e.ID)
+		assert.Equal(t, []string{"t"}, e.Tags)
+
+		assert.Equal(t, payload, string(e.Payload.Bytes))
+		assert.False(t, e.Payload.Binary)
+		assert.Equal(t, "null.string", string(e.Extra.Bytes))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		writeFromReaderToWriter(t, NewReaderString(in), w)
+		require.NoError(t, w.Finish())
+		bin := buf.Bytes()
+
+		var e event
+		require.NoError(t, Unmarshal(bin, &e))
+		assert.Equal(t, 7, e.ID)
+		assert.Equal(t, []string{"t"}, e.Tags)
+
+		// The payload is the annotated struct's bytes, as they appear in the input.
+		assert.True(t, e.Payload.Binary)
+		assert.Equal(t, byte(0xE0), e.Payload.Bytes[0]&0xF0)
+		assert.True(t, bytes.Contains(bin, e.Payload.Bytes))
+		assert.Equal(t, []byte{0x8F}, e.Extra.Bytes) // null.string
+		require.NotNil(t, e.Payload.SymbolTable)
+
+		// With its symbol table, it holds the same value as the text.
+		out, err := MarshalText(e.Payload)
+		require.NoError(t, err)
+		assertSameIon(t, payload, out)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		var es []event
+		require.NoError(t, UnmarshalString("[{id:1, payload:a}, {id:2, payload:(b\n  c)}]", &es))
+		require.Len(t, es, 2)
+		assert.Equal(t, "a", string(es[0].Payload.Bytes))
+		assert.Equal(t, "(b\n  c)", string(es[1].Payload.Bytes))
+	})
+
+	t.Run("top level", func(t *testing.T) {
+		var raw RawValue
+		require.NoError(t, UnmarshalString("  // before\n a::'''x''' /* between */ '''y'''  // after", &raw))
+		assert.Equal(t, "a::'''x''' /* between */ '''y'''", string(raw.Bytes))
+	})
+
+	t.Run("line endings", func(t *testing.T) {
+		var e event
+		require.NoError(t, UnmarshalString("{payload:[1,\r\n2]}", &e))
+		assert.Equal(t, "[1,\n2]", string(e.Payload.Bytes))
+	})
+
+	// A missing field leaves the RawValue empty.
+	var e event
+	require.NoError(t, UnmarshalString("{id:1}", &e))
+	assert.Nil(t, e.Payload.Bytes)
+}
+
+func TestEncodeRawValue(t *testing.T) {
+	v := struct {
+		Name string   `ion:"name"`
+		Raw  RawValue `ion:"raw"`
+		None RawValue `ion:"none"`
+	}{"n", RawValue{Bytes: []byte("{k:[1, 2]} ")}, RawValue{}}
+
+	out, err := MarshalText(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{name:"n",raw:{k:[1,2]},none:null}`, string(out))
+
+	// Values decoded into a RawValue are written back out unchanged.
+	var back struct {
+		Name string   `ion:"name"`
+		Raw  RawValue `ion:"raw"`
+	}
+	require.NoError(t, Unmarshal(out, &back))
+	assert.Equal(t, "{k:[1,2]}", string(back.Raw.Bytes))
+	out2, err := MarshalText(back)
+	require.NoError(t, err)
+	assert.Equal(t, `{name:"n",raw:{k:[1,2]}}`, string(out2))
+
+	_, err = MarshalText(RawValue{Bytes: []byte("1 2")})
+	assert.Error(t, err)
+	_, err = MarshalText(RawValue{Bytes: []byte("{")})
+	assert.Error(t, err)
+	_, err = MarshalText(RawValue{Bytes: []byte("// nothing")})
+	assert.Error(t, err)
+}
+
+// AssertSameIon asserts that the Ion data holds the same values as the text.
+func assertSameIon(t *testing.T, expected string, actual []byte) {
+	toText := func(r Reader) string {
+		buf := strings.Builder{}
+		w := NewTextWriterOpts(&buf, TextWriterQuietFinish)
+		writeFromReaderToWriter(t, r, w)
+		require.NoError(t, w.Finish())
+		return buf.String()
+	}
+	assert.Equal(t, toText(NewReaderString(expected)), toText(NewReaderBytes(actual)))
+}
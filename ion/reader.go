@@ -18,9 +18,11 @@ package ion
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"math"
 	"math/big"
+	"math/bits"
 	"strings"
 )
 
@@ -85,12 +87,49 @@ type Reader interface {
 	// It returns NoType if the Reader is positioned before or after a value.
 	Type() Type
 
+	// PeekType returns the type of the value the next call to Next will move
+	// to, without moving to it, or NoType if Next will return false because the
+	// current container or stream has ended. The Reader stays on its current
+	// value, whose accessors keep working, and the next call to Next moves on
+	// to the peeked value as usual. Calling PeekType again before then returns
+	// the same type.
+	//
+	// Peeking reads past the current value, so it returns an error if the
+	// current value is a non-null container, which could then no longer be
+	// stepped in to; step in and out of it, or move past it with Next, first.
+	// Anything read on the way, such as a local symbol table, is read straight
+	// away: OnSymbolTable callbacks fire and BytesConsumed counts it. An error
+	// reading ahead is returned, and also set as Err, just as Next would have
+	// set it.
+	PeekType() (Type, error)
+
 	// IsNull returns true if the current value is an explicit null. This may be true
 	// even if the Type is not NullType (for example, null.struct has type Struct).
 	IsNull() bool
 
+	// NullForm returns how the current value was spelled if it is a null:
+	// BareNull for `null` and TypedNull for `null.null`, `null.int`, and so on.
+	// The two untyped spellings are otherwise treated as equivalent. It returns
+	// NotNull if the current value is not a null.
+	NullForm() NullForm
+
+	// Expect returns an UnexpectedTypeError if the current value is not of
+	// type t, or if there is no current value. A null of type t, such as
+	// null.int for IntType, is of type t; plain null is of type NullType.
+	Expect(t Type) error
+
+	// ExpectNonNull is like Expect, and also returns an UnexpectedTypeError if
+	// the current value is a null.
+	ExpectNonNull(t Type) error
+
+	// ExpectOneOf is like Expect, returning an UnexpectedTypeError unless the
+	// current value is of one of the given types.
+	ExpectOneOf(ts ...Type) error
+
 	// Annotations returns the annotations associated with the current value as a list of SymbolTokens.
 	// It returns nil if there is no current value or the current value has no annotations.
+	// A binary reader resolves annotations, as it does field names, only when asked for them,
+	// so a scan that doesn't need them doesn't pay for them.
 	Annotations() ([]SymbolToken, error)
 
 	// StepIn steps in to the current value if it is a container. It returns an error if there
@@ -126,6 +165,16 @@ type Reader interface {
 	// nil if the value is an Ion null. It returns an error if the current value is not an Ion integer.
 	BigIntValue() (*big.Int, error)
 
+	// IntBytes returns the magnitude of the current value as big-endian bytes with no leading
+	// zeros (so zero has no bytes at all), along with whether the value is negative. This is the
+	// sign-and-magnitude form Ion itself uses, not two's complement, and for an int too big for an
+	// int64 it is taken straight from the input, without going through a big.Int. It returns nil
+	// if the value is an Ion null, and an error if the current value is not an Ion integer.
+	//
+	// The returned slice is owned by the Reader and is only valid until the next call to Next,
+	// StepIn, StepOut, PeekType or IntBytes; it must not be modified.
+	IntBytes() ([]byte, bool, error)
+
 	// FloatValue returns the current value as a 64-bit floating point number (if that makes
 	// sense). It returns nil if the value is null. It returns an error if the current value
 	// is not an Ion float.
@@ -156,6 +205,26 @@ type Reader interface {
 	// the `SymbolToken` SID is not found in the symbol table.
 	FieldName() (*SymbolToken, error)
 
+	// FieldNameBytes returns the text of the field name associated with the current value, without
+	// allocating a string for it, so that it can be compared with bytes.Equal while scanning for a
+	// field. It returns nil if there is no current value, the current value has no field name, or the
+	// field name's text is unknown, and an error if its symbol ID is not in the symbol table.
+	//
+	// The returned slice is owned by the Reader: for text it is the field name as read from the input,
+	// held in a buffer that is reused for the next field name. It is only valid until the next call to
+	// Next, StepIn, StepOut or PeekType, and must not be modified.
+	FieldNameBytes() ([]byte, error)
+
+	// FieldNameSID returns the symbol ID of the field name associated with the
+	// current value. In binary Ion this is the ID the field name was encoded
+	// with, even if its text is known, so that a binary-to-binary transform can
+	// write the same ID back with the same symbol table. A text reader reports
+	// the ID the field name's text has in the current symbol table, if it has
+	// one, and SymbolIDUnknown otherwise, as it does for a quoted field name.
+	// It returns an error if there is no current value or the current value has
+	// no field name.
+	FieldNameSID() (int64, error)
+
 	// SymbolValue returns the SymbolToken associated with the current value. It returns an
 	// error if the current value is not an Ion symbol.
 	SymbolValue() (*SymbolToken, error)
@@ -164,10 +233,131 @@ type Reader interface {
 	// Text Readers do not, generally speaking, have an associated symbol table.
 	// Binary Readers do.
 	SymbolTable() SymbolTable
+
+	// BytesConsumed returns the number of bytes of input the reader has
+	// consumed so far, for reporting progress through a large stream. It
+	// counts the bytes of every value that has been read or skipped over, not
+	// the bytes the reader has buffered ahead from the underlying io.Reader,
+	// so it only ever grows as the reader advances.
+	BytesConsumed() int64
+
+	// OnSymbolTable sets a function to be called with each new symbol table
+	// the reader establishes as it reads: each local symbol table, including
+	// one that appends to the table before it, and the system symbol table when
+	// a version marker or null local symbol table replaces a local one. The
+	// function is called as the table is read, before the value it precedes is
+	// returned by Next. A nil fn removes the function. It is kept by Reset.
+	OnSymbolTable(fn func(SymbolTable))
+
+	// OnValueSize sets a function to be called with the type and size in bytes
+	// of each non-null string, clob, and blob the reader reads, including the
+	// strings in local symbol tables, so that an application can refuse values
+	// it can't afford to hold, e.g. under memory pressure, as it goes. If fn
+	// returns an error, Next returns false and Err returns that error; the
+	// reader can't then go on. A binary reader calls fn before reading the
+	// value's contents into memory, as soon as it has read their length, and
+	// fails with a SyntaxError, without calling fn, for a length too large for
+	// an int. A text reader can't know the size until it has scanned the
+	// value, and calls fn after that but before the value is returned, so for
+	// text fn can refuse a value but does not limit the memory used to scan
+	// it; bound the input itself for that. Sizes are those of the contents in
+	// memory: the UTF-8 bytes of a string, not counting quotes or escapes in
+	// text, and the bytes of a lob, after base64 decoding for a text blob. A
+	// nil fn removes the function. It is kept by Reset.
+	OnValueSize(fn func(t Type, size int) error)
+
+	// OnNonCanonical sets a function to be called with each part of a binary
+	// stream that is valid Ion but not canonically encoded, so that a validator
+	// can audit what a producer writes without rejecting it: a length written
+	// as a separate VarUInt when it would fit in the type descriptor, a length
+	// or field ID padded with leading zero bytes, NOP padding, and a field of an
+	// ordered struct whose symbol ID is less than the one before it. These are
+	// warnings, not errors; reading goes on as normal. A text reader never calls
+	// fn. A nil fn removes the function. It is kept by Reset.
+	OnNonCanonical(fn func(NonCanonicalEncoding))
+
+	// WithAssumedVersion makes the reader read input that does not start with
+	// a binary version marker as binary Ion of the given version, as if the
+	// marker were there, for storage systems that strip it to save space. The
+	// reader has no way to check the assumption: input that is text, or
+	// binary Ion of another version, is read as garbage or fails with a
+	// syntax error. Input that does start with a version marker is read as
+	// usual. Only version 1.0 is supported; for any other, Next fails with an
+	// UnsupportedVersionError. It must be called before the first call to
+	// Next, and is kept by Reset. It returns the reader.
+	WithAssumedVersion(major, minor int) Reader
+
+	// Reset discards all of the reader's state, including its position and
+	// symbol table, and starts reading from in as a newly-created reader would,
+	// whether in is text or binary Ion. This lets one reader be reused for many
+	// inputs. Callbacks set with OnSymbolTable, OnValueSize, and OnNonCanonical,
+	// and any version set with WithAssumedVersion, carry over. A TriviaReader
+	// only reads text; given binary input, its Next returns false and Err
+	// returns an error.
+	Reset(in io.Reader)
+
+	// ResetBytes is like Reset, reading from the given bytes.
+	ResetBytes(in []byte)
+}
+
+// A valueStarter is a Reader that knows the offset, in bytes, at which its
+// current value starts.
+type valueStarter interface {
+	valueStart() int64
+}
+
+// A typeExpecter is a reader whose current value's type can be checked by
+// expectType.
+type typeExpecter interface {
+	valueStarter
+	Type() Type
+	IsNull() bool
+}
+
+// ExpectType returns an UnexpectedTypeError unless the current value of r is
+// of one of the given types and, if nonNull is set, is not a null.
+func expectType(r typeExpecter, nonNull bool, ts []Type) error {
+	typ := r.Type()
+	if typ != NoType && !(nonNull && r.IsNull()) {
+		for _, t := range ts {
+			if t == typ {
+				return nil
+			}
+		}
+	}
+
+	return &UnexpectedTypeError{
+		Expected: ts,
+		NonNull:  nonNull,
+		Type:     typ,
+		IsNull:   typ != NoType && r.IsNull(),
+		Offset:   uint64(r.valueStart()),
+	}
+}
+
+// ValueOffset returns the offset at which the current value of r starts, if r
+// knows it, or else the number of bytes it has consumed.
+func valueOffset(r Reader) int64 {
+	if vs, ok := r.(valueStarter); ok {
+		return vs.valueStart()
+	}
+	return r.BytesConsumed()
 }
 
 // NewReader creates a new Ion reader of the appropriate type by peeking
 // at the first several bytes of input for a binary version marker.
+//
+// Version markers, binary or text, reset the symbol table and are not values
+// themselves, so an input holding nothing but a version marker has no values,
+// as does an empty one. In text, only an unquoted, unannotated $ion_1_0 symbol
+// at the top level is a version marker.
+//
+// The input may also switch between text and binary Ion, as it does when text
+// and binary files are concatenated: a binary version marker where a text
+// value could start, or a $ion_1_0 text version marker where a binary value
+// could start, at the top level, switches the reader to the other format and
+// resets its symbol table. Offsets in errors are then relative to the start
+// of the segment being read.
 func NewReader(in io.Reader) Reader {
 	return NewReaderCat(in, nil)
 }
@@ -184,14 +374,396 @@ func NewReaderBytes(in []byte) Reader {
 
 // NewReaderCat creates a new reader with the given catalog.
 func NewReaderCat(in io.Reader, cat Catalog) Reader {
-	br := bufio.NewReader(in)
+	return newReader(in, cat, nil)
+}
+
+// PeekSymbolTable reads just the prelude of the given Ion stream, text or
+// binary--its version marker and any local symbol tables before the first
+// value--and returns the symbol table in effect for that value. It returns the
+// system symbol table if the stream has no local symbol table.
+func PeekSymbolTable(in io.Reader) (SymbolTable, error) {
+	return PeekSymbolTableCat(in, nil)
+}
+
+// PeekSymbolTableCat is like PeekSymbolTable, using the given catalog to
+// resolve the symbol table's imports.
+func PeekSymbolTableCat(in io.Reader, cat Catalog) (SymbolTable, error) {
+	r := NewReaderCat(in, cat)
+	r.Next()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
 
-	bs, err := br.Peek(4)
-	if err == nil && bs[0] == 0xE0 && bs[3] == 0xEA {
-		return newBinaryReaderBuf(br, cat)
+	if st := r.SymbolTable(); st != nil {
+		return st, nil
 	}
+	return V1SystemSymbolTable, nil
+}
+
+// NewReader creates a new reader with the given catalog and symbol interner,
+// either of which may be nil.
+func newReader(in io.Reader, cat Catalog, interner Interner) Reader {
+	r := &mixedReader{
+		cat:      cat,
+		interner: interner,
+	}
+	r.in = bufio.NewReader(in)
+	r.first = r.readerFor(r.in)
+	r.Reader = r.first
+	return r
+}
+
+// A mixedReader reads a stream that may switch between text and binary Ion
+// at any top-level version marker, as happens when text and binary files are
+// naively concatenated. It reads with a text or binary reader as appropriate,
+// replacing it with one of the other kind when it reaches a version marker for
+// that kind. Each segment starts with the system symbol table.
+type mixedReader struct {
+	Reader
+	first    Reader
+	in       *bufio.Reader
+	cat      Catalog
+	interner Interner
+	onSymTab func(SymbolTable)
+	onSize   func(Type, int) error
+	onNonCan func(NonCanonicalEncoding)
+
+	// Raw records whether the readers keep their input for rawValue.
+	raw bool
+
+	// Assumed records the version set by WithAssumedVersion, so that Reset
+	// can read binary input without a version marker again.
+	assumed      bool
+	major, minor int
+
+	// Spare is the reader of the other kind from before a Reset switched
+	// kinds, kept for a later Reset to reuse.
+	spare Reader
+
+	// Reused by ResetBytes.
+	inBytes bytes.Reader
+
+	// Consumed counts the bytes consumed by readers before the current one.
+	consumed int64
+
+	// Next is the reader for the following segment of input, if PeekType had
+	// to look into it.
+	next Reader
+}
+
+// A switcher is a reader that can stop at a version marker for the other
+// kind of Ion.
+type switcher interface {
+	switchInput() *bufio.Reader
+}
+
+// ReaderFor returns a reader for the given input, which stops at a version
+// marker for the other kind of Ion.
+func (r *mixedReader) readerFor(br *bufio.Reader) Reader {
+	if isBinary(br) {
+		return r.binaryReaderFor(br)
+	}
+
+	t := newTextReaderBuf(br, r.cat, r.interner).(*textReader)
+	t.tok.stopAtBinary = true
+	return t
+}
+
+// BinaryReaderFor returns a binary reader for the given input, which stops at
+// a text version marker.
+func (r *mixedReader) binaryReaderFor(br *bufio.Reader) *binaryReader {
+	b := newBinaryReaderBuf(br, r.cat, r.interner).(*binaryReader)
+	b.bits.stopAtText = true
+	return b
+}
+
+func (r *mixedReader) Next() bool {
+	for !r.Reader.Next() {
+		next := r.next
+		if next == nil {
+			in := r.Reader.(switcher).switchInput()
+			if in == nil {
+				return false
+			}
+			next = r.nextReader(in)
+		}
+		r.next = nil
+
+		r.consumed += r.Reader.BytesConsumed()
+		prev := r.Reader.SymbolTable()
+		r.Reader = next
+
+		// The new segment starts with the system symbol table.
+		if r.onSymTab != nil && prev != nil && prev != V1SystemSymbolTable {
+			r.onSymTab(V1SystemSymbolTable)
+		}
+	}
+	return true
+}
+
+// NextReader returns a reader for the segment of input that follows the
+// current one.
+func (r *mixedReader) nextReader(in *bufio.Reader) Reader {
+	next := r.readerFor(in)
+	next.OnSymbolTable(r.onSymTab)
+	next.OnValueSize(r.onSize)
+	next.OnNonCanonical(r.onNonCan)
+	next.(rawReader).keepRaw(r.raw)
+	return next
+}
+
+// PeekType returns the type of the next value without moving to it, looking
+// into the next segment of input if the current one ends at a version marker
+// for the other kind of Ion.
+func (r *mixedReader) PeekType() (Type, error) {
+	if r.next != nil {
+		return r.next.PeekType()
+	}
+
+	t, err := r.Reader.PeekType()
+	if t != NoType || err != nil {
+		return t, err
+	}
+	in := r.Reader.(switcher).switchInput()
+	if in == nil {
+		return NoType, nil
+	}
+	r.next = r.nextReader(in)
+	return r.next.PeekType()
+}
+
+func (r *mixedReader) OnSymbolTable(fn func(SymbolTable)) {
+	r.onSymTab = fn
+	r.Reader.OnSymbolTable(fn)
+	if r.first != r.Reader {
+		r.first.OnSymbolTable(fn)
+	}
+}
+
+func (r *mixedReader) OnValueSize(fn func(t Type, size int) error) {
+	r.onSize = fn
+	r.Reader.OnValueSize(fn)
+	if r.first != r.Reader {
+		r.first.OnValueSize(fn)
+	}
+}
+
+func (r *mixedReader) OnNonCanonical(fn func(NonCanonicalEncoding)) {
+	r.onNonCan = fn
+	r.Reader.OnNonCanonical(fn)
+	if r.first != r.Reader {
+		r.first.OnNonCanonical(fn)
+	}
+}
+
+func (r *mixedReader) WithAssumedVersion(major, minor int) Reader {
+	// Until it has read anything, a text reader can be swapped for a binary
+	// one over the same input.
+	if t, ok := r.first.(*textReader); ok && r.Reader == r.first && t.BytesConsumed() == 0 && t.err == nil {
+		b := r.binaryReaderFor(r.in)
+		b.OnSymbolTable(r.onSymTab)
+		b.OnValueSize(r.onSize)
+		b.OnNonCanonical(r.onNonCan)
+		b.keepRaw(r.raw)
+		r.first = b
+		r.Reader = b
+	}
+	r.first.WithAssumedVersion(major, minor)
+	if b, ok := r.first.(*binaryReader); ok && b.assumed {
+		r.assumed, r.major, r.minor = true, major, minor
+	}
+	return r
+}
+
+func (r *mixedReader) BytesConsumed() int64 {
+	return r.consumed + r.Reader.BytesConsumed()
+}
+
+func (r *mixedReader) valueStart() int64 {
+	return r.consumed + valueOffset(r.Reader)
+}
+
+// Reset starts reading from in with a text or binary reader, as in calls for,
+// reusing the one it has of that kind if it can.
+func (r *mixedReader) Reset(in io.Reader) {
+	r.in = resetBuf(r.in, in)
+	r.consumed = 0
+	r.next = nil
+
+	binary := r.assumed || isBinary(r.in)
+	if _, ok := r.first.(*binaryReader); ok != binary {
+		r.first, r.spare = r.spare, r.first
+	}
+
+	switch f := r.first.(type) {
+	case *textReader:
+		f.resetBuf(r.in)
+	case *binaryReader:
+		f.assumed, f.major, f.minor = r.assumed, r.major, r.minor
+		f.resetBuf(r.in)
+	default:
+		if !binary {
+			r.first = r.readerFor(r.in)
+			break
+		}
+		b := r.binaryReaderFor(r.in)
+		if r.assumed {
+			b.WithAssumedVersion(r.major, r.minor)
+		}
+		r.first = b
+	}
+
+	r.first.OnSymbolTable(r.onSymTab)
+	r.first.OnValueSize(r.onSize)
+	r.first.OnNonCanonical(r.onNonCan)
+	r.first.(rawReader).keepRaw(r.raw)
+	r.Reader = r.first
+}
+
+func (r *mixedReader) ResetBytes(in []byte) {
+	r.inBytes.Reset(in)
+	r.Reset(&r.inBytes)
+}
+
+func (r *mixedReader) Expect(t Type) error {
+	return expectType(r, false, []Type{t})
+}
+
+func (r *mixedReader) ExpectNonNull(t Type) error {
+	return expectType(r, true, []Type{t})
+}
+
+func (r *mixedReader) ExpectOneOf(ts ...Type) error {
+	return expectType(r, false, ts)
+}
+
+func (r *mixedReader) resume() {
+	r.Reader.(resumer).resume()
+}
+
+func (r *mixedReader) keepRaw(keep bool) {
+	r.raw = keep
+	r.Reader.(rawReader).keepRaw(keep)
+}
+
+func (r *mixedReader) rawValue() ([]byte, bool, error) {
+	return r.Reader.(rawReader).rawValue()
+}
+
+// IsBinary returns true if the given input starts with a binary version marker.
+func isBinary(in *bufio.Reader) bool {
+	bs, err := in.Peek(4)
+	return err == nil && bs[0] == 0xE0 && bs[3] == 0xEA
+}
+
+// ResetBuf returns a bufio.Reader over in, reusing the given one if possible.
+func resetBuf(br *bufio.Reader, in io.Reader) *bufio.Reader {
+	if br == nil {
+		return bufio.NewReader(in)
+	}
+	br.Reset(in)
+	return br
+}
+
+// A ChunkReader is a Reader whose input arrives in chunks over time, e.g. as
+// frames from a network connection. When Next returns false because the input
+// so far has been consumed, more input can be supplied with Append and reading
+// resumes where it left off, keeping the symbol table that is currently in
+// effect.
+//
+// A chunk need not end on a value boundary. A top-level value is only read
+// once it is known to be complete, so Next returns false at the end of a
+// chunk that ends part way through one, and the rest of it can be appended.
+// In text, where a number or symbol, say, is only known to be complete once
+// something follows it, the last value is held back until more input or
+// AppendEOF arrives.
+//
+// All of the input must be of one kind, text or binary.
+type ChunkReader interface {
+	Reader
+
+	// Append adds b to the end of the reader's input. If the reader had reached
+	// the end of its input at the top level, it can be advanced again with Next.
+	Append(b []byte)
+
+	// AppendEOF marks the end of the reader's input, so that any value held
+	// back can be read. Append must not be called afterwards.
+	AppendEOF()
+}
+
+// NewChunkReader creates a new ChunkReader whose input starts with the given
+// chunk. Whether the input is text or binary is decided from the first chunk,
+// so for binary it must start with the binary version marker. Reset and
+// ResetBytes start the input again with a new first chunk, read from the
+// given io.Reader up to its io.EOF or from the given bytes.
+func NewChunkReader(first []byte) ChunkReader {
+	return NewChunkReaderCat(first, nil)
+}
+
+// NewChunkReaderCat creates a new ChunkReader with the given catalog.
+func NewChunkReaderCat(first []byte, cat Catalog) ChunkReader {
+	in := &chunks{}
+	in.reset(first)
+
+	return &chunkReader{
+		Reader: NewReaderCat(in, cat),
+		in:     in,
+	}
+}
+
+// A rawReader is a reader that can keep the input of the current value, for
+// decoding into a RawValue.
+type rawReader interface {
+	keepRaw(keep bool)
+	rawValue() ([]byte, bool, error)
+}
+
+// A resumer is a reader that can pick up reading where it left off after
+// more input has been made available.
+type resumer interface {
+	resume()
+}
 
-	return newTextReaderBuf(br, cat)
+type chunkReader struct {
+	Reader
+	in *chunks
+}
+
+func (r *chunkReader) Append(b []byte) {
+	r.in.Append(b)
+	r.Reader.(resumer).resume()
+}
+
+func (r *chunkReader) AppendEOF() {
+	r.in.end()
+	r.Reader.(resumer).resume()
+}
+
+func (r *chunkReader) Reset(in io.Reader) {
+	b, err := io.ReadAll(in)
+	r.in.reset(b)
+	if err != nil {
+		r.in.err = err
+		r.in.end()
+	}
+	r.Reader.Reset(r.in)
+}
+
+func (r *chunkReader) ResetBytes(in []byte) {
+	r.in.reset(in)
+	r.Reader.Reset(r.in)
+}
+
+func (r *chunkReader) valueStart() int64 {
+	return valueOffset(r.Reader)
+}
+
+func (r *chunkReader) keepRaw(keep bool) {
+	r.Reader.(rawReader).keepRaw(keep)
+}
+
+func (r *chunkReader) rawValue() ([]byte, bool, error) {
+	return r.Reader.(rawReader).rawValue()
 }
 
 // A reader holds common implementation stuff to both the text and binary readers.
@@ -201,10 +773,78 @@ type reader struct {
 	err error
 
 	lst         SymbolTable
+	interner    Interner
+	onSymTab    func(SymbolTable)
+	onSize      func(Type, int) error
 	fieldName   *SymbolToken
 	annotations []SymbolToken
-	valueType   Type
-	value       interface{}
+
+	// A binary reader reads the symbol IDs of field names and annotations,
+	// which FieldName and Annotations resolve to symbol tokens only when
+	// they're called, to save resolving them for values that never need them.
+	fieldID       int64
+	lazyField     bool
+	annotationIDs []uint64
+
+	// A text reader keeps the text of a field name, in a buffer its tokenizer
+	// reuses, and only makes a symbol token of it when FieldName is called.
+	fieldText   []byte
+	textField   bool
+	quotedField bool
+
+	// SymbolText caches the text of the symbols in symbolTextOf, by ID, for
+	// FieldNameBytes.
+	symbolText   map[int64][]byte
+	symbolTextOf SymbolTable
+
+	valueType Type
+	value     interface{}
+	bareNull  bool
+
+	// Start is the offset at which the current value, including any
+	// annotations, starts.
+	start int64
+
+	// BigInt holds an int value too big for an int64, which value then
+	// points to, and intBytes is reused by IntBytes for smaller ones.
+	bigInt   intMagnitude
+	intBytes []byte
+
+	// Peeked holds the value PeekType read ahead to, which the next call to
+	// Next moves to instead of reading another.
+	peeked *valueState
+
+	// Reused by ResetBytes.
+	inBytes bytes.Reader
+}
+
+// An intMagnitude is an int as Ion represents it: the big-endian bytes of its
+// magnitude, with no leading zeros, and its sign.
+type intMagnitude struct {
+	mag []byte
+	neg bool
+}
+
+// BigInt returns the int as a big.Int.
+func (m *intMagnitude) bigInt() *big.Int {
+	bi := new(big.Int).SetBytes(m.mag)
+	if m.neg {
+		bi.Neg(bi)
+	}
+	return bi
+}
+
+// Int64 returns the int as an int64, and whether it fits in one.
+func (m *intMagnitude) int64() (int64, bool) {
+	if len(m.mag) > 8 {
+		return 0, false
+	}
+
+	u := uint64(0)
+	for _, b := range m.mag {
+		u = u<<8 | uint64(b)
+	}
+	return signedInt64(u, m.neg)
 }
 
 // Err returns the current error.
@@ -222,12 +862,83 @@ func (r *reader) IsNull() bool {
 	return r.valueType != NoType && r.value == nil
 }
 
+// Reset clears the state shared by all readers.
+func (r *reader) reset() {
+	r.ctx.arr = r.ctx.arr[:0]
+	r.eof = false
+	r.err = nil
+	r.lst = nil
+	r.peeked = nil
+	r.clear()
+}
+
+// OnSymbolTable sets the function called with each symbol table the reader
+// establishes.
+func (r *reader) OnSymbolTable(fn func(SymbolTable)) {
+	r.onSymTab = fn
+}
+
+// OnValueSize sets the function called with the size of each string, clob,
+// and blob.
+func (r *reader) OnValueSize(fn func(t Type, size int) error) {
+	r.onSize = fn
+}
+
+// OnNonCanonical does nothing; only binary Ion has non-canonical encodings.
+func (r *reader) OnNonCanonical(fn func(NonCanonicalEncoding)) {}
+
+// CheckValueSize passes the size of a value of the given type to the
+// OnValueSize function, if there is one, returning its error.
+func (r *reader) checkValueSize(t Type, size int) error {
+	if r.onSize == nil {
+		return nil
+	}
+	return r.onSize(t, size)
+}
+
+// SetSymbolTable installs st as the symbol table in effect, passing it to the
+// OnSymbolTable function unless it is the system symbol table replacing itself.
+func (r *reader) setSymbolTable(st SymbolTable) {
+	prev := r.lst
+	r.lst = st
+	if r.onSymTab == nil {
+		return
+	}
+	if st == V1SystemSymbolTable && (prev == nil || prev == V1SystemSymbolTable) {
+		return
+	}
+	r.onSymTab(st)
+}
+
+// NullForm returns how the current value was spelled if it is null.
+func (r *reader) NullForm() NullForm {
+	switch {
+	case !r.IsNull():
+		return NotNull
+	case r.bareNull:
+		return BareNull
+	default:
+		return TypedNull
+	}
+}
+
 // Annotations returns the current value's annotations.
 func (r *reader) Annotations() ([]SymbolToken, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
 
+	if r.annotations == nil && len(r.annotationIDs) > 0 {
+		as := make([]SymbolToken, len(r.annotationIDs))
+		for i, id := range r.annotationIDs {
+			st, err := NewSymbolTokenBySID(r.lst, int64(id))
+			if err != nil {
+				return nil, err
+			}
+			as[i] = st
+		}
+		r.annotations = as
+	}
 	return r.annotations, nil
 }
 
@@ -288,9 +999,7 @@ func (r *reader) Int64Value() (*int64, error) {
 		return &i, nil
 	}
 
-	bi := r.value.(*big.Int)
-	if bi.IsInt64() {
-		val := bi.Int64()
+	if val, ok := r.value.(*intMagnitude).int64(); ok {
 		return &val, nil
 	}
 
@@ -309,7 +1018,40 @@ func (r *reader) BigIntValue() (*big.Int, error) {
 	if i, ok := r.value.(int64); ok {
 		return big.NewInt(i), nil
 	}
-	return r.value.(*big.Int), nil
+	return r.value.(*intMagnitude).bigInt(), nil
+}
+
+// IntBytes returns the magnitude and sign of the current value.
+func (r *reader) IntBytes() ([]byte, bool, error) {
+	if r.valueType != IntType {
+		return nil, false, &UsageError{"Reader.IntBytes", "value is not an int"}
+	}
+	if r.value == nil {
+		return nil, false, nil
+	}
+
+	var mag []byte
+	var neg bool
+	switch v := r.value.(type) {
+	case *intMagnitude:
+		mag, neg = v.mag, v.neg
+	case int64:
+		u := uint64(v)
+		if v < 0 {
+			u = -u
+		}
+		r.intBytes = r.intBytes[:0]
+		for shift := (bits.Len64(u) + 7) &^ 7; shift > 0; shift -= 8 {
+			r.intBytes = append(r.intBytes, byte(u>>(shift-8)))
+		}
+		mag, neg = r.intBytes, v < 0
+	}
+
+	if mag == nil {
+		// Zero has no bytes, but isn't null.
+		mag = []byte{}
+	}
+	return mag, neg, nil
 }
 
 // FloatValue returns the current value as a float.
@@ -361,9 +1103,134 @@ func (r *reader) ByteValue() ([]byte, error) {
 // Clear clears the current value from the reader.
 func (r *reader) clear() {
 	r.fieldName = nil
+	r.lazyField = false
+	r.fieldText = nil
+	r.textField = false
 	r.annotations = nil
+	r.annotationIDs = r.annotationIDs[:0]
 	r.valueType = NoType
 	r.value = nil
+	r.bareNull = false
+}
+
+// A valueState holds the state a reader keeps for its current value, so that
+// PeekType can read ahead to the next value and then put the current one back.
+type valueState struct {
+	eof           bool
+	lst           SymbolTable
+	fieldName     *SymbolToken
+	fieldID       int64
+	lazyField     bool
+	fieldText     []byte
+	textField     bool
+	quotedField   bool
+	annotations   []SymbolToken
+	annotationIDs []uint64
+	valueType     Type
+	value         interface{}
+	bareNull      bool
+	start         int64
+}
+
+// SaveValue returns the state of the current value.
+func (r *reader) saveValue() valueState {
+	return valueState{
+		eof:           r.eof,
+		lst:           r.lst,
+		fieldName:     r.fieldName,
+		fieldID:       r.fieldID,
+		lazyField:     r.lazyField,
+		fieldText:     append(make([]byte, 0, len(r.fieldText)), r.fieldText...),
+		textField:     r.textField,
+		quotedField:   r.quotedField,
+		annotations:   r.annotations,
+		annotationIDs: append([]uint64(nil), r.annotationIDs...),
+		valueType:     r.valueType,
+		value:         copyBigInt(r.value),
+		bareNull:      r.bareNull,
+		start:         r.start,
+	}
+}
+
+// CopyBigInt returns a copy of the given value if it is an int too big for an
+// int64, whose bytes the reader reuses for the next such value.
+func copyBigInt(value interface{}) interface{} {
+	if m, ok := value.(*intMagnitude); ok {
+		return &intMagnitude{append(make([]byte, 0, len(m.mag)), m.mag...), m.neg}
+	}
+	return value
+}
+
+// RestoreValue makes the value saved in v the current value again.
+func (r *reader) restoreValue(v valueState) {
+	r.eof = v.eof
+	r.lst = v.lst
+	r.fieldName = v.fieldName
+	r.fieldID = v.fieldID
+	r.lazyField = v.lazyField
+	r.fieldText = v.fieldText
+	r.textField = v.textField
+	r.quotedField = v.quotedField
+	r.annotations = v.annotations
+	r.annotationIDs = append(r.annotationIDs[:0], v.annotationIDs...)
+	r.valueType = v.valueType
+	r.value = v.value
+	r.bareNull = v.bareNull
+	r.start = v.start
+}
+
+// ValueStart returns the offset at which the current value starts.
+func (r *reader) valueStart() int64 {
+	return r.start
+}
+
+// Expect returns an UnexpectedTypeError unless the current value is of type t.
+func (r *reader) Expect(t Type) error {
+	return expectType(r, false, []Type{t})
+}
+
+// ExpectNonNull returns an UnexpectedTypeError unless the current value is a
+// non-null value of type t.
+func (r *reader) ExpectNonNull(t Type) error {
+	return expectType(r, true, []Type{t})
+}
+
+// ExpectOneOf returns an UnexpectedTypeError unless the current value is of
+// one of the given types.
+func (r *reader) ExpectOneOf(ts ...Type) error {
+	return expectType(r, false, ts)
+}
+
+// PeekType implements Reader.PeekType for a reader that moves to its next
+// value with next.
+func (r *reader) peekType(next func() bool) (Type, error) {
+	if r.err != nil {
+		return NoType, r.err
+	}
+
+	if r.peeked == nil {
+		if r.value != nil && (r.valueType == ListType || r.valueType == SexpType || r.valueType == StructType) {
+			return NoType, &UsageError{"Reader.PeekType", fmt.Sprintf("cannot peek past a %v; step over it first", r.valueType)}
+		}
+
+		cur := r.saveValue()
+		next()
+		if r.err != nil {
+			return NoType, r.err
+		}
+		peeked := r.saveValue()
+		r.restoreValue(cur)
+		r.peeked = &peeked
+	}
+
+	return r.peeked.valueType, nil
+}
+
+// NextPeeked moves to the value PeekType read ahead to.
+func (r *reader) nextPeeked() bool {
+	r.restoreValue(*r.peeked)
+	r.peeked = nil
+	return !r.eof
 }
 
 // IsInStruct returns true if we are currently in a struct.
@@ -412,9 +1279,97 @@ func (r *reader) FieldName() (*SymbolToken, error) {
 		return nil, r.err
 	}
 
+	if err := r.resolveFieldName(); err != nil {
+		return nil, err
+	}
 	return r.fieldName, nil
 }
 
+// ResolveFieldName makes a symbol token of a field name that has so far only
+// been read as a symbol ID or as text.
+func (r *reader) resolveFieldName() error {
+	switch {
+	case r.lazyField:
+		st, err := NewSymbolTokenBySID(r.lst, r.fieldID)
+		if err != nil {
+			return err
+		}
+		r.fieldName = &st
+		r.lazyField = false
+
+	case r.textField && r.fieldName == nil:
+		text := intern(r.interner, string(r.fieldText))
+		if r.quotedField {
+			r.fieldName = &SymbolToken{Text: &text, LocalSID: SymbolIDUnknown}
+			break
+		}
+		st, err := NewSymbolToken(r.lst, text)
+		if err != nil {
+			return err
+		}
+		r.fieldName = &st
+	}
+	return nil
+}
+
+// FieldNameBytes returns the text of the current field name.
+func (r *reader) FieldNameBytes() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	switch {
+	case r.textField:
+		return r.fieldText, nil
+	case r.lazyField:
+		return r.symbolBytes(r.fieldID)
+	case r.fieldName == nil || r.fieldName.Text == nil:
+		return nil, nil
+	default:
+		return r.symbolBytes(r.fieldName.LocalSID)
+	}
+}
+
+// SymbolBytes returns the text of the given symbol in the current symbol
+// table, converting it to bytes only the first time it is asked for.
+func (r *reader) symbolBytes(sid int64) ([]byte, error) {
+	if r.symbolTextOf != r.lst {
+		r.symbolText = map[int64][]byte{}
+		r.symbolTextOf = r.lst
+	}
+	if b, ok := r.symbolText[sid]; ok {
+		return b, nil
+	}
+
+	st, err := NewSymbolTokenBySID(r.lst, sid)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	if st.Text != nil {
+		b = append(make([]byte, 0, len(*st.Text)), *st.Text...)
+	}
+	r.symbolText[sid] = b
+	return b, nil
+}
+
+// FieldNameSID returns the symbol ID of the current field name.
+func (r *reader) FieldNameSID() (int64, error) {
+	if r.err != nil {
+		return SymbolIDUnknown, r.err
+	}
+	if r.lazyField {
+		return r.fieldID, nil
+	}
+	if err := r.resolveFieldName(); err != nil {
+		return SymbolIDUnknown, err
+	}
+	if r.fieldName == nil {
+		return SymbolIDUnknown, &UsageError{"Reader.FieldNameSID", "current value has no field name"}
+	}
+	return r.fieldName.LocalSID, nil
+}
+
 // SymbolTable returns the current symbol table.
 func (r *reader) SymbolTable() SymbolTable {
 	return r.lst
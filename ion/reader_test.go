@@ -16,12 +16,17 @@
 package ion
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,8 +40,302 @@ var blacklist = map[string]bool{
 	"../ion-tests/iontestdata/good/typecodes/T7-large.10n":   true,
 }
 
+func TestChunkReaderBinary(t *testing.T) {
+	r := NewChunkReader([]byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0xED, 0x81, 0x83, 0xDA, // $ion_symbol_table::{
+		0x87, 0xB8, // symbols:[
+		0x83, 'f', 'o', 'o', // "foo",
+		0x83, 'b', 'a', 'r', // "bar"
+		// ]}
+	})
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	r.Append([]byte{0x71, 0x0A}) // foo
+	require.True(t, r.Next())
+	testChunkReaderSymbol(t, r, "foo")
+	assert.False(t, r.Next())
+
+	r.Append([]byte{0x71, 0x0B})             // bar
+	r.Append([]byte{0xD3, 0x8A, 0x21, 0x01}) // {foo:1}
+	require.True(t, r.Next())
+	testChunkReaderSymbol(t, r, "bar")
+
+	require.True(t, r.Next())
+	require.NoError(t, r.StepIn())
+	require.True(t, r.Next())
+	fn, err := r.FieldName()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", *fn.Text)
+	require.NoError(t, r.StepOut())
+
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+}
+
+func TestChunkReaderText(t *testing.T) {
+	r := NewChunkReader([]byte(`$ion_symbol_table::{symbols:["foo","bar"]}`))
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	// Each value is held back until the next one starts, as it might go on.
+	r.Append([]byte("$10"))
+	assert.False(t, r.Next())
+
+	r.Append([]byte(" 42"))
+	require.True(t, r.Next())
+	testChunkReaderSymbol(t, r, "foo")
+	assert.False(t, r.Next())
+
+	r.Append([]byte(" $11"))
+	require.True(t, r.Next())
+	val, err := r.Int64Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), *val)
+	assert.False(t, r.Next())
+
+	r.AppendEOF()
+	require.True(t, r.Next())
+	testChunkReaderSymbol(t, r, "bar")
+
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+}
+
+func TestChunkReaderSplitValues(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		r := NewChunkReader([]byte("1 12"))
+		r.Append([]byte("34 abc"))
+		r.Append([]byte(`def "s`))
+		r.Append([]byte(`tr\`))
+		r.Append([]byte(`"ing" a:`))
+		r.Append([]byte(":[x, "))
+		r.Append([]byte("y] '''a'"))
+		r.Append([]byte("'' '''b''' {{"))
+		r.Append([]byte("aGk=}} 1.5e0"))
+		r.AppendEOF()
+
+		assert.Equal(t, []interface{}{
+			int64(1), int64(1234), "abcdef", `str"ing`, []interface{}{"x", "y"}, "ab", []byte("hi"), 1.5,
+		}, readChunkValues(t, r))
+	})
+
+	t.Run("held back", func(t *testing.T) {
+		r := NewChunkReader([]byte("a "))
+		assert.False(t, r.Next())
+		r.Append([]byte("/* b */ "))
+		assert.False(t, r.Next())
+		r.Append([]byte("'''x''' "))
+		require.True(t, r.Next())
+		testChunkReaderSymbol(t, r, "a")
+
+		// Another long string could still be joined on.
+		assert.False(t, r.Next())
+		r.Append([]byte(`"y"`))
+		require.True(t, r.Next())
+		require.True(t, r.Next())
+		assert.False(t, r.Next())
+		require.NoError(t, r.Err())
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		bin := []byte{
+			0xE0, 0x01, 0x00, 0xEA,
+			0x21, 0x01, // 1
+			0x8E, 0x81, 'a', // "a"
+			0xB2, 0x20, 0x21, // [0, 1]
+		}
+		r := NewChunkReader(bin[:5])
+		assert.False(t, r.Next())
+		r.Append(bin[5:8])
+		require.True(t, r.Next())
+		assert.False(t, r.Next())
+		r.Append(bin[8:11])
+		require.True(t, r.Next())
+		assert.False(t, r.Next())
+		r.Append(bin[11:])
+		require.True(t, r.Next())
+		assert.False(t, r.Next())
+		require.NoError(t, r.Err())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		r := NewChunkReader([]byte("1 2"))
+		r.ResetBytes([]byte("3 4"))
+		r.Append([]byte("5"))
+		r.AppendEOF()
+		assert.Equal(t, []interface{}{int64(3), int64(45)}, readChunkValues(t, r))
+
+		r.Reset(strings.NewReader("a b"))
+		r.Append([]byte("c"))
+		r.AppendEOF()
+		assert.Equal(t, []interface{}{"a", "bc"}, readChunkValues(t, r))
+	})
+}
+
+// ReadChunkValues reads the values from r up to the end of its input or the
+// current container.
+func readChunkValues(t *testing.T, r Reader) []interface{} {
+	var vals []interface{}
+	for r.Next() {
+		var v interface{}
+		switch r.Type() {
+		case IntType:
+			n, err := r.Int64Value()
+			require.NoError(t, err)
+			v = *n
+		case FloatType:
+			f, err := r.FloatValue()
+			require.NoError(t, err)
+			v = *f
+		case StringType:
+			s, err := r.StringValue()
+			require.NoError(t, err)
+			v = *s
+		case SymbolType:
+			s, err := r.SymbolValue()
+			require.NoError(t, err)
+			v = *s.Text
+		case BlobType:
+			b, err := r.ByteValue()
+			require.NoError(t, err)
+			v = b
+		case ListType:
+			require.NoError(t, r.StepIn())
+			v = readChunkValues(t, r)
+			require.NoError(t, r.StepOut())
+		}
+		vals = append(vals, v)
+	}
+	require.NoError(t, r.Err())
+	return vals
+}
+
+func testChunkReaderSymbol(t *testing.T, r Reader, eval string) {
+	assert.Equal(t, SymbolType, r.Type())
+	val, err := r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, eval, *val.Text)
+}
+
 type drainfunc func(t *testing.T, r Reader, f string)
 
+func TestFieldNameBytes(t *testing.T) {
+	text := `{a:1, b:2, 'c':3, "b":4, $4:5, '':6}`
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	writeFromReaderToWriter(t, NewReaderString(text), w)
+	require.NoError(t, w.Finish())
+
+	test := func(name string, data []byte) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReaderBytes(data)
+			require.True(t, r.Next())
+
+			fn, err := r.FieldNameBytes()
+			require.NoError(t, err)
+			assert.Nil(t, fn)
+
+			require.NoError(t, r.StepIn())
+
+			var found []string
+			for r.Next() {
+				fn, err := r.FieldNameBytes()
+				require.NoError(t, err)
+				require.NotNil(t, fn)
+				found = append(found, string(fn))
+
+				// Peeking at the next value keeps the current field name.
+				_, err = r.PeekType()
+				require.NoError(t, err)
+				fn, err = r.FieldNameBytes()
+				require.NoError(t, err)
+				assert.Equal(t, found[len(found)-1], string(fn))
+
+				st, err := r.FieldName()
+				require.NoError(t, err)
+				assert.Equal(t, *st.Text, string(fn))
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, []string{"a", "b", "c", "b", "name", ""}, found)
+
+			require.NoError(t, r.StepOut())
+		})
+	}
+
+	test("text", []byte(text))
+	test("binary", buf.Bytes())
+
+	// Unlike FieldName, scanning for a field with FieldNameBytes allocates
+	// nothing more than the scan itself.
+	scan := func(r Reader, field func(r Reader)) float64 {
+		return testing.AllocsPerRun(10, func() {
+			require.True(t, r.Next())
+			require.NoError(t, r.StepIn())
+			for r.Next() {
+				field(r)
+			}
+			require.NoError(t, r.StepOut())
+		})
+	}
+	buf.Reset()
+	w = NewBinaryWriter(&buf)
+	for i := 0; i < 11; i++ {
+		writeFromReaderToWriter(t, NewReaderString(text), w)
+	}
+	require.NoError(t, w.Finish())
+	for _, data := range [][]byte{[]byte(strings.Repeat(text, 11)), buf.Bytes()} {
+		allocs := scan(NewReaderBytes(data), func(Reader) {})
+		assert.Equal(t, allocs, scan(NewReaderBytes(data), func(r Reader) {
+			fn, _ := r.FieldNameBytes()
+			_ = bytes.Equal(fn, []byte("b"))
+		}))
+	}
+}
+
+func TestBoolRoundTrip(t *testing.T) {
+	write := func(w Writer) {
+		require.NoError(t, w.WriteBool(true))
+		require.NoError(t, w.WriteBool(false))
+		require.NoError(t, w.WriteNullType(BoolType))
+		require.NoError(t, w.WriteNull())
+		require.NoError(t, w.Finish())
+	}
+	read := func(t *testing.T, r Reader) {
+		_bool(t, r, true)
+		_bool(t, r, false)
+		_null(t, r, BoolType)
+		_null(t, r, NullType)
+		_eof(t, r)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		write(NewTextWriter(&buf))
+		assert.Equal(t, "true\nfalse\nnull.bool\nnull\n", buf.String())
+		read(t, NewReaderBytes(buf.Bytes()))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		write(NewBinaryWriter(&buf))
+		assert.Equal(t, []byte{0xE0, 0x01, 0x00, 0xEA, 0x11, 0x10, 0x1F, 0x0F}, buf.Bytes())
+		read(t, NewReaderBytes(buf.Bytes()))
+	})
+
+	// A typed null.bool is not equivalent to an untyped null (see
+	// non-equivs/bools.ion).
+	a := readCurrentValue(t, nextOf(t, NewReaderString("null.bool")))
+	b := readCurrentValue(t, nextOf(t, NewReaderString("null")))
+	assert.False(t, a.equal(b))
+}
+
+func nextOf(t *testing.T, r Reader) Reader {
+	require.True(t, r.Next())
+	return r
+}
+
 func TestDecodeFiles(t *testing.T) {
 	testReadDir(t, "../ion-tests/iontestdata/good", func(t *testing.T, r Reader, f string) {
 		d := NewDecoder(r)
@@ -84,3 +383,928 @@ func testReadFile(t *testing.T, path string, d drainfunc) {
 
 	require.NoError(t, file.Close())
 }
+
+func TestIntBytes(t *testing.T) {
+	text := "0 1 -1 256 -9223372036854775808 0x10000000000000000 -0x10000000000000000 " +
+		"-0b1_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000_0000 " +
+		"18446744073709551616 null.int \"1\""
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	writeFromReaderToWriter(t, NewReaderString(text), w)
+	require.NoError(t, w.Finish())
+
+	test := func(name string, data []byte) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReaderBytes(data)
+
+			next := func(mag []byte, neg bool) {
+				require.True(t, r.Next())
+				bs, n, err := r.IntBytes()
+				require.NoError(t, err)
+				assert.Equal(t, mag, bs)
+				assert.Equal(t, neg, n)
+			}
+
+			next([]byte{}, false)
+			next([]byte{0x01}, false)
+			next([]byte{0x01}, true)
+			next([]byte{0x01, 0x00}, false)
+			next([]byte{0x80, 0, 0, 0, 0, 0, 0, 0}, true)
+
+			large := []byte{0x01, 0, 0, 0, 0, 0, 0, 0, 0}
+			next(large, false)
+			next(large, true)
+
+			// Peeking at the next value keeps the current one's bytes.
+			_, err := r.PeekType()
+			require.NoError(t, err)
+			bs, neg, err := r.IntBytes()
+			require.NoError(t, err)
+			assert.Equal(t, large, bs)
+			assert.True(t, neg)
+
+			bi, err := r.BigIntValue()
+			require.NoError(t, err)
+			assert.Equal(t, "-18446744073709551616", bi.String())
+			_, err = r.Int64Value()
+			assert.Error(t, err)
+
+			next(large, true)
+			next(large, false)
+
+			require.True(t, r.Next())
+			bs, neg, err = r.IntBytes()
+			require.NoError(t, err)
+			assert.Nil(t, bs)
+			assert.False(t, neg)
+
+			require.True(t, r.Next())
+			_, _, err = r.IntBytes()
+			assert.Error(t, err)
+		})
+	}
+
+	test("text", []byte(text))
+	test("binary", buf.Bytes())
+
+	// Large binary ints are read without going through a big.Int.
+	buf.Reset()
+	w = NewBinaryWriter(&buf)
+	writeFromReaderToWriter(t, NewReaderString(strings.Repeat("0x10000000000000000 ", 12)), w)
+	require.NoError(t, w.Finish())
+
+	r := NewReaderBytes(buf.Bytes())
+	require.True(t, r.Next())
+	allocs := testing.AllocsPerRun(10, func() {
+		require.True(t, r.Next())
+		_, _, err := r.IntBytes()
+		require.NoError(t, err)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestReaderReset(t *testing.T) {
+	decodeAll := func(t *testing.T, r Reader) []interface{} {
+		var vals []interface{}
+		d := NewDecoder(r)
+		for {
+			v, err := d.Decode()
+			if err == ErrNoInput {
+				return vals
+			}
+			require.NoError(t, err)
+			vals = append(vals, v)
+		}
+	}
+
+	// Leaves the reader part-way through a container.
+	partlyRead := func(t *testing.T, r Reader) {
+		require.True(t, r.Next())
+		require.True(t, r.Next())
+		require.NoError(t, r.StepIn())
+		require.True(t, r.Next())
+	}
+
+	test := func(name string, first, second []byte) {
+		t.Run(name, func(t *testing.T) {
+			expected := decodeAll(t, NewReaderBytes(second))
+
+			r := NewReaderBytes(first)
+			partlyRead(t, r)
+			r.ResetBytes(second)
+			assert.Equal(t, expected, decodeAll(t, r))
+			require.NoError(t, r.Err())
+
+			r = NewReaderBytes(first)
+			partlyRead(t, r)
+			r.Reset(bytes.NewReader(second))
+			assert.Equal(t, expected, decodeAll(t, r))
+			require.NoError(t, r.Err())
+		})
+	}
+
+	text1 := "$ion_symbol_table::{symbols:[\"a\",\"b\"]} $10 {$11:[1, 2]} end"
+	text2 := "$ion_1_0 x::1 {y:[2, 3]} \"end\""
+	test("text", []byte(text1), []byte(text2))
+	test("text to empty", []byte(text1), nil)
+
+	binary := func(vals ...interface{}) []byte {
+		buf := bytes.Buffer{}
+		e := NewBinaryEncoder(&buf)
+		for _, v := range vals {
+			require.NoError(t, e.Encode(v))
+		}
+		require.NoError(t, e.Finish())
+		return buf.Bytes()
+	}
+
+	// The symbol tables differ, so stale symbols would decode wrongly.
+	bin1 := binary("s", map[string]interface{}{"first": []int{1, 2}})
+	bin2 := binary(map[string]interface{}{"second": "other"}, "s")
+	test("binary", bin1, bin2)
+
+	// The new input needn't be in the same format as the old.
+	test("text to binary", []byte(text1), bin2)
+	test("binary to text", bin1, []byte(text2))
+
+	t.Run("back and forth", func(t *testing.T) {
+		tables := 0
+		count := func(SymbolTable) { tables++ }
+
+		r := NewReaderBytes(bin1)
+		r.OnSymbolTable(count)
+		for _, in := range [][]byte{[]byte(text1), bin2, []byte(text2), bin1, nil} {
+			fresh := NewReaderBytes(in)
+			fresh.OnSymbolTable(count)
+			tables = 0
+			expected := decodeAll(t, fresh)
+			expectedTables := tables
+
+			// The callback carries over to each new input.
+			tables = 0
+			r.ResetBytes(in)
+			assert.Equal(t, expected, decodeAll(t, r))
+			require.NoError(t, r.Err())
+			assert.Equal(t, expectedTables, tables)
+		}
+	})
+}
+
+func TestOnSymbolTable(t *testing.T) {
+	collect := func(r Reader) [][]string {
+		var tables [][]string
+		r.OnSymbolTable(func(st SymbolTable) {
+			var syms []string
+			for id := V1SystemSymbolTable.MaxID() + 1; id <= st.MaxID(); id++ {
+				sym, _ := st.FindByID(id)
+				syms = append(syms, sym)
+			}
+			tables = append(tables, syms)
+		})
+		for r.Next() {
+		}
+		require.NoError(t, r.Err())
+		return tables
+	}
+
+	text := `$ion_symbol_table::{symbols:["a"]} $10
+		$ion_symbol_table::{imports:$ion_symbol_table, symbols:["b"]} $11
+		$ion_symbol_table::{symbols:["c", "d"]} $10
+		$ion_1_0 x
+		$ion_1_0 y`
+	expected := [][]string{{"a"}, {"a", "b"}, {"c", "d"}, nil}
+	assert.Equal(t, expected, collect(NewReaderString(text)))
+
+	// A stream without local symbol tables establishes none.
+	assert.Empty(t, collect(NewReaderString("$ion_1_0 a b")))
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.WriteSymbolFromString("a"))
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.WriteSymbolFromString("b"))
+	require.NoError(t, w.Finish())
+	// Flush starts a new stream, with a version marker, so the second table is
+	// established afresh rather than appended.
+	assert.Equal(t, [][]string{{"a"}, nil, {"a", "b"}}, collect(NewReaderBytes(buf.Bytes())))
+
+	// The callback follows the reader across a switch between text and
+	// binary, and is kept by Reset.
+	r := NewReaderBytes(append([]byte(`$ion_symbol_table::{symbols:["t"]} $10 `), buf.Bytes()...))
+	assert.Equal(t, [][]string{{"t"}, nil, {"a"}, nil, {"a", "b"}}, collect(r))
+
+	var n int
+	r.OnSymbolTable(func(SymbolTable) { n++ })
+	r.ResetBytes([]byte(text))
+	for r.Next() {
+	}
+	assert.Equal(t, 4, n)
+
+	r.OnSymbolTable(nil)
+	r.ResetBytes([]byte(text))
+	for r.Next() {
+	}
+	assert.Equal(t, 4, n)
+}
+
+func TestOnValueSize(t *testing.T) {
+	type payload struct {
+		Small []byte `ion:"small"`
+		Big   []byte `ion:"big"`
+	}
+	vals := []interface{}{"héllo", payload{[]byte{1, 2, 3}, bytes.Repeat([]byte{0xAB}, 1000)}, "after"}
+
+	buf := bytes.Buffer{}
+	e := NewTextEncoder(&buf)
+	for _, v := range vals {
+		require.NoError(t, e.Encode(v))
+	}
+	require.NoError(t, e.Finish())
+	text := append([]byte{}, buf.Bytes()...)
+
+	buf.Reset()
+	e = NewBinaryEncoder(&buf)
+	for _, v := range vals {
+		require.NoError(t, e.Encode(v))
+	}
+	require.NoError(t, e.Finish())
+	bin := buf.Bytes()
+
+	type seen struct {
+		t    Type
+		size int
+	}
+
+	// The binary stream's symbol table is read with the same reader, so the
+	// strings in it, the field names, are seen too.
+	var lst []seen
+	errTooBig := errors.New("too big")
+	for name, in := range map[string][]byte{"text": text, "binary": bin} {
+		if name == "binary" {
+			lst = []seen{{StringType, 5}, {StringType, 3}}
+		} else {
+			lst = nil
+		}
+		t.Run(name, func(t *testing.T) {
+			var sizes []seen
+			r := NewReaderBytes(in)
+			r.OnValueSize(func(t Type, size int) error {
+				sizes = append(sizes, seen{t, size})
+				if t == BlobType && size > 100 {
+					return errTooBig
+				}
+				return nil
+			})
+
+			require.True(t, r.Next())
+			val, err := r.StringValue()
+			require.NoError(t, err)
+			assert.Equal(t, "héllo", *val)
+
+			require.True(t, r.Next())
+			require.NoError(t, r.StepIn())
+			require.True(t, r.Next())
+			bs, err := r.ByteValue()
+			require.NoError(t, err)
+			assert.Equal(t, []byte{1, 2, 3}, bs)
+
+			// The big blob is refused, aborting the read.
+			assert.False(t, r.Next())
+			assert.Equal(t, errTooBig, r.Err())
+			assert.False(t, r.Next())
+			assert.Equal(t, append(lst, seen{StringType, 6}, seen{BlobType, 3}, seen{BlobType, 1000}), sizes)
+
+			// Without the function, or after Reset with a permissive one,
+			// everything is read.
+			sizes = nil
+			r.OnValueSize(func(t Type, size int) error {
+				sizes = append(sizes, seen{t, size})
+				return nil
+			})
+			r.ResetBytes(in)
+			var n int
+			for r.Next() {
+				n++
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, 3, n)
+			assert.Equal(t, append(lst, seen{StringType, 6}, seen{StringType, 5}), sizes)
+
+			r.OnValueSize(nil)
+			r.ResetBytes(in)
+			n = 0
+			for r.Next() {
+				n++
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, 3, n)
+		})
+	}
+}
+
+func TestOnValueSizeHugeBinaryLength(t *testing.T) {
+	// A blob whose length, 2^63, doesn't fit in an int is a syntax error,
+	// whether or not there's a function to pass it to.
+	in := []byte{0xE0, 0x01, 0x00, 0xEA, 0xAE, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0x80}
+
+	called := false
+	r := NewReaderBytes(in)
+	r.OnValueSize(func(t Type, size int) error {
+		called = true
+		return nil
+	})
+	assert.False(t, r.Next())
+	var se *SyntaxError
+	assert.True(t, errors.As(r.Err(), &se), "%v", r.Err())
+	assert.False(t, called)
+
+	r = NewReaderBytes(in)
+	assert.False(t, r.Next())
+	assert.True(t, errors.As(r.Err(), &se), "%v", r.Err())
+}
+
+func TestReadEmptyDocuments(t *testing.T) {
+	ivm := []byte{0xE0, 0x01, 0x00, 0xEA}
+	cat := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	test := func(name string, in []byte, expected string) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReaderBytes(in)
+			buf := strings.Builder{}
+			w := NewTextWriterOpts(&buf, TextWriterQuietFinish)
+			writeFromReaderToWriter(t, r, w)
+			require.NoError(t, w.Finish())
+			assert.Equal(t, expected, buf.String())
+			assert.False(t, r.Next())
+			assert.NoError(t, r.Err())
+		})
+	}
+
+	test("empty", nil, "")
+	test("binary ivm only", ivm, "")
+	test("binary ivms only", cat(ivm, ivm), "")
+	test("binary trailing ivm", cat(ivm, []byte{0x21, 0x01}, ivm), "1")
+	test("text whitespace only", []byte(" \n"), "")
+	test("text ivm only", []byte("$ion_1_0"), "")
+	test("text ivms only", []byte("$ion_1_0 $ion_1_0\n"), "")
+	test("text trailing ivm", []byte("1 $ion_1_0"), "1")
+
+	// Only an unquoted, unannotated version marker at the top level is one.
+	test("text not ivms", []byte("'$ion_1_0' a::$ion_1_0 [$ion_1_0]"), "'$ion_1_0'\na::'$ion_1_0'\n['$ion_1_0']")
+
+	// A version marker resets the symbol table.
+	t.Run("text ivm resets symbols", func(t *testing.T) {
+		r := NewReaderString("$ion_symbol_table::{symbols:[\"a\"]} $10 $ion_1_0 $10")
+		require.True(t, r.Next())
+		sym, err := r.SymbolValue()
+		require.NoError(t, err)
+		assert.Equal(t, "a", *sym.Text)
+		assert.False(t, r.Next())
+		assert.Error(t, r.Err())
+	})
+}
+
+func TestReadMixedTextAndBinary(t *testing.T) {
+	binary := func(vals ...interface{}) []byte {
+		buf := bytes.Buffer{}
+		e := NewBinaryEncoder(&buf)
+		for _, v := range vals {
+			require.NoError(t, e.Encode(v))
+		}
+		require.NoError(t, e.Finish())
+		return buf.Bytes()
+	}
+
+	var in []byte
+	in = append(in, "a 1 "...)
+	in = append(in, binary(map[string]interface{}{"x": "y"}, NewSymbolTokenFromString("z"))...)
+	in = append(in, "$ion_1_0 b::2\n"...)
+	in = append(in, binary(3)...)
+	in = append(in, binary(4.5)...)
+	in = append(in, "$ion_1_0 {c:[d]}"...)
+
+	buf := strings.Builder{}
+	w := NewTextWriterOpts(&buf, TextWriterQuietFinish)
+	writeFromReaderToWriter(t, NewReaderBytes(in), w)
+	require.NoError(t, w.Finish())
+
+	// Each segment starts afresh, and version markers are not values.
+	assert.Equal(t, "a\n1\n{x:\"y\"}\nz\nb::2\n3\n4.5e+0\n{c:[d]}", buf.String())
+
+	t.Run("binary first", func(t *testing.T) {
+		in := append(binary(1), "$ion_1_0 two"...)
+		r := NewReaderBytes(in)
+		require.True(t, r.Next())
+		require.True(t, r.Next())
+		sym, err := r.SymbolValue()
+		require.NoError(t, err)
+		assert.Equal(t, "two", *sym.Text)
+		assert.False(t, r.Next())
+		assert.NoError(t, r.Err())
+	})
+
+	t.Run("not a marker", func(t *testing.T) {
+		// Only a whole $ion_1_0 symbol is a text version marker; this is
+		// read as binary, starting with a four-byte int.
+		r := NewReaderBytes(append(binary(1), "$ion_1_0x"...))
+		require.True(t, r.Next())
+		require.True(t, r.Next())
+		assert.Equal(t, IntType, r.Type())
+	})
+
+	t.Run("inside a container", func(t *testing.T) {
+		r := NewReaderBytes(append([]byte("[1, "), binary(2)...))
+		require.True(t, r.Next())
+		require.NoError(t, r.StepIn())
+		require.True(t, r.Next())
+		assert.False(t, r.Next())
+		assert.Error(t, r.Err())
+	})
+}
+
+func TestEmptyAndUnknownAnnotations(t *testing.T) {
+	empty := NewSymbolTokenFromString("")
+	dollarZero := NewSymbolTokenFromString("$0")
+	unknown := SymbolToken{LocalSID: 0}
+	as := []SymbolToken{empty, unknown, dollarZero, empty}
+
+	check := func(t *testing.T, r Reader) {
+		require.True(t, r.Next())
+		got, err := r.Annotations()
+		require.NoError(t, err)
+		require.Len(t, got, 4)
+
+		for _, i := range []int{0, 3} {
+			require.NotNil(t, got[i].Text)
+			assert.Equal(t, "", *got[i].Text)
+		}
+		assert.Nil(t, got[1].Text)
+		assert.Equal(t, int64(0), got[1].LocalSID)
+		require.NotNil(t, got[2].Text)
+		assert.Equal(t, "$0", *got[2].Text)
+
+		val, err := r.Int64Value()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), *val)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := strings.Builder{}
+		w := NewTextWriter(&buf)
+		require.NoError(t, w.Annotations(as...))
+		require.NoError(t, w.WriteInt(1))
+		require.NoError(t, w.Finish())
+		assert.Equal(t, "''::$0::'$0'::''::1\n", buf.String())
+
+		check(t, NewReaderString(buf.String()))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		require.NoError(t, w.Annotations(as...))
+		require.NoError(t, w.WriteInt(1))
+		require.NoError(t, w.Finish())
+
+		check(t, NewReaderBytes(buf.Bytes()))
+	})
+}
+
+func TestSymbolIDOutOfRange(t *testing.T) {
+	test := func(name string, in []byte, sid int64, maxID uint64) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReaderBytes(in)
+			var walk func()
+			walk = func() {
+				for r.Next() {
+					if r.Type() == StructType || r.Type() == ListType {
+						require.NoError(t, r.StepIn())
+						walk()
+						return
+					}
+				}
+			}
+			walk()
+
+			var err *SymbolIDOutOfRangeError
+			require.True(t, errors.As(r.Err(), &err), "unexpected error %v", r.Err())
+			assert.Equal(t, sid, err.SID)
+			assert.Equal(t, maxID, err.MaxID)
+		})
+	}
+
+	// Mirrors ion-tests' bad/symbolIDUnmapped.ion and .10n.
+	test("symbolIDUnmapped.ion", []byte("$ion_1_0 $10"), 10, 9)
+	test("symbolIDUnmapped.10n", []byte{0xE0, 0x01, 0x00, 0xEA, 0x71, 0x0A}, 10, 9)
+
+	test("text value", []byte("[a, $12]"), 12, 9)
+	test("text annotation", []byte("$ion_symbol_table::{symbols:[\"a\"]} $11::1"), 11, 10)
+	test("text field name", []byte("{a:1, $10:2}"), 10, 9)
+
+	test("binary value", []byte{0xE0, 0x01, 0x00, 0xEA, 0xB2, 0x71, 0x0C}, 12, 9)
+	test("binary annotation", []byte{0xE0, 0x01, 0x00, 0xEA, 0xE4, 0x81, 0x8B, 0x21, 0x01}, 11, 9)
+	test("binary field name", []byte{0xE0, 0x01, 0x00, 0xEA, 0xD3, 0x8A, 0x21, 0x01}, 10, 9)
+}
+
+func TestBytesConsumed(t *testing.T) {
+	text := "a 1 {b:[2, 3], c:\"four\"}\r\n(5 6) // end\n"
+	bin, err := MarshalBinary([]interface{}{"a", 1, map[string]interface{}{"b": []int{2, 3}}})
+	require.NoError(t, err)
+	bin = append(bin, bin...)
+
+	test := func(name string, in []byte, deep bool) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(bytes.NewReader(in))
+			assert.Equal(t, int64(0), r.BytesConsumed())
+
+			last := int64(0)
+			check := func() {
+				n := r.BytesConsumed()
+				assert.GreaterOrEqual(t, n, last)
+				assert.LessOrEqual(t, n, int64(len(in)))
+				last = n
+			}
+
+			var walk func()
+			walk = func() {
+				for r.Next() {
+					check()
+					if deep && (r.Type() == ListType || r.Type() == StructType || r.Type() == SexpType) {
+						require.NoError(t, r.StepIn())
+						walk()
+						require.NoError(t, r.StepOut())
+						check()
+					}
+				}
+			}
+			walk()
+			require.NoError(t, r.Err())
+			check()
+			assert.Greater(t, last, int64(len(in))/2)
+		})
+	}
+
+	test("text", []byte(text), true)
+	test("text skipping", []byte(text), false)
+	test("binary", bin, true)
+	test("binary skipping", bin, false)
+	test("mixed", append(append([]byte(text), bin...), "$ion_1_0 "+text...), true)
+
+	// A fully-read binary stream has been consumed entirely.
+	r := NewReaderBytes(bin)
+	for r.Next() {
+	}
+	assert.Equal(t, int64(len(bin)), r.BytesConsumed())
+}
+
+func TestBytesConsumedCRLF(t *testing.T) {
+	// A \r\n is one newline but two bytes, whether the reader has read past
+	// it or only peeked at it, so every count for \r\n input is the count
+	// for \n input plus one for each newline before that point.
+	consumed := func(in string) []int64 {
+		var ns []int64
+		r := NewReaderString(in)
+		var walk func()
+		walk = func() {
+			for r.Next() {
+				ns = append(ns, r.BytesConsumed())
+				if r.Type() == SexpType || r.Type() == StructType {
+					require.NoError(t, r.StepIn())
+					walk()
+					require.NoError(t, r.StepOut())
+					ns = append(ns, r.BytesConsumed())
+				}
+			}
+		}
+		walk()
+		require.NoError(t, r.Err())
+		return append(ns, r.BytesConsumed())
+	}
+
+	for _, lf := range []string{
+		"1\n2\n",
+		"a\n\n\nb",
+		"(a\n)\nb",
+		"{a:1\n}\nb",
+		"1.5\nb // c\n",
+		"'''a'''\n'''b'''\nc",
+	} {
+		crlf := strings.ReplaceAll(lf, "\n", "\r\n")
+		var expected []int64
+		for _, n := range consumed(lf) {
+			expected = append(expected, n+int64(strings.Count(lf[:n], "\n")))
+		}
+		assert.Equal(t, expected, consumed(crlf), "%q", crlf)
+	}
+}
+
+func TestExpect(t *testing.T) {
+	text := "1 null.int  a::null \"s\""
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.WriteInt(1))
+	require.NoError(t, w.WriteNullType(IntType))
+	require.NoError(t, w.Annotation(NewSymbolTokenFromString("a")))
+	require.NoError(t, w.WriteNull())
+	require.NoError(t, w.WriteString("s"))
+	require.NoError(t, w.Finish())
+	bin := buf.Bytes()
+
+	// The values are encoded in 2, 1, 4, and 2 bytes at the end.
+	start := uint64(len(bin) - 9)
+
+	test := func(name string, r Reader, offsets []uint64) {
+		t.Run(name, func(t *testing.T) {
+			unexpected := func(err error) *UnexpectedTypeError {
+				var ute *UnexpectedTypeError
+				require.True(t, errors.As(err, &ute), "expected an UnexpectedTypeError, got %v", err)
+				return ute
+			}
+
+			// Before the first value.
+			err := r.Expect(IntType)
+			assert.Equal(t, "ion: expected int, got no value (offset 0)", err.Error())
+			assert.Equal(t, NoType, unexpected(err).Type)
+
+			require.True(t, r.Next())
+			assert.NoError(t, r.Expect(IntType))
+			assert.NoError(t, r.ExpectNonNull(IntType))
+			assert.NoError(t, r.ExpectOneOf(FloatType, IntType))
+
+			ute := unexpected(r.Expect(StringType))
+			assert.Equal(t, []Type{StringType}, ute.Expected)
+			assert.Equal(t, IntType, ute.Type)
+			assert.False(t, ute.IsNull)
+			assert.Equal(t, offsets[0], ute.Offset)
+
+			// Peeking ahead doesn't move the offset.
+			_, err = r.PeekType()
+			require.NoError(t, err)
+			err = r.ExpectOneOf(FloatType, DecimalType)
+			assert.Equal(t, fmt.Sprintf("ion: expected float or decimal, got int (offset %v)", offsets[0]), err.Error())
+
+			require.True(t, r.Next())
+			assert.NoError(t, r.Expect(IntType))
+			err = r.ExpectNonNull(IntType)
+			assert.Contains(t, err.Error(), "ion: expected non-null int, got null.int (offset ")
+			ute = unexpected(err)
+			assert.True(t, ute.NonNull)
+			assert.True(t, ute.IsNull)
+			assert.Equal(t, offsets[1], ute.Offset)
+
+			require.True(t, r.Next())
+			assert.NoError(t, r.Expect(NullType))
+			assert.Contains(t, r.ExpectNonNull(NullType).Error(), "expected non-null null, got null (offset ")
+			assert.Contains(t, r.Expect(IntType).Error(), "expected int, got null (offset ")
+
+			// An annotated value starts at its annotations.
+			assert.Equal(t, offsets[2], unexpected(r.Expect(IntType)).Offset)
+
+			require.True(t, r.Next())
+			assert.NoError(t, r.ExpectOneOf(StringType, SymbolType))
+			assert.Equal(t, offsets[3], unexpected(r.ExpectOneOf()).Offset)
+
+			require.False(t, r.Next())
+			assert.Error(t, r.Expect(StringType))
+		})
+	}
+
+	offsets := []uint64{0, 2, 12, 20}
+	test("text", NewReaderString(text), offsets)
+	test("binary", NewReaderBytes(bin), []uint64{start, start + 2, start + 3, start + 7})
+	test("trivia", NewTriviaReader(strings.NewReader(text)), offsets)
+	test("chunk", NewChunkReader([]byte(text)), offsets)
+}
+
+func TestPeekType(t *testing.T) {
+	text := `a::1 {b:"x", c:[2]} "s" null.list`
+
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf)
+	require.NoError(t, w.Annotation(NewSymbolTokenFromString("a")))
+	require.NoError(t, w.WriteInt(1))
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("b")))
+	require.NoError(t, w.WriteString("x"))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("c")))
+	require.NoError(t, w.BeginList())
+	require.NoError(t, w.WriteInt(2))
+	require.NoError(t, w.EndList())
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.WriteString("s"))
+	require.NoError(t, w.WriteNullType(ListType))
+	require.NoError(t, w.Finish())
+	bin := buf.Bytes()
+
+	peek := func(r Reader, eval Type) {
+		typ, err := r.PeekType()
+		require.NoError(t, err)
+		assert.Equal(t, eval, typ)
+	}
+
+	test := func(name string, r Reader) {
+		t.Run(name, func(t *testing.T) {
+			peek(r, IntType)
+			assert.Equal(t, NoType, r.Type())
+
+			require.True(t, r.Next())
+			peek(r, StructType)
+			peek(r, StructType)
+			as, err := r.Annotations()
+			require.NoError(t, err)
+			assert.Equal(t, "a", *as[0].Text)
+			val, err := r.Int64Value()
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), *val)
+
+			require.True(t, r.Next())
+			assert.Equal(t, StructType, r.Type())
+			_, err = r.PeekType()
+			var uerr *UsageError
+			assert.True(t, errors.As(err, &uerr))
+
+			require.NoError(t, r.StepIn())
+			peek(r, StringType)
+			require.True(t, r.Next())
+			peek(r, ListType)
+			assert.Error(t, r.StepIn())
+			str, err := r.StringValue()
+			require.NoError(t, err)
+			assert.Equal(t, "x", *str)
+			fn, err := r.FieldName()
+			require.NoError(t, err)
+			assert.Equal(t, "b", *fn.Text)
+
+			require.True(t, r.Next())
+			fn, err = r.FieldName()
+			require.NoError(t, err)
+			assert.Equal(t, "c", *fn.Text)
+			require.NoError(t, r.StepIn())
+			require.True(t, r.Next())
+			peek(r, NoType)
+			require.NoError(t, r.StepOut())
+			peek(r, NoType)
+			require.NoError(t, r.StepOut())
+
+			peek(r, StringType)
+			require.True(t, r.Next())
+			peek(r, ListType)
+			require.True(t, r.Next())
+			assert.True(t, r.IsNull())
+			peek(r, NoType)
+			assert.False(t, r.Next())
+			assert.NoError(t, r.Err())
+		})
+	}
+
+	test("text", NewReaderString(text))
+	test("binary", NewReaderBytes(bin))
+	test("trivia", NewTriviaReader(strings.NewReader(text)))
+
+	t.Run("step out past the peeked value", func(t *testing.T) {
+		r := NewReaderString("[1, [2]] 3")
+		require.True(t, r.Next())
+		require.NoError(t, r.StepIn())
+		require.True(t, r.Next())
+		peek(r, ListType)
+		require.NoError(t, r.StepOut())
+		require.True(t, r.Next())
+		val, err := r.Int64Value()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), *val)
+	})
+
+	t.Run("symbol tables", func(t *testing.T) {
+		// The binary reader resolves annotations lazily, against the symbol
+		// table of the value, not the one peeking installed.
+		buf := bytes.Buffer{}
+		w := NewBinaryWriter(&buf)
+		require.NoError(t, w.Annotation(NewSymbolTokenFromString("first")))
+		require.NoError(t, w.WriteInt(1))
+		require.NoError(t, w.Finish())
+		one := buf.Bytes()
+		two, err := MarshalBinary(NewSymbolTokenFromString("second"))
+		require.NoError(t, err)
+
+		for _, r := range []Reader{
+			NewReaderBytes(append(one, two...)),
+			NewReaderString(`first::1 $ion_symbol_table::{symbols:["second"]} $10`),
+		} {
+			require.True(t, r.Next())
+			peek(r, SymbolType)
+			as, err := r.Annotations()
+			require.NoError(t, err)
+			assert.Equal(t, "first", *as[0].Text)
+			require.True(t, r.Next())
+			sym, err := r.SymbolValue()
+			require.NoError(t, err)
+			assert.Equal(t, "second", *sym.Text)
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		bin, err := MarshalBinary(2)
+		require.NoError(t, err)
+		r := NewReaderBytes(append([]byte("1 "), bin...))
+		require.True(t, r.Next())
+		peek(r, IntType)
+		val, err := r.Int64Value()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), *val)
+		require.True(t, r.Next())
+		val, err = r.Int64Value()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), *val)
+		peek(r, NoType)
+		assert.False(t, r.Next())
+	})
+
+	t.Run("chunk", func(t *testing.T) {
+		r := NewChunkReader([]byte("1 2"))
+		require.True(t, r.Next())
+		peek(r, NoType)
+		r.Append([]byte(" 3"))
+		peek(r, IntType)
+		require.True(t, r.Next())
+		val, err := r.Int64Value()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), *val)
+	})
+}
+
+// WriteScalars writes n top-level scalars of assorted types, as a metrics or
+// telemetry log might hold, to w.
+func writeScalars(w Writer, n int) error {
+	ts := NewTimestamp(time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC), TimestampPrecisionSecond, TimezoneUTC)
+	for i := 0; i < n; i++ {
+		var err error
+		switch i % 6 {
+		case 0:
+			err = w.WriteInt(int64(i))
+		case 1:
+			err = w.WriteFloat(float64(i) / 8)
+		case 2:
+			err = w.WriteString("cpu.load")
+		case 3:
+			err = w.WriteSymbolFromString("host-a")
+		case 4:
+			err = w.WriteTimestamp(ts)
+		case 5:
+			err = w.WriteBool(i%2 == 0)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.Finish()
+}
+
+// ReadScalars reads every top-level scalar from r.
+func readScalars(r Reader) error {
+	for r.Next() {
+		var err error
+		switch r.Type() {
+		case IntType:
+			_, err = r.Int64Value()
+		case FloatType:
+			_, err = r.FloatValue()
+		case StringType:
+			_, err = r.StringValue()
+		case SymbolType:
+			_, err = r.SymbolValue()
+		case TimestampType:
+			_, err = r.TimestampValue()
+		case BoolType:
+			_, err = r.BoolValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+func BenchmarkReadScalars(b *testing.B) {
+	bench := func(name string, w func(*bytes.Buffer) Writer) {
+		buf := bytes.Buffer{}
+		require.NoError(b, writeScalars(w(&buf), 10000))
+		bs := buf.Bytes()
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bs)))
+			r := NewReaderBytes(bs)
+			for i := 0; i < b.N; i++ {
+				r.ResetBytes(bs)
+				if err := readScalars(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	bench("text", func(buf *bytes.Buffer) Writer { return NewTextWriter(buf) })
+	bench("binary", func(buf *bytes.Buffer) Writer { return NewBinaryWriter(buf) })
+}
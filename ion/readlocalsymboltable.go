@@ -3,13 +3,28 @@ package ion
 import "fmt"
 
 // ReadLocalSymbolTable reads and installs a new local symbol table.
-func readLocalSymbolTable(r Reader, cat Catalog) (SymbolTable, error) {
+func readLocalSymbolTable(r Reader, cat Catalog, interner Interner) (SymbolTable, error) {
+	// The struct may carry more annotations after $ion_symbol_table; it's
+	// still a symbol table, and the table keeps them so they can be written
+	// back out. Any without known text are dropped.
+	as, err := r.Annotations()
+	if err != nil {
+		return nil, err
+	}
+	var annotations []string
+	for _, a := range as[1:] {
+		if a.Text != nil {
+			annotations = append(annotations, *a.Text)
+		}
+	}
+
 	if err := r.StepIn(); err != nil {
 		return nil, err
 	}
 
 	var imps []SharedSymbolTable
 	var syms []string
+	var unknown []int
 
 	foundImport := false
 	foundLocals := false
@@ -30,7 +45,7 @@ func readLocalSymbolTable(r Reader, cat Catalog) (SymbolTable, error) {
 				return nil, fmt.Errorf("ion: multiple symbol fields found within a single local symbol table")
 			}
 			foundLocals = true
-			syms, err = readSymbols(r)
+			syms, unknown, err = readSymbols(r, interner)
 		case "imports":
 			if foundImport {
 				return nil, fmt.Errorf("ion: multiple imports fields found within a single local symbol table")
@@ -47,7 +62,9 @@ func readLocalSymbolTable(r Reader, cat Catalog) (SymbolTable, error) {
 		return nil, err
 	}
 
-	return NewLocalSymbolTable(imps, syms), nil
+	t := newLocalSymbolTable(imps, syms, unknown)
+	t.annotations = annotations
+	return t, nil
 }
 
 // ReadImports reads the imports field of a local symbol table.
@@ -185,33 +202,32 @@ func readImport(r Reader, cat Catalog) (SharedSymbolTable, error) {
 	return imp, nil
 }
 
-// ReadSymbols reads the symbols from a symbol table.
-func readSymbols(r Reader) ([]string, error) {
+// ReadSymbols reads the symbols from a symbol table, along with the indexes
+// of any that have unknown text: those that are not strings, which are given
+// as "".
+func readSymbols(r Reader, interner Interner) ([]string, []int, error) {
 	if r.Type() != ListType {
-		return nil, nil
+		return nil, nil, nil
 	}
 	if err := r.StepIn(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var syms []string
+	var unknown []int
 	for r.Next() {
-		if r.Type() == StringType {
+		if r.Type() == StringType && !r.IsNull() {
 			sym, err := r.StringValue()
-
 			if err != nil {
-				return nil, err
-			}
-			if sym != nil {
-				syms = append(syms, *sym)
-			} else {
-				syms = append(syms, "")
+				return nil, nil, err
 			}
+			syms = append(syms, intern(interner, *sym))
 		} else {
+			unknown = append(unknown, len(syms))
 			syms = append(syms, "")
 		}
 	}
 
 	err := r.StepOut()
-	return syms, err
+	return syms, unknown, err
 }
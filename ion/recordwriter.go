@@ -0,0 +1,77 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"fmt"
+)
+
+// A RecordWriter writes homogeneous structs, all with the same fields in the
+// same order, given just their values. It is handy for tabular data:
+//
+//     rw := NewRecordWriter(NewBinaryWriter(out), "id", "name")
+//     for _, row := range rows {
+//         if err := rw.WriteRecord(row.ID, row.Name); err != nil {
+//             return err
+//         }
+//     }
+//     return rw.Finish()
+//
+type RecordWriter struct {
+	w      Writer
+	fields []SymbolToken
+}
+
+// NewRecordWriter creates a RecordWriter that writes structs with the given
+// field names, in order, to w. Values can still be written to w directly
+// between records.
+func NewRecordWriter(w Writer, fields ...string) *RecordWriter {
+	toks := make([]SymbolToken, len(fields))
+	for i, f := range fields {
+		toks[i] = NewSymbolTokenFromString(f)
+	}
+	return &RecordWriter{
+		w:      w,
+		fields: toks,
+	}
+}
+
+// WriteRecord writes a struct whose fields have the given values, marshaled
+// as by Writer.WriteValue. There must be exactly one value per field.
+func (rw *RecordWriter) WriteRecord(values ...interface{}) error {
+	if len(values) != len(rw.fields) {
+		msg := fmt.Sprintf("expected %v values, got %v", len(rw.fields), len(values))
+		return &UsageError{"RecordWriter.WriteRecord", msg}
+	}
+
+	if err := rw.w.BeginStruct(); err != nil {
+		return err
+	}
+	for i, v := range values {
+		if err := rw.w.FieldName(rw.fields[i]); err != nil {
+			return err
+		}
+		if err := rw.w.WriteValue(v); err != nil {
+			return err
+		}
+	}
+	return rw.w.EndStruct()
+}
+
+// Finish finishes writing to the underlying Writer.
+func (rw *RecordWriter) Finish() error {
+	return rw.w.Finish()
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordWriter(t *testing.T) {
+	buf := strings.Builder{}
+	rw := NewRecordWriter(NewTextWriter(&buf), "id", "name", "tags")
+
+	require.NoError(t, rw.WriteRecord(1, "one", []string{"a"}))
+	require.NoError(t, rw.WriteRecord(2, nil, []string{}))
+
+	err := rw.WriteRecord(3, "three")
+	require.Error(t, err)
+	assert.IsType(t, &UsageError{}, err)
+
+	require.NoError(t, rw.Finish())
+	assert.Equal(t, "{id:1,name:\"one\",tags:[\"a\"]}\n{id:2,name:null,tags:[]}\n", buf.String())
+}
+
+func TestRecordWriterBinary(t *testing.T) {
+	type row struct {
+		ID   int    `ion:"id"`
+		Name string `ion:"name"`
+	}
+
+	buf := bytes.Buffer{}
+	rw := NewRecordWriter(NewBinaryWriter(&buf), "id", "name")
+	require.NoError(t, rw.WriteRecord(1, "one"))
+	require.NoError(t, rw.WriteRecord(2, "two"))
+	require.NoError(t, rw.Finish())
+
+	rows, err := ReadAll[row](&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []row{{1, "one"}, {2, "two"}}, rows)
+}
+
+func BenchmarkRecordWriter(b *testing.B) {
+	b.Run("RecordWriter", func(b *testing.B) {
+		b.ReportAllocs()
+		w := NewBinaryWriter(ioutil.Discard)
+		rw := NewRecordWriter(w, "id", "name", "score")
+		for i := 0; i < b.N; i++ {
+			if err := rw.WriteRecord(i, "name", 1.5); err != nil {
+				b.Fatal(err)
+			}
+		}
+		require.NoError(b, rw.Finish())
+	})
+
+	b.Run("Manual", func(b *testing.B) {
+		b.ReportAllocs()
+		w := NewBinaryWriter(ioutil.Discard)
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, w.BeginStruct())
+			require.NoError(b, w.FieldName(NewSymbolTokenFromString("id")))
+			require.NoError(b, w.WriteInt(int64(i)))
+			require.NoError(b, w.FieldName(NewSymbolTokenFromString("name")))
+			require.NoError(b, w.WriteString("name"))
+			require.NoError(b, w.FieldName(NewSymbolTokenFromString("score")))
+			require.NoError(b, w.WriteFloat(1.5))
+			require.NoError(b, w.EndStruct())
+		}
+		require.NoError(b, w.Finish())
+	})
+}
@@ -78,6 +78,25 @@ func (t *tokenizer) SkipLobWhitespace() (int, error) {
 // SkipValue skips to the end of the current value, if the caller
 // didn't bother to consume it before calling Next again.
 func (t *tokenizer) skipValue() (int, error) {
+	c, err := t.skipValueBody()
+	if err != nil {
+		return 0, err
+	}
+
+	if isWhitespace(c) {
+		c, _, err = t.skipWhitespace()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	t.unfinished = false
+	return c, nil
+}
+
+// SkipValueBody skips to the end of the current value, returning the
+// character following it.
+func (t *tokenizer) skipValueBody() (int, error) {
 	var c int
 	var err error
 
@@ -112,19 +131,7 @@ func (t *tokenizer) skipValue() (int, error) {
 		panic(fmt.Sprintf("skipValue called with token=%v", t.token))
 	}
 
-	if err != nil {
-		return 0, err
-	}
-
-	if isWhitespace(c) {
-		c, _, err = t.skipWhitespace()
-		if err != nil {
-			return 0, err
-		}
-	}
-
-	t.unfinished = false
-	return c, nil
+	return c, err
 }
 
 // SkipNumber skips a (non-binary, non-hex) number.
@@ -564,6 +571,7 @@ func (t *tokenizer) skipEndOfLongString(handler commentHandler) (bool, bool, err
 	if err != nil {
 		return false, isConsumed, err
 	}
+	t.longEnd = len(t.tape)
 
 	// Consume any additional whitespace/comments.
 	c, _, err := t.skipWhitespaceWith(handler)
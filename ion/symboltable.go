@@ -16,6 +16,8 @@
 package ion
 
 import (
+	"io"
+	"sort"
 	"strings"
 )
 
@@ -24,7 +26,8 @@ import (
 type SymbolTable interface {
 	// Imports returns the symbol tables this table imports.
 	Imports() []SharedSymbolTable
-	// Symbols returns the symbols this symbol table defines.
+	// Symbols returns the symbols this symbol table defines, with "" for any
+	// that has unknown text.
 	Symbols() []string
 	// MaxID returns the maximum ID this symbol table defines.
 	MaxID() uint64
@@ -34,7 +37,21 @@ type SymbolTable interface {
 	FindByName(symbol string) (uint64, bool)
 	// FindByID finds the name of a symbol given its ID.
 	FindByID(id uint64) (string, bool)
-	// WriteTo serializes the symbol table to an ion.Writer.
+	// Compatible returns true if other is an append-extension of this symbol
+	// table; that is, if every symbol ID this table defines maps to the same
+	// text in other, so that data encoded against this table can be read with
+	// other without remapping any symbol IDs.
+	Compatible(other SymbolTable) bool
+	// Delta returns the symbols this table defines beyond those of prev, in
+	// symbol ID order, with "" for any ID that has no known text. These are the
+	// symbols a local symbol table appended to prev must add to produce this
+	// table. It returns an error if this table is not an append-extension of
+	// prev, as reported by Compatible. A nil prev is the system symbol table.
+	Delta(prev SymbolTable) ([]string, error)
+	// WriteTo serializes the symbol table to an ion.Writer, including any
+	// annotations a local symbol table carries after $ion_symbol_table. (A
+	// binary writer created with NewBinaryWriterLST writes only those that
+	// are system symbols when it writes the table.)
 	WriteTo(w Writer) error
 	// String returns an ion text representation of the symbol table.
 	String() string
@@ -155,6 +172,14 @@ func (s *sst) FindByID(id uint64) (string, bool) {
 	return s.symbols[id-1], true
 }
 
+func (s *sst) Compatible(other SymbolTable) bool {
+	return compatible(s, other)
+}
+
+func (s *sst) Delta(prev SymbolTable) ([]string, error) {
+	return delta(s, prev)
+}
+
 func (s *sst) WriteTo(w Writer) error {
 	ionSharedSymbolTableText := "$ion_shared_symbol_table"
 	if err := w.Annotation(SymbolToken{Text: &ionSharedSymbolTableText, LocalSID: 9}); err != nil {
@@ -283,6 +308,14 @@ func (s *bogusSST) FindByID(id uint64) (string, bool) {
 	return "", false
 }
 
+func (s *bogusSST) Compatible(other SymbolTable) bool {
+	return compatible(s, other)
+}
+
+func (s *bogusSST) Delta(prev SymbolTable) ([]string, error) {
+	return delta(s, prev)
+}
+
 func (s *bogusSST) WriteTo(w Writer) error {
 	return &UsageError{"SharedSymbolTable.WriteTo", "bogus symbol table should never be written"}
 }
@@ -321,23 +354,50 @@ type lst struct {
 
 	symbols []string
 	index   map[string]uint64
+
+	// Unknown holds the IDs of the symbols, given as "" in symbols, that have
+	// unknown text; they're written as nulls to keep later IDs in place.
+	unknown map[uint64]bool
+
+	// Annotations holds any annotations the table's struct carries after
+	// $ion_symbol_table, which are written back out after it; a binary
+	// writer drops any that aren't system symbols.
+	annotations []string
 }
 
 // NewLocalSymbolTable creates a new local symbol table.
 func NewLocalSymbolTable(imports []SharedSymbolTable, symbols []string) SymbolTable {
+	return newLocalSymbolTable(imports, symbols, nil)
+}
+
+// NewLocalSymbolTable creates a new local symbol table in which the symbols
+// at the given indexes have unknown text.
+func newLocalSymbolTable(imports []SharedSymbolTable, symbols []string, unknown []int) *lst {
 	imps, offsets, maxID := processImports(imports)
 	syms := make([]string, len(symbols))
 	copy(syms, symbols)
 
 	index := buildIndex(syms, maxID+1)
 
-	return &lst{
+	t := &lst{
 		imports:     imps,
 		offsets:     offsets,
 		maxImportID: maxID,
 		symbols:     syms,
 		index:       index,
 	}
+	for _, i := range unknown {
+		t.markUnknown(maxID + 1 + uint64(i))
+	}
+	return t
+}
+
+// MarkUnknown records that the symbol with the given ID has unknown text.
+func (t *lst) markUnknown(id uint64) {
+	if t.unknown == nil {
+		t.unknown = make(map[uint64]bool)
+	}
+	t.unknown[id] = true
 }
 
 func (t *lst) Imports() []SharedSymbolTable {
@@ -397,13 +457,21 @@ func (t *lst) FindByID(id uint64) (string, bool) {
 
 	// Local to this symbol table.
 	idx := id - t.maxImportID - 1
-	if idx < uint64(len(t.symbols)) {
+	if idx < uint64(len(t.symbols)) && !t.unknown[id] {
 		return t.symbols[idx], true
 	}
 
 	return "", false
 }
 
+func (t *lst) Compatible(other SymbolTable) bool {
+	return compatible(t, other)
+}
+
+func (t *lst) Delta(prev SymbolTable) ([]string, error) {
+	return delta(t, prev)
+}
+
 func (t *lst) findByIDInImports(id uint64) (string, bool) {
 	i := 1
 	off := uint64(0)
@@ -427,6 +495,11 @@ func (t *lst) WriteTo(w Writer) error {
 	if err := w.Annotation(SymbolToken{Text: &ionSymbolTableText, LocalSID: 3}); err != nil {
 		return err
 	}
+	for _, a := range t.annotations {
+		if err := w.Annotation(NewSymbolTokenFromString(a)); err != nil {
+			return err
+		}
+	}
 	if err := w.BeginStruct(); err != nil {
 		return err
 	}
@@ -502,8 +575,14 @@ func (t *lst) WriteTo(w Writer) error {
 		if err := w.BeginList(); err != nil {
 			return err
 		}
-		for _, sym := range t.symbols {
-			if err := w.WriteString(sym); err != nil {
+		for i, sym := range t.symbols {
+			var err error
+			if t.unknown[t.maxImportID+1+uint64(i)] {
+				err = w.WriteNull()
+			} else {
+				err = w.WriteString(sym)
+			}
+			if err != nil {
 				return err
 			}
 		}
@@ -528,9 +607,16 @@ func (t *lst) String() string {
 type SymbolTableBuilder interface {
 	SymbolTable
 
-	// Add adds a symbol to this symbol table.
+	// Add adds a symbol to this symbol table, returning its symbol ID and true,
+	// or the ID it already has and false if the table already holds it.
 	Add(symbol string) (uint64, bool)
-	// Build creates an immutable local symbol table.
+	// AddUnknown reserves the next symbol ID for a symbol with unknown text,
+	// returning the ID. The table writes the symbol as null, so that the IDs
+	// of the symbols after it match those of a table, such as one read from a
+	// source document, that has a gap there.
+	AddUnknown() uint64
+	// Build creates an immutable local symbol table holding the symbols added
+	// so far. Symbols added afterwards do not change it.
 	Build() SymbolTable
 }
 
@@ -563,6 +649,14 @@ func (b *symbolTableBuilder) Add(symbol string) (uint64, bool) {
 	return id, true
 }
 
+func (b *symbolTableBuilder) AddUnknown() uint64 {
+	b.symbols = append(b.symbols, "")
+	id := b.maxImportID + uint64(len(b.symbols))
+	b.markUnknown(id)
+
+	return id
+}
+
 func (b *symbolTableBuilder) Build() SymbolTable {
 	symbols := append([]string{}, b.symbols...)
 	index := make(map[string]uint64)
@@ -570,13 +664,17 @@ func (b *symbolTableBuilder) Build() SymbolTable {
 		index[s] = i
 	}
 
-	return &lst{
+	t := &lst{
 		imports:     b.imports,
 		offsets:     b.offsets,
 		maxImportID: b.maxImportID,
 		symbols:     symbols,
 		index:       index,
 	}
+	for id := range b.unknown {
+		t.markUnknown(id)
+	}
+	return t
 }
 
 // ProcessImports processes a slice of imports, returning an (augmented) copy, a set of
@@ -604,6 +702,48 @@ func processImports(imports []SharedSymbolTable) ([]SharedSymbolTable, []uint64,
 	return imps, offsets, maxID
 }
 
+// Compatible returns true if every symbol ID defined by t maps to the same text
+// (or lack thereof) in other.
+func compatible(t, other SymbolTable) bool {
+	if other == nil {
+		return false
+	}
+
+	maxID := t.MaxID()
+	if other.MaxID() < maxID {
+		return false
+	}
+
+	for id := uint64(1); id <= maxID; id++ {
+		text, ok := t.FindByID(id)
+		otherText, otherOK := other.FindByID(id)
+		if ok != otherOK || text != otherText {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Delta returns the text of the symbol IDs t defines beyond those defined by
+// prev, which t must be compatible with.
+func delta(t, prev SymbolTable) ([]string, error) {
+	if prev == nil {
+		prev = V1SystemSymbolTable
+	}
+	if !compatible(prev, t) {
+		return nil, &UsageError{"SymbolTable.Delta", "symbol table is not an append-extension of the previous one"}
+	}
+
+	maxID := t.MaxID()
+	syms := make([]string, 0, maxID-prev.MaxID())
+	for id := prev.MaxID() + 1; id <= maxID; id++ {
+		text, _ := t.FindByID(id)
+		syms = append(syms, text)
+	}
+	return syms, nil
+}
+
 // BuildIndex builds an index from symbol name to symbol ID.
 func buildIndex(symbols []string, offset uint64) map[string]uint64 {
 	index := make(map[string]uint64)
@@ -618,3 +758,81 @@ func buildIndex(symbols []string, offset uint64) map[string]uint64 {
 
 	return index
 }
+
+// CollectSymbols reads all of the given Ion data, text or binary, and returns
+// the distinct text of every symbol it uses as a field name, annotation, or
+// symbol value, most frequently used first, and in lexical order where
+// frequencies tie. Symbols with unknown text, and the system symbols, which
+// every stream has already, are left out. The result is suited to building a
+// shared symbol table with NewSharedSymbolTable, giving the most-used symbols
+// the smallest IDs.
+func CollectSymbols(in io.Reader) ([]string, error) {
+	counts := map[string]int{}
+	count := func(st *SymbolToken) {
+		if st == nil || st.Text == nil {
+			return
+		}
+		if _, ok := V1SystemSymbolTable.FindByName(*st.Text); !ok {
+			counts[*st.Text]++
+		}
+	}
+
+	r := NewReader(in)
+	var walk func() error
+	walk = func() error {
+		for r.Next() {
+			fn, err := r.FieldName()
+			if err != nil {
+				return err
+			}
+			count(fn)
+
+			as, err := r.Annotations()
+			if err != nil {
+				return err
+			}
+			for i := range as {
+				count(&as[i])
+			}
+
+			if r.IsNull() {
+				continue
+			}
+			switch r.Type() {
+			case SymbolType:
+				sym, err := r.SymbolValue()
+				if err != nil {
+					return err
+				}
+				count(sym)
+
+			case ListType, SexpType, StructType:
+				if err := r.StepIn(); err != nil {
+					return err
+				}
+				if err := walk(); err != nil {
+					return err
+				}
+				if err := r.StepOut(); err != nil {
+					return err
+				}
+			}
+		}
+		return r.Err()
+	}
+	if err := walk(); err != nil {
+		return nil, err
+	}
+
+	syms := make([]string, 0, len(counts))
+	for sym := range counts {
+		syms = append(syms, sym)
+	}
+	sort.Slice(syms, func(i, j int) bool {
+		if counts[syms[i]] != counts[syms[j]] {
+			return counts[syms[i]] > counts[syms[j]]
+		}
+		return syms[i] < syms[j]
+	})
+	return syms, nil
+}
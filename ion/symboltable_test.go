@@ -16,7 +16,9 @@
 package ion
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -139,6 +141,258 @@ func TestSymbolTableBuilder(t *testing.T) {
 	testFindByID(t, st, 1, "$ion")
 	testFindByID(t, st, 10, "foo")
 	testFindByID(t, st, 11, "")
+
+	// The built table is frozen; the builder carries on independently.
+	id, ok = b.Add("bar")
+	assert.True(t, ok, "Add(bar) returned false")
+	assert.Equal(t, 11, int(id), "Add(bar) returned %v", id)
+	assert.Equal(t, 10, int(st.MaxID()), "maxid returned %v", st.MaxID())
+	testFindByName(t, st, "bar", 0)
+	testFindByName(t, b, "bar", 11)
+
+	// And can seed a writer, whose output uses its symbol IDs.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterLST(&buf, st)
+	require.NoError(t, w.WriteSymbolFromString("foo"))
+	require.NoError(t, w.Finish())
+
+	r := NewReaderBytes(buf.Bytes())
+	require.True(t, r.Next())
+	sym, err := r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), sym.LocalSID)
+	assert.Equal(t, "foo", *sym.Text)
+}
+
+func TestSymbolTableBuilderUnknown(t *testing.T) {
+	b := NewSymbolTableBuilder()
+	b.Add("a")
+	assert.Equal(t, 11, int(b.AddUnknown()))
+	id, ok := b.Add("b")
+	assert.True(t, ok)
+	assert.Equal(t, 12, int(id))
+
+	st := b.Build()
+	assert.Equal(t, 12, int(st.MaxID()))
+	assert.Equal(t, []string{"a", "", "b"}, st.Symbols())
+
+	testFindByID(t, st, 10, "a")
+	testFindByID(t, st, 11, "")
+	testFindByID(t, st, 12, "b")
+	testFindByName(t, st, "", 0)
+	testString(t, st, `$ion_symbol_table::{symbols:["a",null,"b"]}`)
+
+	// Gaps survive the built table being frozen.
+	b.AddUnknown()
+	testFindByID(t, st, 11, "")
+	assert.Equal(t, 12, int(st.MaxID()))
+}
+
+func TestLocalSymbolTableUnknownRoundTrip(t *testing.T) {
+	// SIDs 10-12 come from a shared table that isn't available, and 14 and 16
+	// are local symbols with no text.
+	in := `$ion_symbol_table::{imports:[{name:"missing", version:1, max_id:3}], symbols:["a", null, "b", 5]} $11 $13 $14 $15 $16 a`
+
+	r := NewReaderString(in)
+	require.True(t, r.Next())
+	st := r.SymbolTable()
+	assert.Equal(t, 16, int(st.MaxID()))
+	testFindByID(t, st, 13, "a")
+	testFindByID(t, st, 14, "")
+	testFindByID(t, st, 15, "b")
+	testFindByID(t, st, 16, "")
+
+	// Copy the values to binary with the same symbol table, so that the SIDs
+	// are written as they are.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterLST(&buf, st)
+	for {
+		sym, err := r.SymbolValue()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteSymbol(*sym))
+		if !r.Next() {
+			break
+		}
+	}
+	require.NoError(t, r.Err())
+	require.NoError(t, w.Finish())
+
+	check := func(r Reader) {
+		var sids []int64
+		var texts []string
+		for r.Next() {
+			sym, err := r.SymbolValue()
+			require.NoError(t, err)
+			sids = append(sids, sym.LocalSID)
+			if sym.Text == nil {
+				texts = append(texts, "?")
+			} else {
+				texts = append(texts, *sym.Text)
+			}
+		}
+		require.NoError(t, r.Err())
+		assert.Equal(t, []int64{11, 13, 14, 15, 16, 13}, sids)
+		assert.Equal(t, []string{"?", "a", "?", "b", "?", "a"}, texts)
+	}
+	check(NewReaderBytes(buf.Bytes()))
+
+	// And the table itself writes back out with its gaps in place.
+	text := strings.Builder{}
+	tw := NewTextWriter(&text)
+	require.NoError(t, st.WriteTo(tw))
+	require.NoError(t, tw.Finish())
+	assert.Equal(t, `$ion_symbol_table::{imports:[{name:"missing",version:1,max_id:3}],symbols:["a",null,"b",null]}`+"\n", text.String())
+	check(NewReaderString(text.String() + " $11 $13 $14 $15 $16 a"))
+}
+
+func TestLocalSymbolTableWithAnnotations(t *testing.T) {
+	readSymbols := func(r Reader) []string {
+		var syms []string
+		for r.Next() {
+			as, err := r.Annotations()
+			require.NoError(t, err)
+			require.Empty(t, as)
+			sym, err := r.SymbolValue()
+			require.NoError(t, err)
+			syms = append(syms, *sym.Text)
+		}
+		require.NoError(t, r.Err())
+		return syms
+	}
+
+	// Annotations after $ion_symbol_table don't stop the struct being a
+	// symbol table, and the table keeps them.
+	r := NewReaderString(`$ion_symbol_table::name::{symbols:["a"]} $10`)
+	assert.Equal(t, []string{"a"}, readSymbols(r))
+	st := r.SymbolTable()
+	assert.Equal(t, `$ion_symbol_table::name::{symbols:["a"]}`, fmt.Sprint(st))
+
+	// They're written back out with the table, in binary too.
+	buf := bytes.Buffer{}
+	w := NewBinaryWriterLST(&buf, st)
+	require.NoError(t, w.WriteSymbolFromString("a"))
+	require.NoError(t, w.Finish())
+	r = NewReaderBytes(buf.Bytes())
+	assert.Equal(t, []string{"a"}, readSymbols(r))
+	assert.Equal(t, fmt.Sprint(st), fmt.Sprint(r.SymbolTable()))
+
+	// But in binary they can only be system symbols, since they're read before
+	// the table takes effect; others are dropped, leaving the table as is.
+	r = NewReaderString(`$ion_symbol_table::foo::name::{symbols:["a"]} $10`)
+	assert.Equal(t, []string{"a"}, readSymbols(r))
+	st = r.SymbolTable()
+	buf.Reset()
+	w = NewBinaryWriterLST(&buf, st)
+	require.NoError(t, w.WriteSymbolFromString("a"))
+	require.NoError(t, w.Finish())
+	r = NewReaderBytes(buf.Bytes())
+	assert.Equal(t, []string{"a"}, readSymbols(r))
+	assert.Equal(t, `$ion_symbol_table::name::{symbols:["a"]}`, fmt.Sprint(r.SymbolTable()))
+	assert.Equal(t, `$ion_symbol_table::foo::name::{symbols:["a"]}`, fmt.Sprint(st))
+
+	// A text writer keeps them all.
+	text := strings.Builder{}
+	tw := NewTextWriterOpts(&text, TextWriterQuietFinish)
+	require.NoError(t, st.WriteTo(tw))
+	require.NoError(t, tw.WriteSymbolFromString("$10"))
+	require.NoError(t, tw.Finish())
+	r = NewReaderString(text.String())
+	assert.Equal(t, []string{"a"}, readSymbols(r))
+	assert.Equal(t, fmt.Sprint(st), fmt.Sprint(r.SymbolTable()))
+
+	// A struct whose first annotation isn't $ion_symbol_table is a value.
+	r = NewReaderString(`foo::$ion_symbol_table::{symbols:["a"]}`)
+	require.True(t, r.Next())
+	assert.Equal(t, StructType, r.Type())
+	as, err := r.Annotations()
+	require.NoError(t, err)
+	require.Len(t, as, 2)
+	assert.Equal(t, "foo", *as[0].Text)
+}
+
+func TestSymbolTableCompatible(t *testing.T) {
+	shared := NewSharedSymbolTable("shared", 1, []string{"a", "b"})
+
+	base := NewLocalSymbolTable(nil, []string{"foo", "bar"})
+	same := NewLocalSymbolTable(nil, []string{"foo", "bar"})
+	extended := NewLocalSymbolTable(nil, []string{"foo", "bar", "baz"})
+	reordered := NewLocalSymbolTable(nil, []string{"bar", "foo"})
+	shorter := NewLocalSymbolTable(nil, []string{"foo"})
+	imported := NewLocalSymbolTable([]SharedSymbolTable{shared}, []string{"foo", "bar"})
+
+	assert.True(t, base.Compatible(base))
+	assert.True(t, base.Compatible(same))
+	assert.True(t, base.Compatible(extended))
+	assert.False(t, extended.Compatible(base))
+	assert.False(t, base.Compatible(reordered))
+	assert.False(t, base.Compatible(shorter))
+	assert.True(t, shorter.Compatible(base))
+	assert.False(t, base.Compatible(imported))
+	assert.False(t, base.Compatible(nil))
+
+	assert.True(t, V1SystemSymbolTable.Compatible(base))
+	assert.True(t, V1SystemSymbolTable.Compatible(imported))
+	assert.False(t, shared.Compatible(base))
+
+	b := NewSymbolTableBuilder(shared)
+	b.Add("foo")
+	assert.True(t, b.Compatible(imported))
+	assert.True(t, b.Build().Compatible(imported))
+	b.Add("baz")
+	assert.False(t, b.Compatible(imported))
+}
+
+func TestSymbolTableDelta(t *testing.T) {
+	shared := NewSharedSymbolTable("shared", 1, []string{"a", "b"})
+
+	base := NewLocalSymbolTable(nil, []string{"foo", "bar"})
+	extended := NewLocalSymbolTable(nil, []string{"foo", "bar", "baz", "", "qux"})
+	reordered := NewLocalSymbolTable(nil, []string{"bar", "foo"})
+	imported := NewLocalSymbolTable([]SharedSymbolTable{shared}, []string{"foo"})
+
+	d, err := extended.Delta(base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"baz", "", "qux"}, d)
+
+	d, err = base.Delta(base)
+	require.NoError(t, err)
+	assert.Empty(t, d)
+
+	d, err = base.Delta(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, d)
+
+	d, err = imported.Delta(V1SystemSymbolTable)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "foo"}, d)
+
+	_, err = reordered.Delta(base)
+	assert.Error(t, err)
+	_, err = base.Delta(extended)
+	assert.Error(t, err)
+	_, err = base.Delta(imported)
+	assert.Error(t, err)
+
+	// Appending the delta to the previous table reproduces the new one.
+	d, err = extended.Delta(base)
+	require.NoError(t, err)
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	require.NoError(t, base.WriteTo(w))
+	require.NoError(t, w.Annotation(NewSymbolTokenFromString("$ion_symbol_table")))
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("imports")))
+	require.NoError(t, w.WriteSymbolFromString("$ion_symbol_table"))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("symbols")))
+	require.NoError(t, w.WriteValue(d))
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.WriteInt(1))
+	require.NoError(t, w.Finish())
+
+	r := NewReaderString(buf.String())
+	require.True(t, r.Next())
+	assert.True(t, extended.Compatible(r.SymbolTable()))
+	assert.True(t, r.SymbolTable().Compatible(extended))
 }
 
 func testFindByName(t *testing.T, st SymbolTable, sym string, expected uint64) {
@@ -184,3 +438,78 @@ func testString(t *testing.T, st SymbolTable, expected string) {
 func newString(value string) *string {
 	return &value
 }
+
+func TestPeekSymbolTable(t *testing.T) {
+	test := func(name string, in []byte, cat Catalog, eval string) {
+		t.Run(name, func(t *testing.T) {
+			st, err := PeekSymbolTableCat(bytes.NewReader(in), cat)
+			require.NoError(t, err)
+			assert.Equal(t, eval, st.String())
+		})
+	}
+
+	system := V1SystemSymbolTable.String()
+
+	test("empty", nil, nil, system)
+	test("text no lst", []byte("a b c"), nil, system)
+	test("text ivm only", []byte("$ion_1_0"), nil, system)
+	test("text", []byte(`$ion_1_0 $ion_symbol_table::{symbols:["a","b"]} $10 $11`), nil,
+		`$ion_symbol_table::{symbols:["a","b"]}`)
+	test("text lst only", []byte(`$ion_symbol_table::{symbols:["a"]}`), nil,
+		`$ion_symbol_table::{symbols:["a"]}`)
+
+	bin, err := MarshalBinary(map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+	test("binary", bin, nil, `$ion_symbol_table::{symbols:["foo"]}`)
+	test("binary ivm only", []byte{0xE0, 0x01, 0x00, 0xEA}, nil, system)
+
+	shared := NewSharedSymbolTable("shared", 1, []string{"foo", "bar"})
+	buf := bytes.Buffer{}
+	w := NewBinaryWriter(&buf, shared)
+	require.NoError(t, w.WriteSymbolFromString("baz"))
+	require.NoError(t, w.Finish())
+
+	st, err := PeekSymbolTableCat(bytes.NewReader(buf.Bytes()), NewCatalog(shared))
+	require.NoError(t, err)
+	testFindByName(t, st, "bar", 11)
+	testFindByName(t, st, "baz", 12)
+
+	// Symbol tables appended to earlier ones are combined.
+	st, err = PeekSymbolTable(strings.NewReader(`$ion_symbol_table::{symbols:["a"]} $ion_symbol_table::{imports:$ion_symbol_table, symbols:["b"]} 1`))
+	require.NoError(t, err)
+	testFindByName(t, st, "a", 10)
+	testFindByName(t, st, "b", 11)
+
+	_, err = PeekSymbolTable(strings.NewReader("$ion_symbol_table::{symbols:[\"a\""))
+	assert.Error(t, err)
+}
+
+func TestCollectSymbols(t *testing.T) {
+	text := `a::{b: c, d: [c, c, c, 'e'], b: "not a symbol", name: $ion, $0: f}
+             (a b c) a::g::null.symbol h::1 {b: null}`
+	// c is used 5 times, b 4, a 3, and the rest once each.
+	expected := []string{"c", "b", "a", "d", "e", "f", "g", "h"}
+
+	syms, err := CollectSymbols(strings.NewReader(text))
+	require.NoError(t, err)
+	assert.Equal(t, expected, syms)
+
+	// The same from binary, where the symbols are already in a symbol table.
+	bin := bytes.Buffer{}
+	w := NewBinaryWriter(&bin)
+	r := NewReaderString(text)
+	writeFromReaderToWriter(t, r, w)
+	require.NoError(t, w.Finish())
+
+	syms, err = CollectSymbols(&bin)
+	require.NoError(t, err)
+	assert.Equal(t, expected, syms)
+
+	// Which makes a shared symbol table with the most common symbols first.
+	sst := NewSharedSymbolTable("collected", 1, syms)
+	testFindByName(t, sst, "c", 1)
+	testFindByName(t, sst, "h", 8)
+
+	_, err = CollectSymbols(strings.NewReader("{a: b"))
+	assert.Error(t, err)
+}
@@ -112,10 +112,11 @@ func newSymbolTokenPtrFromString(text string) *SymbolToken {
 }
 
 // NewSymbolTokenBySID will check and return a symbol token if the given id exists in a symbol table,
-// otherwise return a new symbol token.
+// otherwise return a new symbol token. It returns a SymbolIDOutOfRangeError if the id is greater
+// than the symbol table's max ID.
 func NewSymbolTokenBySID(symbolTable SymbolTable, sid int64) (SymbolToken, error) {
 	if sid < 0 || uint64(sid) > symbolTable.MaxID() {
-		return SymbolToken{}, fmt.Errorf("ion: Symbol token not found for SID '%v' in symbol table %v", sid, symbolTable)
+		return SymbolToken{}, &SymbolIDOutOfRangeError{sid, symbolTable.MaxID()}
 	}
 
 	text, ok := symbolTable.FindByID(uint64(sid))
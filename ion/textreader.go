@@ -17,8 +17,10 @@ package ion
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"math"
 	"strconv"
 )
@@ -58,9 +60,22 @@ type textReader struct {
 	tok   tokenizer
 	state trs
 	cat   Catalog
+
+	// Trivia-keeping state; see NewTriviaReader.
+	keepTrivia bool
+	trivia     string
+	started    bool
+
+	// PeekedTrivia is the trivia before the value PeekType read ahead to.
+	peekedTrivia string
+
+	// If keepRaw is set, the tokenizer keeps a tape for rawValue, and
+	// rawStart is the offset in it at which the current value starts.
+	keepRawValues bool
+	rawStart      int
 }
 
-func newTextReaderBuf(in *bufio.Reader, cat Catalog) Reader {
+func newTextReaderBuf(in *bufio.Reader, cat Catalog, interner Interner) Reader {
 	tr := textReader{
 		cat: cat,
 		tok: tokenizer{
@@ -69,12 +84,58 @@ func newTextReaderBuf(in *bufio.Reader, cat Catalog) Reader {
 		state: trsBeforeTypeAnnotations,
 	}
 	tr.lst = V1SystemSymbolTable
+	tr.interner = interner
 
 	return &tr
 }
 
+// WithAssumedVersion fails; a text reader cannot read binary Ion.
+func (t *textReader) WithAssumedVersion(major, minor int) Reader {
+	if t.err == nil {
+		t.err = &UsageError{"Reader.WithAssumedVersion", "cannot read text Ion as binary"}
+	}
+	return t
+}
+
+// Reset discards the reader's state and starts reading from in.
+func (t *textReader) Reset(in io.Reader) {
+	t.resetBuf(resetBuf(t.tok.in, in))
+}
+
+// ResetBuf discards the reader's state and starts reading from br.
+func (t *textReader) resetBuf(br *bufio.Reader) {
+	t.reader.reset()
+	t.lst = V1SystemSymbolTable
+
+	t.tok = tokenizer{
+		in:           br,
+		buffer:       t.tok.buffer[:0],
+		keepTape:     t.tok.keepTape,
+		tape:         t.tok.tape[:0],
+		stopAtBinary: t.tok.stopAtBinary,
+	}
+	t.state = trsBeforeTypeAnnotations
+	t.trivia = ""
+	t.started = false
+
+	if isBinary(br) {
+		t.err = &UsageError{"Reader.Reset", "input is binary Ion"}
+		t.state = trsDone
+	}
+}
+
+// ResetBytes discards the reader's state and starts reading from in.
+func (t *textReader) ResetBytes(in []byte) {
+	t.inBytes.Reset(in)
+	t.Reset(&t.inBytes)
+}
+
 // Next moves the reader to the next value.
 func (t *textReader) Next() bool {
+	if t.peeked != nil && t.err == nil {
+		t.trivia = t.peekedTrivia
+		return t.nextPeeked()
+	}
 	if t.state == trsDone || t.eof {
 		return false
 	}
@@ -87,6 +148,13 @@ func (t *textReader) Next() bool {
 	}
 
 	t.clear()
+	if t.keepRawValues && !t.keepTrivia {
+		t.tok.tape = t.tok.tape[:0]
+	}
+
+	topLevel := t.keepTrivia && t.ctx.peek() == ctxAtTopLevel
+	first := true
+	start := 0
 
 	// Loop until we've consumed enough tokens to know what the next value is.
 	for {
@@ -95,6 +163,11 @@ func (t *textReader) Next() bool {
 			return false
 		}
 
+		if topLevel && first {
+			start = t.tok.tokStart
+			first = false
+		}
+
 		var done bool
 		var err error
 
@@ -114,11 +187,98 @@ func (t *textReader) Next() bool {
 		}
 
 		if done {
+			if topLevel {
+				t.onTrivia(start)
+			}
+
 			// We're done reading tokens. If we hit the end of the current sequence,
 			// return false. Otherwise, we've got a value for the caller.
 			return !t.eof
 		}
+
+		if topLevel && len(t.annotations) == 0 {
+			// That was a system value (a version marker or local symbol
+			// table); the next value starts with the next token.
+			t.dropSystemValue(start)
+			first = true
+		}
+	}
+}
+
+// BytesConsumed returns the number of bytes of input consumed so far, not
+// counting any the tokenizer has peeked at.
+func (t *textReader) BytesConsumed() int64 {
+	return int64(t.tok.offset())
+}
+
+// PeekType returns the type of the next value without moving to it.
+func (t *textReader) PeekType() (Type, error) {
+	if t.peeked != nil {
+		return t.peekType(t.Next)
+	}
+
+	trivia := t.trivia
+	typ, err := t.peekType(t.Next)
+	t.peekedTrivia, t.trivia = t.trivia, trivia
+	return typ, err
+}
+
+// SwitchInput returns the rest of the input if the reader stopped at a binary
+// version marker, and nil otherwise.
+func (t *textReader) switchInput() *bufio.Reader {
+	if !t.tok.atBinary || t.err != nil || t.ctx.peek() != ctxAtTopLevel {
+		return nil
+	}
+
+	// The start of the marker has already been read into the tokenizer's
+	// buffer; put it back in front of the rest.
+	return bufio.NewReader(io.MultiReader(bytes.NewReader(t.tok.pending()), t.tok.in))
+}
+
+// Resume clears the end-of-input state at the top level so that reading can
+// continue after more input has been made available.
+func (t *textReader) resume() {
+	if t.state != trsDone && t.ctx.peek() == ctxAtTopLevel {
+		t.eof = false
+		t.tok.resume()
+		if t.peeked != nil && t.peeked.eof {
+			// Peeking found nothing more, but there may be now.
+			t.peeked = nil
+		}
+	}
+}
+
+// KeepRaw starts or stops keeping the input that rawValue needs.
+func (t *textReader) keepRaw(keep bool) {
+	t.keepRawValues = keep
+	t.tok.keepTape = keep || t.keepTrivia
+	if !t.tok.keepTape {
+		t.tok.tape = t.tok.tape[:0]
 	}
+	t.rawStart = len(t.tok.tape)
+}
+
+// RawValue returns the current value as it appeared in the input, apart from
+// line endings, reading the rest of it if it hasn't been already.
+func (t *textReader) rawValue() ([]byte, bool, error) {
+	if t.err != nil {
+		return nil, false, t.err
+	}
+	if t.valueType == NoType || !t.keepRawValues {
+		return nil, false, fmt.Errorf("ion: the current value was not kept raw")
+	}
+
+	if err := t.finishValue(); err != nil {
+		t.explode(err)
+		return nil, false, err
+	}
+	raw := t.tok.tape[t.rawStart:]
+	if t.valueType == StringType && t.tok.longEnd > t.rawStart {
+		// Leave out any comments the tokenizer read past, looking for
+		// another long string to join on.
+		raw = t.tok.tape[t.rawStart:t.tok.longEnd]
+	}
+	return bytes.TrimRight(raw, " \t\n"), false, nil
 }
 
 // NextAfterValue moves to the next value when we're in the
@@ -171,25 +331,28 @@ func (t *textReader) nextBeforeFieldName() (bool, error) {
 		return true, nil
 
 	case tokenSymbol, tokenSymbolQuoted, tokenString, tokenLongString:
-		// Read the field name.
-		val, err := t.tok.ReadValue(tok)
+		// Read the field name, leaving FieldName to make a symbol token of it
+		// unless it's a symbol ID, which has to be checked now.
+		name, err := t.tok.ReadFieldName(tok)
 		if err != nil {
 			return false, err
 		}
-		if tok == tokenSymbol {
-			if err := t.verifyUnquotedSymbol(val, "field name"); err != nil {
-				return false, err
-			}
+		if tok == tokenSymbol && isKeyword(name) {
+			return false, t.verifyUnquotedSymbol(string(name), "field name")
 		}
 
-		if tok == tokenSymbolQuoted {
-			t.fieldName = &SymbolToken{Text: &val, LocalSID: SymbolIDUnknown}
-		} else {
-			st, err := newSymbolToken(t.SymbolTable(), val)
-			if err != nil {
-				return false, err
+		t.fieldText = name
+		t.textField = true
+		t.quotedField = tok == tokenSymbolQuoted
+		if !t.quotedField && len(name) > 1 && name[0] == '$' {
+			if sid, ok := symbolIdentifier(string(name)); ok {
+				st, err := NewSymbolTokenBySID(t.SymbolTable(), sid)
+				if err != nil {
+					return false, err
+				}
+				t.fieldName = &st
+				t.textField = false
 			}
-			t.fieldName = &st
 		}
 
 		// Skip over the following colon.
@@ -213,6 +376,11 @@ func (t *textReader) nextBeforeFieldName() (bool, error) {
 // BeforeTypeAnnotations state.
 func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 	tok := t.tok.Token()
+	if len(t.annotations) == 0 {
+		// The value starts at its first annotation, if it has any.
+		t.start = int64(t.tok.tokOffset)
+		t.rawStart = t.tok.tokStart
+	}
 	switch tok {
 	case tokenEOF:
 		if t.ctx.peek() == ctxAtTopLevel {
@@ -233,6 +401,7 @@ func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 		if err != nil {
 			return false, err
 		}
+		val = intern(t.interner, val)
 
 		ok, ws, err := t.tok.SkipDoubleColon()
 		if err != nil {
@@ -268,6 +437,11 @@ func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 			t.value = &SymbolToken{Text: &val, LocalSID: SymbolIDUnknown}
 			t.valueType = SymbolType
 			t.state = t.stateAfterValue()
+		} else if tok == tokenSymbol && val == "$ion_1_0" && len(t.annotations) == 0 && t.ctx.peek() == ctxAtTopLevel {
+			// A version marker; reset the symbol table and keep going.
+			t.setSymbolTable(V1SystemSymbolTable)
+			t.state = t.stateAfterValue()
+			return false, nil
 		} else {
 			if err := t.onSymbol(val, tok, ws); err != nil {
 				return false, err
@@ -280,6 +454,9 @@ func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 		if err != nil {
 			return false, err
 		}
+		if err := t.checkValueSize(StringType, len(val)); err != nil {
+			return false, err
+		}
 
 		t.state = t.stateAfterValue()
 		t.valueType = StringType
@@ -313,13 +490,13 @@ func (t *textReader) nextBeforeTypeAnnotations() (bool, error) {
 		if ctx == ctxAtTopLevel && isIonSymbolTable(t.annotations) {
 			if t.IsNull() {
 				t.clear()
-				t.lst = V1SystemSymbolTable
+				t.setSymbolTable(V1SystemSymbolTable)
 				return false, nil
 			}
 
-			st, err := readLocalSymbolTable(t, t.cat)
+			st, err := readLocalSymbolTable(t, t.cat, t.interner)
 			if err == nil {
-				t.lst = st
+				t.setSymbolTable(st)
 				return false, nil
 			}
 			return false, err
@@ -365,7 +542,8 @@ func (t *textReader) StepIn() error {
 	if t.err != nil {
 		return t.err
 	}
-	if t.state != trsBeforeContainer {
+	if t.state != trsBeforeContainer || t.peeked != nil {
+		// If PeekType has read ahead, the state is that of the peeked value.
 		return &UsageError{"Reader.StepIn", fmt.Sprintf("cannot step in to a %v", t.valueType)}
 	}
 
@@ -395,6 +573,11 @@ func (t *textReader) StepOut() error {
 	}
 	ctype := ctxToContainerType(ctx)
 
+	if t.peeked != nil {
+		// Carry on from the value PeekType read ahead to.
+		t.nextPeeked()
+	}
+
 	// Finish off whatever value *inside* the container that we're currently reading.
 	_, err := t.tok.FinishValue()
 	if err != nil {
@@ -420,6 +603,16 @@ func (t *textReader) StepOut() error {
 
 // VerifyUnquotedSymbol checks for certain 'special' values that are returned from
 // the tokenizer as symbols but cannot be used as field names or annotations.
+// IsKeyword returns true if the given text is a keyword that can't be used as
+// an unquoted symbol.
+func isKeyword(text []byte) bool {
+	switch string(text) {
+	case "null", "true", "false", "nan":
+		return true
+	}
+	return false
+}
+
 func (t *textReader) verifyUnquotedSymbol(val string, ctx string) error {
 	switch val {
 	case "null", "true", "false", "nan":
@@ -481,6 +674,7 @@ func (t *textReader) onNull(ws bool) (Type, error) {
 			return t.readNullType()
 		}
 	}
+	t.bareNull = true
 	return NullType, nil
 }
 
@@ -545,7 +739,7 @@ func (t *textReader) onNumber(tok token) error {
 		}
 
 		valueType = IntType
-		value, err = parseInt(val, 2)
+		value, err = t.parseInt(val, 2)
 		if err != nil {
 			return err
 		}
@@ -557,7 +751,7 @@ func (t *textReader) onNumber(tok token) error {
 		}
 
 		valueType = IntType
-		value, err = parseInt(val, 16)
+		value, err = t.parseInt(val, 16)
 		if err != nil {
 			return err
 		}
@@ -572,7 +766,7 @@ func (t *textReader) onNumber(tok token) error {
 
 		switch tt {
 		case IntType:
-			value, err = parseInt(val, 10)
+			value, err = t.parseInt(val, 10)
 		case FloatType:
 			value, err = parseFloat(val)
 		case DecimalType:
@@ -604,6 +798,21 @@ func (t *textReader) onNumber(tok token) error {
 	return nil
 }
 
+// ParseInt parses the text of an int in the given radix, returning an int64
+// if it fits in one and otherwise t's intMagnitude, filled in from its digits.
+func (t *textReader) parseInt(str string, radix int) (interface{}, error) {
+	i, ok, err := parseInt(str, radix)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return i, nil
+	}
+
+	t.bigInt.mag, t.bigInt.neg = appendMagnitude(t.bigInt.mag[:0], str, radix)
+	return &t.bigInt, nil
+}
+
 // OnTimestamp handles finding a timestamp token.
 func (t *textReader) onTimestamp() error {
 	val, err := t.tok.ReadValue(tokenTimestamp)
@@ -675,6 +884,10 @@ func (t *textReader) onLob() error {
 		}
 	}
 
+	if err := t.checkValueSize(valType, len(val)); err != nil {
+		return err
+	}
+
 	t.state = t.stateAfterValue()
 	t.valueType = valType
 	t.value = val
@@ -17,6 +17,7 @@ package ion
 
 import (
 	"bytes"
+	"errors"
 	"math"
 	"math/big"
 	"testing"
@@ -114,6 +115,24 @@ func TestReadTextFieldNames(t *testing.T) {
 	_nextF(t, r, &SymbolToken{}, true, true)
 }
 
+func TestReadTextBools(t *testing.T) {
+	r := NewReaderString("false true null.bool null 'true' a::null.bool")
+
+	_bool(t, r, false)
+	_bool(t, r, true)
+	_null(t, r, BoolType)
+	_null(t, r, NullType)
+	_symbol(t, r, NewSymbolTokenFromString("true"))
+	_nullAF(t, r, BoolType, nil, []SymbolToken{NewSymbolTokenFromString("a")})
+	_eof(t, r)
+
+	r = NewReaderString("null.bool")
+	_null(t, r, BoolType)
+	val, err := r.BoolValue()
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+}
+
 func TestReadTextNullFieldName(t *testing.T) {
 	ionText := `{
 					null.symbol:1
@@ -125,6 +144,21 @@ func TestReadTextNullFieldName(t *testing.T) {
 	_nextF(t, r, &SymbolToken{}, true, true)
 }
 
+func TestReadTextFieldNameSIDs(t *testing.T) {
+	r := NewReaderString(`$ion_symbol_table::{symbols:["foo"]} {name:1, foo:2, 'foo':3, bar:4, $10:5}`)
+	require.True(t, r.Next())
+	require.NoError(t, r.StepIn())
+
+	var sids []int64
+	for r.Next() {
+		sid, err := r.FieldNameSID()
+		require.NoError(t, err)
+		sids = append(sids, sid)
+	}
+	require.NoError(t, r.Err())
+	assert.Equal(t, []int64{4, 10, SymbolIDUnknown, SymbolIDUnknown, 10}, sids)
+}
+
 func TestIgnoreValues(t *testing.T) {
 	r := NewReaderString("(skip ++ me / please) {skip: me, please: 0}\n[skip, me, please]\nfoo")
 
@@ -209,6 +243,51 @@ func TestNullStructs(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestReadTextNullForm(t *testing.T) {
+	r := NewReaderString("null null.null a::null null.int 1 [null] {a:null.null}")
+
+	test := func(et Type, form NullForm) {
+		require.True(t, r.Next())
+		assert.Equal(t, et, r.Type())
+		assert.Equal(t, form, r.NullForm())
+	}
+
+	test(NullType, BareNull)
+	test(NullType, TypedNull)
+	test(NullType, BareNull)
+	test(IntType, TypedNull)
+	test(IntType, NotNull)
+
+	test(ListType, NotNull)
+	require.NoError(t, r.StepIn())
+	test(NullType, BareNull)
+	require.NoError(t, r.StepOut())
+
+	test(StructType, NotNull)
+	require.NoError(t, r.StepIn())
+	test(NullType, TypedNull)
+	require.NoError(t, r.StepOut())
+
+	_eof(t, r)
+	assert.Equal(t, NotNull, r.NullForm())
+
+	// The two spellings read as the same value.
+	assert.Equal(t, decodeAllString(t, "null"), decodeAllString(t, "null.null"))
+}
+
+func decodeAllString(t *testing.T, str string) []interface{} {
+	var vals []interface{}
+	d := NewDecoder(NewReaderString(str))
+	for {
+		v, err := d.Decode()
+		if err == ErrNoInput {
+			return vals
+		}
+		require.NoError(t, err)
+		vals = append(vals, v)
+	}
+}
+
 func TestLists(t *testing.T) {
 	test := func(str string, f containerhandler) {
 		t.Run(str, func(t *testing.T) {
@@ -274,6 +353,56 @@ func TestClobs(t *testing.T) {
 	test("{{ \"hello world\" }}", []byte("hello world"))
 	test("{{'''hello world'''}}", []byte("hello world"))
 	test("{{'''hello'''\n'''world'''}}", []byte("helloworld"))
+
+	test(`{{"\0"}}`, []byte{0})
+	test(`{{"\x7f\xFF"}}`, []byte{0x7F, 0xFF})
+	test(`{{"\a\b\t\n\f\r\v\?\/\'\"\\"}}`, []byte("\a\b\t\n\f\r\v?/'\"\\"))
+	test("{{\"\x7f\"}}", []byte{0x7F})
+	test("{{\"a\\\nb\"}}", []byte("ab"))
+	test("{{'''a\\\nb'''}}", []byte("ab"))
+	test("{{'''a\nb'''}}", []byte("a\nb"))
+}
+
+func TestBadClobs(t *testing.T) {
+	test := func(str string) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderString(str)
+			if r.Next() {
+				if _, err := r.ByteValue(); err != nil {
+					return
+				}
+				r.Next()
+			}
+			assert.Error(t, r.Err())
+		})
+	}
+
+	// Non-ASCII text, raw or escaped.
+	test("{{\"é\"}}")
+	test("{{'''é'''}}")
+	test(`{{"\u0020"}}`)
+	test(`{{"\U00000020"}}`)
+	test(`{{'''\u0020'''}}`)
+
+	// Bad escapes and control characters.
+	test(`{{"\xZZ"}}`)
+	test(`{{"\x2"}}`)
+	test(`{{"\q"}}`)
+	test("{{\"a\nb\"}}")
+	test("{{\"\x01\"}}")
+
+	// Short clobs can't be concatenated or mixed with long ones, and
+	// long clob segments can only be separated by whitespace.
+	test(`{{"a" "b"}}`)
+	test(`{{'''a''' "b"}}`)
+	test(`{{"a" '''b'''}}`)
+	test(`{{'''a''' /*c*/ '''b'''}}`)
+	test("{{'''a''' //c\n '''b'''}}")
+
+	// Unterminated.
+	test(`{{"\x20"`)
+	test(`{{"a"}`)
+	test(`{{"a"} }`)
 }
 
 func TestBlobs(t *testing.T) {
@@ -444,6 +573,80 @@ func TestInts(t *testing.T) {
 	testBigInt("-0x1_FFFF_FFFF_FFFF_FFFF", "-0x1FFFFFFFFFFFFFFFF")
 }
 
+func TestNumberTypes(t *testing.T) {
+	test := func(str string, etype Type) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderString(str)
+			_next(t, r, etype)
+			_eof(t, r)
+
+			// Inside a container, where the number is followed by a delimiter.
+			r = NewReaderString("[" + str + ", " + str + "]")
+			require.True(t, r.Next())
+			require.NoError(t, r.StepIn())
+			_next(t, r, etype)
+			_next(t, r, etype)
+			_eof(t, r)
+		})
+	}
+
+	test("1", IntType)
+	test("-1", IntType)
+	test("-0", IntType)
+	test("1_000", IntType)
+	test("0x1F", IntType)
+	test("0B101", IntType)
+
+	test("1.", DecimalType)
+	test("-1.", DecimalType)
+	test("0.", DecimalType)
+	test("1.0", DecimalType)
+	test("1.2_3", DecimalType)
+	test("1d0", DecimalType)
+	test("1D0", DecimalType)
+	test("1d-3", DecimalType)
+	test("1D+3", DecimalType)
+	test("1.d0", DecimalType)
+	test("1.5d01", DecimalType)
+	test("-0d-1", DecimalType)
+
+	test("1e0", FloatType)
+	test("1E0", FloatType)
+	test("1e-5", FloatType)
+	test("1E+5", FloatType)
+	test("1.e0", FloatType)
+	test("1.5e3", FloatType)
+	test("-0e0", FloatType)
+	test("1e01", FloatType)
+	test("1_0e1_0", FloatType)
+
+	bad := func(str string) {
+		t.Run(str, func(t *testing.T) {
+			r := NewReaderString(str)
+			assert.False(t, r.Next())
+			require.Error(t, r.Err())
+			assert.Contains(t, r.Err().Error(), "ion: ")
+		})
+	}
+
+	bad("1e")
+	bad("1E+")
+	bad("1d")
+	bad("1.5d-")
+	bad("1e x")
+	bad("1ee1")
+	bad("1e1e1")
+	bad("1d1d1")
+	bad("1.2.3")
+	bad("01")
+	bad("-01.")
+	bad("0x")
+	bad("-0b")
+	bad("0xg")
+	bad("1_")
+	bad("1._5")
+}
+
 func TestStrings(t *testing.T) {
 	r := NewReaderString(`foo::"bar" "baz" 'a'::'b'::'''beep''' '''boop''' null.string`)
 
@@ -466,6 +669,41 @@ func TestSymbols(t *testing.T) {
 	_eof(t, r)
 }
 
+func TestEscapedSymbolsAndStrings(t *testing.T) {
+	r := NewReaderString(`'\uD83D\uDE00' '\U0001F600' '\x41\
+b' "\uD83D\uDE00" "caf\u00E9"`)
+
+	_symbol(t, r, NewSymbolTokenFromString("😀"))
+	_symbol(t, r, NewSymbolTokenFromString("😀"))
+	_symbol(t, r, NewSymbolTokenFromString("Ab"))
+	_string(t, r, newString("😀"))
+	_string(t, r, newString("café"))
+	_eof(t, r)
+
+	bad := func(in string) {
+		t.Run(in, func(t *testing.T) {
+			r := NewReaderString(in)
+			assert.False(t, r.Next())
+			var se *SyntaxError
+			assert.True(t, errors.As(r.Err(), &se), "expected a SyntaxError, got %v", r.Err())
+		})
+	}
+
+	bad(`'\uD83D'`)
+	bad(`'\uDE00'`)
+	bad(`'\uD83Dx'`)
+	bad(`'\uD83D\u0041'`)
+	bad(`"\uD83D"`)
+	bad(`'\U00110000'`)
+	bad(`'\U0000D800'`)
+
+	// Unescaped control characters are rejected just as in strings.
+	r = NewReaderString("'a\x01b'")
+	assert.False(t, r.Next())
+	var ue *UnexpectedRuneError
+	assert.True(t, errors.As(r.Err(), &ue), "expected an UnexpectedRuneError, got %v", r.Err())
+}
+
 func TestSpecialSymbols(t *testing.T) {
 	r := NewReaderString("null\nnull.struct\ntrue\nfalse\nnan")
 
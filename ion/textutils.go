@@ -16,11 +16,12 @@
 package ion
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"math/big"
+	"math"
 	"strconv"
-	"strings"
+	"unicode/utf8"
 )
 
 // Does this symbol need to be quoted in text form?
@@ -28,6 +29,9 @@ func symbolNeedsQuoting(sym string) bool {
 	switch sym {
 	case "", "null", "true", "false", "nan":
 		return true
+	case "$ion_1_0":
+		// Unquoted, it would be read as a version marker.
+		return true
 	}
 
 	if !isIdentifierStart(int(sym[0])) {
@@ -116,34 +120,50 @@ func isWhitespace(c int) bool {
 
 // Formats a float64 in Ion text style.
 func formatFloat(val float64) string {
-	str := strconv.FormatFloat(val, 'e', -1, 64)
+	return string(appendFloat(nil, val))
+}
 
+// AppendFloat appends the Ion text form of a float to buf.
+func appendFloat(buf []byte, val float64) []byte {
 	// Ion uses lower case for special values.
-	switch str {
-	case "NaN":
-		return "nan"
-	case "+Inf":
-		return "+inf"
-	case "-Inf":
-		return "-inf"
+	switch {
+	case math.IsNaN(val):
+		return append(buf, "nan"...)
+	case math.IsInf(val, 1):
+		return append(buf, "+inf"...)
+	case math.IsInf(val, -1):
+		return append(buf, "-inf"...)
 	}
 
-	idx := strings.Index(str, "e")
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, val, 'e', -1, 64)
+
+	idx := bytes.IndexByte(buf[start:], 'e')
 	if idx < 0 {
 		// We need to add an 'e' or it will get interpreted as an Ion decimal.
-		str += "e0"
-	} else if idx+2 < len(str) && str[idx+2] == '0' {
-		// FormatFloat returns exponents with a leading ±0 in some cases; strip it.
-		str = str[:idx+2] + str[idx+3:]
+		return append(buf, "e0"...)
+	}
+	idx += start
+	if idx+2 < len(buf) && buf[idx+2] == '0' {
+		// AppendFloat returns exponents with a leading ±0 in some cases; strip it.
+		buf = append(buf[:idx+2], buf[idx+3:]...)
 	}
 
-	return str
+	return buf
 }
 
 // Write the given symbol out.
 func writeSymbol(val interface{}, out io.Writer) error {
-	token := val.(SymbolToken)
+	return writeSymbolToken(val.(SymbolToken), false, out)
+}
+
+// Write the given symbol out, escaping any non-ASCII characters.
+func writeSymbolASCII(val interface{}, out io.Writer) error {
+	return writeSymbolToken(val.(SymbolToken), true, out)
+}
 
+// Write the given symbol token out, optionally escaping non-ASCII characters.
+func writeSymbolToken(token SymbolToken, asciiOnly bool, out io.Writer) error {
 	var text string
 	if token.Text != nil {
 		text = *token.Text
@@ -161,18 +181,27 @@ func writeSymbol(val interface{}, out io.Writer) error {
 		return fmt.Errorf("ion: invalid symbol token")
 	}
 
-	return writeSymbolFromString(text, out)
+	return writeSymbolText(text, asciiOnly, out)
 }
 
 // Write the given symbol out, quoting and encoding if necessary.
 func writeSymbolFromString(val interface{}, out io.Writer) error {
-	sym := val.(string)
+	return writeSymbolText(val.(string), false, out)
+}
 
+// Write the given symbol out, quoting and encoding if necessary and escaping
+// any non-ASCII characters.
+func writeSymbolFromStringASCII(val interface{}, out io.Writer) error {
+	return writeSymbolText(val.(string), true, out)
+}
+
+// Write the given symbol text out, optionally escaping non-ASCII characters.
+func writeSymbolText(sym string, asciiOnly bool, out io.Writer) error {
 	if symbolNeedsQuoting(sym) {
 		if err := writeRawChar('\'', out); err != nil {
 			return err
 		}
-		if err := writeEscapedSymbol(sym, out); err != nil {
+		if err := writeEscapedText(sym, '\'', asciiOnly, out); err != nil {
 			return err
 		}
 		return writeRawChar('\'', out)
@@ -182,26 +211,37 @@ func writeSymbolFromString(val interface{}, out io.Writer) error {
 
 // Write the given symbol out, escaping any characters that need escaping.
 func writeEscapedSymbol(sym string, out io.Writer) error {
-	for i := 0; i < len(sym); i++ {
-		c := sym[i]
-		if c < 32 || c == '\\' || c == '\'' {
-			if err := writeEscapedChar(c, out); err != nil {
-				return err
-			}
-		} else {
-			if err := writeRawChar(c, out); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return writeEscapedText(sym, '\'', false, out)
 }
 
 // Write the given string out, escaping any characters that need escaping.
 func writeEscapedString(str string, out io.Writer) error {
+	return writeEscapedText(str, '"', false, out)
+}
+
+// Write the given text out, escaping any characters that need escaping within
+// the given quotes. If asciiOnly is set, non-ASCII characters are escaped too.
+// Bytes that are not valid UTF-8, which Ion text cannot hold, are written as
+// U+FFFD, the Unicode replacement character.
+func writeEscapedText(str string, quote byte, asciiOnly bool, out io.Writer) error {
 	for i := 0; i < len(str); i++ {
 		c := str[i]
-		if c < 32 || c == '\\' || c == '"' {
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(str[i:])
+			var err error
+			switch {
+			case asciiOnly:
+				err = writeUnicodeEscape(r, out)
+			case r == utf8.RuneError && size == 1:
+				err = writeRawString(string(utf8.RuneError), out)
+			default:
+				err = writeRawString(str[i:i+size], out)
+			}
+			if err != nil {
+				return err
+			}
+			i += size - 1
+		} else if c < 32 || c == '\\' || c == quote {
 			if err := writeEscapedChar(c, out); err != nil {
 				return err
 			}
@@ -214,6 +254,14 @@ func writeEscapedString(str string, out io.Writer) error {
 	return nil
 }
 
+// Write out the given character as a \u or \U escape.
+func writeUnicodeEscape(r rune, out io.Writer) error {
+	if r <= 0xFFFF {
+		return writeRawString(fmt.Sprintf("\\u%04X", r), out)
+	}
+	return writeRawString(fmt.Sprintf("\\U%08X", r), out)
+}
+
 // Write out the given character in escaped form.
 func writeEscapedChar(c byte, out io.Writer) error {
 	switch c {
@@ -247,9 +295,7 @@ func writeEscapedChar(c byte, out io.Writer) error {
 
 // Write out the given raw string.
 func writeRawString(val interface{}, out io.Writer) error {
-	s := val.(string)
-
-	_, err := out.Write([]byte(s))
+	_, err := io.WriteString(out, val.(string))
 	return err
 }
 
@@ -261,10 +307,19 @@ func writeRawChars(cs []byte, out io.Writer) error {
 
 // Write out the given raw character.
 func writeRawChar(c byte, out io.Writer) error {
-	_, err := out.Write([]byte{c})
+	_, err := out.Write(rawChars[int(c) : int(c)+1])
 	return err
 }
 
+// RawChars backs the one-byte slices written by writeRawChar, so that
+// writing a single character doesn't allocate.
+var rawChars = func() (cs [256]byte) {
+	for i := range cs {
+		cs[i] = byte(i)
+	}
+	return
+}()
+
 func parseFloat(str string) (float64, error) {
 	val, err := strconv.ParseFloat(str, 64)
 	if err != nil {
@@ -282,48 +337,98 @@ func parseDecimal(str string) (*Decimal, error) {
 	return ParseDecimal(str)
 }
 
-func parseInt(str string, radix int) (interface{}, error) {
-	digits := str
+// ParseInt parses the text of an int in the given radix, returning false if
+// it is too big for an int64.
+func parseInt(str string, radix int) (int64, bool, error) {
+	digits, neg := intDigits(str, radix)
+
+	u, err := strconv.ParseUint(digits, radix, 64)
+	if err != nil {
+		if err.(*strconv.NumError).Err == strconv.ErrRange {
+			return 0, false, nil
+		}
+		return 0, false, &strconv.NumError{
+			Func: "ParseInt",
+			Num:  str,
+			Err:  strconv.ErrSyntax,
+		}
+	}
+
+	i, ok := signedInt64(u, neg)
+	return i, ok, nil
+}
+
+// AppendMagnitude appends the magnitude of the int whose text is given, in
+// the given radix, to mag as big-endian bytes with no leading zeros. It
+// returns them along with whether the int is negative.
+func appendMagnitude(mag []byte, str string, radix int) ([]byte, bool) {
+	digits, neg := intDigits(str, radix)
+
+	// Build the bytes little-endian, then turn them around.
+	start := len(mag)
+	for i := 0; i < len(digits); i++ {
+		carry := digitValue(digits[i])
+		for j := start; j < len(mag); j++ {
+			v := int(mag[j])*radix + carry
+			mag[j] = byte(v)
+			carry = v >> 8
+		}
+		for ; carry > 0; carry >>= 8 {
+			mag = append(mag, byte(carry))
+		}
+	}
+
+	for i, j := start, len(mag)-1; i < j; i, j = i+1, j-1 {
+		mag[i], mag[j] = mag[j], mag[i]
+	}
+	return mag, neg
+}
+
+// IntDigits splits the text of an int in the given radix into its digits,
+// without any 0x or 0b prefix, and whether it is negative.
+func intDigits(str string, radix int) (string, bool) {
+	neg := false
+	if str[0] == '-' {
+		neg = true
+		str = str[1:]
+	}
 
 	switch radix {
 	case 10:
 		// All set.
 
 	case 2, 16:
-		neg := false
-		if digits[0] == '-' {
-			neg = true
-			digits = digits[1:]
-		}
-
 		// Skip over the '0x' prefix.
-		digits = digits[2:]
-		if neg {
-			digits = "-" + digits
-		}
+		str = str[2:]
 
 	default:
 		panic("unsupported radix")
 	}
 
-	i, err := strconv.ParseInt(digits, radix, 64)
-	if err == nil {
-		return i, nil
-	}
-	if err.(*strconv.NumError).Err != strconv.ErrRange {
-		return nil, err
-	}
+	return str, neg
+}
 
-	bi, ok := (&big.Int{}).SetString(digits, radix)
-	if !ok {
-		return nil, &strconv.NumError{
-			Func: "ParseInt",
-			Num:  str,
-			Err:  strconv.ErrSyntax,
-		}
+// DigitValue returns the value of the given hex digit.
+func digitValue(c byte) int {
+	switch {
+	case c >= 'a':
+		return int(c-'a') + 10
+	case c >= 'A':
+		return int(c-'A') + 10
 	}
+	return int(c - '0')
+}
 
-	return bi, nil
+// SignedInt64 returns the int64 with the given magnitude and sign, and
+// whether there is one.
+func signedInt64(mag uint64, neg bool) (int64, bool) {
+	switch {
+	case !neg && mag <= math.MaxInt64:
+		return int64(mag), true
+	case neg && mag <= 1<<63:
+		return -int64(mag), true
+	}
+	return 0, false
 }
 
 func parseTimestamp(val string) (Timestamp, error) {
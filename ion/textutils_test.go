@@ -90,6 +90,12 @@ func TestWriteSymbol(t *testing.T) {
 	test("123", "'123'")
 	test("abc'def", "'abc\\'def'")
 	test("abc\"def", "'abc\"def'")
+
+	test("café", "'café'")
+	test("1abc", "'1abc'")
+	test("a\u2003b", "'a\u2003b'")
+	test("日本", "'日本'")
+	test("a\xffb", "'a\uFFFDb'")
 }
 
 func TestSymbolNeedsQuoting(t *testing.T) {
@@ -105,8 +111,10 @@ func TestSymbolNeedsQuoting(t *testing.T) {
 	test("true", true)
 	test("false", true)
 	test("nan", true)
+	test("$ion_1_0", true)
 
 	test("basic", false)
+	test("$ion_1_1", false)
 	test("_basic_", false)
 	test("basic$123", false)
 	test("$", false)
@@ -121,6 +129,10 @@ func TestSymbolNeedsQuoting(t *testing.T) {
 	test("abc}def", true)
 	test("abc[def", true)
 	test("abc]def", true)
+	test("café", true)
+	test("\u3000x", true)
+	test("e\u0301", true)
+	test("😀", true)
 	test("abc'def", true)
 	test("abc\"def", true)
 }
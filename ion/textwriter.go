@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"strconv"
+	"strings"
 )
 
 // TextWriterOpts defines a set of bit flag options for text writers.
@@ -33,19 +35,49 @@ const (
 
 	// TextWriterPretty enables pretty-printing mode.
 	TextWriterPretty TextWriterOpts = 2
+
+	// TextWriterASCIIOnly escapes every non-ASCII character in strings and
+	// symbols as \uXXXX or \UXXXXXXXX, rather than writing it as UTF-8, so that
+	// the output is plain ASCII. Invalid UTF-8 is written as \ufffd.
+	TextWriterASCIIOnly TextWriterOpts = 4
+
+	// TextWriterCompact leaves out whitespace wherever it is optional: between
+	// values that are already delimited by a closing brace, bracket,
+	// parenthesis, or quote, e.g. {a:1}{b:2} rather than one value per line at
+	// the top level, or ("x""y") rather than ("x" "y") in an sexp. Whitespace
+	// that separates two values is kept. It has no effect with
+	// TextWriterPretty, and the newline written by Finish is left to
+	// TextWriterQuietFinish.
+	TextWriterCompact TextWriterOpts = 8
+
+	// TextWriterHexComments follows each non-empty blob and clob with a
+	// comment showing its bytes in hex, e.g. {{aGk=}} /* 68 69 */, for people
+	// inspecting binary payloads. The value itself is written as usual, so the
+	// output reads back the same as without the option.
+	TextWriterHexComments TextWriterOpts = 16
 )
 
 // textWriter is a writer that writes human-readable text
 type textWriter struct {
 	writer
 	opts           TextWriterOpts
+	digitGroups    int
 	needsSeparator bool
 	emptyContainer bool
 	emptyStream    bool
 	indent         int
 
+	// Delimited is set if the last value written ended with a closing
+	// delimiter, so that another value can follow it without whitespace.
+	delimited bool
+
 	lstb     SymbolTableBuilder
 	wroteLST bool
+
+	trivia *string
+
+	// Scratch is reused to format scalar values.
+	scratch []byte
 }
 
 // NewTextWriter returns a new text writer that will construct a
@@ -64,14 +96,36 @@ func NewTextWriterOpts(out io.Writer, opts TextWriterOpts, sts ...SharedSymbolTa
 	}
 }
 
+// NewTextWriterDigitGroups returns a new text writer with the given options
+// that writes integers with an underscore between each group of n digits,
+// counting from the right, e.g. 1_000_000 for n=3, for readability. It
+// returns an error if n is less than one.
+func NewTextWriterDigitGroups(out io.Writer, opts TextWriterOpts, n int, sts ...SharedSymbolTable) (Writer, error) {
+	if n < 1 {
+		return nil, &UsageError{"NewTextWriterDigitGroups", fmt.Sprintf("invalid digit group size %v", n)}
+	}
+	w := NewTextWriterOpts(out, opts, sts...).(*textWriter)
+	w.digitGroups = n
+	return w, nil
+}
+
 // WriteNull writes an untyped null.
 func (w *textWriter) WriteNull() error {
-	return w.writeValue("Writer.WriteNull", textNulls[NoType], writeRawString)
+	return w.writeRawValue("Writer.WriteNull", textNulls[NoType])
 }
 
 // WriteNullType writes a typed null.
 func (w *textWriter) WriteNullType(t Type) error {
-	return w.writeValue("Writer.WriteNullType", textNulls[t], writeRawString)
+	return w.writeRawValue("Writer.WriteNullType", textNulls[t])
+}
+
+// WriteNullWithTypeAndAnnotations writes a typed null with the given
+// annotations.
+func (w *textWriter) WriteNullWithTypeAndAnnotations(t Type, annotations ...string) error {
+	if w.err != nil {
+		return w.err
+	}
+	return writeNullWithTypeAndAnnotations(w, t, annotations)
 }
 
 // WriteBool writes a boolean value.
@@ -80,46 +134,67 @@ func (w *textWriter) WriteBool(val bool) error {
 	if val {
 		str = "true"
 	}
-	return w.writeValue("Writer.WriteBool", str, writeRawString)
+	return w.writeRawValue("Writer.WriteBool", str)
 }
 
 // WriteInt writes an integer value.
 func (w *textWriter) WriteInt(val int64) error {
-	return w.writeValue("Writer.WriteInt", fmt.Sprintf("%d", val), writeRawString)
+	if w.digitGroups == 0 {
+		return w.appendValue("Writer.WriteInt", func(buf []byte) []byte {
+			return strconv.AppendInt(buf, val, 10)
+		})
+	}
+	return w.writeRawValue("Writer.WriteInt", w.groupDigits(strconv.FormatInt(val, 10)))
 }
 
 // WriteUint writes an unsigned integer value.
 func (w *textWriter) WriteUint(val uint64) error {
-	return w.writeValue("Writer.WriteUint", fmt.Sprintf("%d", val), writeRawString)
+	if w.digitGroups == 0 {
+		return w.appendValue("Writer.WriteUint", func(buf []byte) []byte {
+			return strconv.AppendUint(buf, val, 10)
+		})
+	}
+	return w.writeRawValue("Writer.WriteUint", w.groupDigits(strconv.FormatUint(val, 10)))
 }
 
 // WriteBigInt writes a (big) integer value.
 func (w *textWriter) WriteBigInt(val *big.Int) error {
-	return w.writeValue("Writer.WriteBigInt", val.String(), writeRawString)
+	return w.writeRawValue("Writer.WriteBigInt", w.groupDigits(val.String()))
 }
 
 // WriteFloat writes a floating-point value.
 func (w *textWriter) WriteFloat(val float64) error {
-	return w.writeValue("Writer.WriteFloat", formatFloat(val), writeRawString)
+	return w.appendValue("Writer.WriteFloat", func(buf []byte) []byte {
+		return appendFloat(buf, val)
+	})
 }
 
 // WriteDecimal writes an arbitrary-precision decimal value.
 func (w *textWriter) WriteDecimal(val *Decimal) error {
-	return w.writeValue("Writer.WriteDecimal", val.String(), writeRawString)
+	if !val.isValid() {
+		return &UsageError{"Writer.WriteDecimal", "decimal has no value"}
+	}
+	return w.writeRawValue("Writer.WriteDecimal", val.String())
 }
 
 // WriteTimestamp writes a timestamp.
 func (w *textWriter) WriteTimestamp(val Timestamp) error {
-	return w.writeValue("Writer.WriteTimestamp", val.String(), writeRawString)
+	return w.writeRawValue("Writer.WriteTimestamp", val.String())
 }
 
 // WriteSymbol writes a symbol given a SymbolToken.
 func (w *textWriter) WriteSymbol(val SymbolToken) error {
+	if w.asciiOnly() {
+		return w.writeValue("Writer.WriteSymbol", val, writeSymbolASCII)
+	}
 	return w.writeValue("Writer.WriteSymbol", val, writeSymbol)
 }
 
 // WriteSymbolFromString writes a symbol given a string.
 func (w *textWriter) WriteSymbolFromString(val string) error {
+	if w.asciiOnly() {
+		return w.writeValue("Writer.WriteSymbolFromString", val, writeSymbolFromStringASCII)
+	}
 	return w.writeValue("Writer.WriteSymbolFromString", val, writeSymbolFromString)
 }
 
@@ -135,7 +210,7 @@ func (w *textWriter) WriteString(val string) error {
 	if w.err = writeRawChar('"', w.out); w.err != nil {
 		return w.err
 	}
-	if w.err = writeEscapedString(val, w.out); w.err != nil {
+	if w.err = writeEscapedText(val, '"', w.asciiOnly(), w.out); w.err != nil {
 		return w.err
 	}
 	if w.err = writeRawChar('"', w.out); w.err != nil {
@@ -143,6 +218,7 @@ func (w *textWriter) WriteString(val string) error {
 	}
 
 	w.endValue()
+	w.delimited = true
 	return nil
 }
 
@@ -172,8 +248,12 @@ func (w *textWriter) WriteClob(val []byte) error {
 	if w.err = writeRawString("\"}}", w.out); w.err != nil {
 		return w.err
 	}
+	if w.err = w.writeHexComment(val); w.err != nil {
+		return w.err
+	}
 
 	w.endValue()
+	w.delimited = true
 	return nil
 }
 
@@ -202,11 +282,39 @@ func (w *textWriter) WriteBlob(val []byte) error {
 	if w.err = writeRawString("}}", w.out); w.err != nil {
 		return w.err
 	}
+	if w.err = w.writeHexComment(val); w.err != nil {
+		return w.err
+	}
 
 	w.endValue()
+	w.delimited = true
 	return nil
 }
 
+// WriteValue marshals a Go value and writes it.
+func (w *textWriter) WriteValue(v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	return MarshalTo(w, v)
+}
+
+// WriteFieldIf writes a field with the given name and value if cond is true.
+func (w *textWriter) WriteFieldIf(cond bool, name string, v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	return writeFieldIf(w, cond, name, v)
+}
+
+// WriteListFromChan writes a list of the values received from ch.
+func (w *textWriter) WriteListFromChan(ch <-chan interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	return writeListFromChan(w, ch)
+}
+
 // BeginList begins writing a list.
 func (w *textWriter) BeginList() error {
 	if w.err == nil {
@@ -264,7 +372,15 @@ func (w *textWriter) Finish() error {
 		return &UsageError{"Writer.Finish", "not at top level"}
 	}
 
-	if !w.emptyStream && w.opts&TextWriterQuietFinish == 0 {
+	if w.trivia != nil {
+		// Trailing trivia takes the place of the final newline.
+		if w.err = writeRawString(*w.trivia, w.out); w.err != nil {
+			return w.err
+		}
+		w.trivia = nil
+		w.needsSeparator = false
+		w.emptyStream = true
+	} else if !w.emptyStream && w.opts&TextWriterQuietFinish == 0 {
 		if w.err = writeRawChar('\n', w.out); w.err != nil {
 			return w.err
 		}
@@ -276,11 +392,91 @@ func (w *textWriter) Finish() error {
 	return nil
 }
 
+// Reset discards the writer's state and directs its output to out.
+func (w *textWriter) Reset(out io.Writer) {
+	w.writer.reset(out)
+	w.needsSeparator = false
+	w.emptyContainer = false
+	w.emptyStream = true
+	w.indent = 0
+	w.delimited = false
+	w.wroteLST = false
+	w.trivia = nil
+}
+
+// Flush flushes the underlying io.Writer. Text values are written out as
+// they go, so there is never anything buffered by the writer itself.
+func (w *textWriter) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.flushOut()
+	return w.err
+}
+
 // pretty returns true if we're pretty-printing.
 func (w *textWriter) pretty() bool {
 	return w.opts&TextWriterPretty == TextWriterPretty
 }
 
+// groupDigits separates the digits of the given decimal integer into groups
+// with underscores, if we're doing so.
+func (w *textWriter) groupDigits(num string) string {
+	n := w.digitGroups
+	if n == 0 {
+		return num
+	}
+
+	sign := ""
+	if strings.HasPrefix(num, "-") {
+		sign, num = "-", num[1:]
+	}
+	if len(num) <= n {
+		return sign + num
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString(sign)
+	first := len(num) % n
+	if first == 0 {
+		first = n
+	}
+	buf.WriteString(num[:first])
+	for i := first; i < len(num); i += n {
+		buf.WriteByte('_')
+		buf.WriteString(num[i : i+n])
+	}
+	return buf.String()
+}
+
+// compact returns true if we're leaving out optional whitespace.
+func (w *textWriter) compact() bool {
+	return w.opts&(TextWriterCompact|TextWriterPretty) == TextWriterCompact
+}
+
+// WriteHexComment writes a comment showing the given lob's bytes in hex, if
+// the writer was asked to.
+func (w *textWriter) writeHexComment(val []byte) error {
+	if w.opts&TextWriterHexComments == 0 || len(val) == 0 {
+		return nil
+	}
+
+	buf := make([]byte, 0, 6+3*len(val))
+	buf = append(buf, " /*"...)
+	for _, c := range val {
+		buf = append(buf, ' ', hexChars[c>>4], hexChars[c&0xF])
+	}
+	buf = append(buf, " */"...)
+
+	_, err := w.out.Write(buf)
+	return err
+}
+
+// asciiOnly returns true if we're escaping non-ASCII characters.
+func (w *textWriter) asciiOnly() bool {
+	return w.opts&TextWriterASCIIOnly == TextWriterASCIIOnly
+}
+
 // writeValue writes a stringified value to the output stream.
 func (w *textWriter) writeValue(api string, val interface{}, fn func(interface{}, io.Writer) error) error {
 	if w.err != nil {
@@ -298,6 +494,44 @@ func (w *textWriter) writeValue(api string, val interface{}, fn func(interface{}
 	return nil
 }
 
+// WriteRawValue writes a value that is already in its text form.
+func (w *textWriter) writeRawValue(api string, val string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.beginValue(api); w.err != nil {
+		return w.err
+	}
+
+	if _, w.err = io.WriteString(w.out, val); w.err != nil {
+		return w.err
+	}
+
+	w.endValue()
+	return nil
+}
+
+// AppendValue writes a value whose text form is built by appendValue into
+// the writer's scratch buffer, so that common scalars can be written without
+// allocating. The buffer is filled only after beginValue, which may itself
+// write values while writing out the local symbol table.
+func (w *textWriter) appendValue(api string, appendValue func(buf []byte) []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.beginValue(api); w.err != nil {
+		return w.err
+	}
+
+	w.scratch = appendValue(w.scratch[:0])
+	if _, w.err = w.out.Write(w.scratch); w.err != nil {
+		return w.err
+	}
+
+	w.endValue()
+	return nil
+}
+
 // beginValue begins the process of writing a value, by writing out
 // a separator (if needed), field name (if in a struct), and type
 // annotations (if any).
@@ -317,7 +551,13 @@ func (w *textWriter) beginValue(api string) error {
 		}
 	}
 
-	if w.needsSeparator {
+	if w.trivia != nil {
+		// Trivia takes the place of the separator.
+		if err := writeRawString(*w.trivia, w.out); err != nil {
+			return err
+		}
+		w.trivia = nil
+	} else if w.needsSeparator {
 		if err := w.writeSeparator(); err != nil {
 			return err
 		}
@@ -380,6 +620,11 @@ func (w *textWriter) writeSeparator() error {
 		sep = "\n"
 	}
 
+	if sep != "," && w.delimited && w.compact() {
+		// Whitespace is only needed between two undelimited values.
+		return nil
+	}
+
 	return writeRawString(sep, w.out)
 }
 
@@ -391,7 +636,7 @@ func (w *textWriter) writeFieldName(api string) error {
 	name := w.fieldName
 	w.fieldName = nil
 
-	if err := writeSymbol(*name, w.out); err != nil {
+	if err := writeSymbolToken(*name, w.asciiOnly(), w.out); err != nil {
 		return err
 	}
 
@@ -409,7 +654,7 @@ func (w *textWriter) writeAnnotations() error {
 	w.annotations = nil
 
 	for _, a := range as {
-		if err := writeSymbol(a, w.out); err != nil {
+		if err := writeSymbolToken(a, w.asciiOnly(), w.out); err != nil {
 			return err
 		}
 		if err := writeRawString("::", w.out); err != nil {
@@ -425,6 +670,7 @@ func (w *textWriter) endValue() {
 	w.needsSeparator = true
 	w.emptyContainer = false
 	w.emptyStream = false
+	w.delimited = false
 }
 
 // begin starts writing a container of the given type.
@@ -465,6 +711,7 @@ func (w *textWriter) end(api string, t ctx, c byte) error {
 	w.clear()
 	w.ctx.pop()
 	w.endValue()
+	w.delimited = true
 
 	return nil
 }
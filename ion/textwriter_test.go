@@ -16,6 +16,9 @@
 package ion
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"strings"
@@ -144,6 +147,18 @@ func TestWriteTextNulls(t *testing.T) {
 	})
 }
 
+func TestWriteTextNullWithTypeAndAnnotations(t *testing.T) {
+	expected := "foo::null.int\na::b::null.null\nnull.struct\nx::'y z'::null.list"
+
+	testTextWriter(t, expected, func(w Writer) {
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(IntType, "foo"))
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(NullType, "a", "b"))
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(StructType))
+		assert.NoError(t, w.Annotation(NewSymbolTokenFromString("x")))
+		assert.NoError(t, w.WriteNullWithTypeAndAnnotations(ListType, "y z"))
+	})
+}
+
 func TestWriteTextBool(t *testing.T) {
 	expected := "true\n(false '123'::true)\n'false'::false"
 	testTextWriter(t, expected, func(w Writer) {
@@ -308,6 +323,144 @@ func TestWriteTextClob(t *testing.T) {
 	})
 }
 
+func TestWriteTextASCIIOnly(t *testing.T) {
+	write := func(opts TextWriterOpts) string {
+		buf := strings.Builder{}
+		w := NewTextWriterOpts(&buf, opts|TextWriterQuietFinish)
+		require.NoError(t, w.BeginStruct())
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("clé")))
+		require.NoError(t, w.Annotation(NewSymbolTokenFromString("日本")))
+		require.NoError(t, w.WriteString("naïve 😀 \"q\"\n"))
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("sym")))
+		require.NoError(t, w.WriteSymbolFromString("ĳ'😀"))
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("tok")))
+		require.NoError(t, w.WriteSymbol(NewSymbolTokenFromString("ascii")))
+		require.NoError(t, w.EndStruct())
+		require.NoError(t, w.Finish())
+		return buf.String()
+	}
+
+	utf8Text := write(0)
+	assert.Equal(t, "{'clé':'日本'::\"naïve 😀 \\\"q\\\"\\n\",sym:'ĳ\\'😀',tok:ascii}", utf8Text)
+
+	asciiText := write(TextWriterASCIIOnly)
+	assert.Equal(t, "{'cl\\u00E9':'\\u65E5\\u672C'::\"na\\u00EFve \\U0001F600 \\\"q\\\"\\n\",sym:'\\u0133\\'\\U0001F600',tok:ascii}", asciiText)
+	for _, c := range []byte(asciiText) {
+		assert.Less(t, c, byte(0x80))
+	}
+
+	// Both read back as the same values.
+	var fromUTF8, fromASCII interface{}
+	require.NoError(t, UnmarshalString(utf8Text, &fromUTF8))
+	require.NoError(t, UnmarshalString(asciiText, &fromASCII))
+	assert.Equal(t, fromUTF8, fromASCII)
+
+	r := NewReaderString(asciiText)
+	require.True(t, r.Next())
+	require.NoError(t, r.StepIn())
+	require.True(t, r.Next())
+	fn, err := r.FieldName()
+	require.NoError(t, err)
+	assert.Equal(t, "clé", *fn.Text)
+	as, err := r.Annotations()
+	require.NoError(t, err)
+	assert.Equal(t, "日本", *as[0].Text)
+	s, err := r.StringValue()
+	require.NoError(t, err)
+	assert.Equal(t, "naïve 😀 \"q\"\n", *s)
+	require.True(t, r.Next())
+	sym, err := r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, "ĳ'😀", *sym.Text)
+}
+
+func TestWriteTextUnicodeSymbolsRoundTrip(t *testing.T) {
+	syms := []string{"café", "1abc", "a\u2003b", "\u3000x", "e\u0301", "日本", "😀", "\u00A0", "x\u200By"}
+
+	for _, opts := range []TextWriterOpts{0, TextWriterASCIIOnly} {
+		for _, sym := range syms {
+			buf := strings.Builder{}
+			w := NewTextWriterOpts(&buf, opts|TextWriterQuietFinish)
+			require.NoError(t, w.Annotation(NewSymbolTokenFromString(sym)))
+			require.NoError(t, w.BeginStruct())
+			require.NoError(t, w.FieldName(NewSymbolTokenFromString(sym)))
+			require.NoError(t, w.BeginSexp())
+			require.NoError(t, w.WriteSymbolFromString(sym))
+			require.NoError(t, w.EndSexp())
+			require.NoError(t, w.EndStruct())
+			require.NoError(t, w.Finish())
+
+			r := NewReaderString(buf.String())
+			require.True(t, r.Next(), buf.String())
+			as, err := r.Annotations()
+			require.NoError(t, err)
+			assert.Equal(t, sym, *as[0].Text)
+			require.NoError(t, r.StepIn())
+			require.True(t, r.Next())
+			fn, err := r.FieldName()
+			require.NoError(t, err)
+			assert.Equal(t, sym, *fn.Text)
+			require.NoError(t, r.StepIn())
+			require.True(t, r.Next())
+			val, err := r.SymbolValue()
+			require.NoError(t, err)
+			assert.Equal(t, sym, *val.Text)
+		}
+	}
+}
+
+func TestWriteTextDigitGroups(t *testing.T) {
+	test := func(n int, val interface{}, expected string) {
+		t.Run(fmt.Sprintf("%v/%v", n, expected), func(t *testing.T) {
+			buf := strings.Builder{}
+			w, err := NewTextWriterDigitGroups(&buf, TextWriterQuietFinish, n)
+			require.NoError(t, err)
+			switch v := val.(type) {
+			case int64:
+				require.NoError(t, w.WriteInt(v))
+			case uint64:
+				require.NoError(t, w.WriteUint(v))
+			case *big.Int:
+				require.NoError(t, w.WriteBigInt(v))
+			}
+			require.NoError(t, w.Finish())
+			assert.Equal(t, expected, buf.String())
+
+			// The reader accepts the separators.
+			r := NewReaderString(buf.String())
+			require.True(t, r.Next())
+			bi, err := r.BigIntValue()
+			require.NoError(t, err)
+			assert.Equal(t, strings.ReplaceAll(expected, "_", ""), bi.String())
+		})
+	}
+
+	test(3, int64(0), "0")
+	test(3, int64(999), "999")
+	test(3, int64(1000), "1_000")
+	test(3, int64(-1000), "-1_000")
+	test(3, int64(1000000), "1_000_000")
+	test(3, int64(-123456789), "-123_456_789")
+	test(3, int64(math.MinInt64), "-9_223_372_036_854_775_808")
+	test(3, uint64(math.MaxUint64), "18_446_744_073_709_551_615")
+	test(4, int64(12345678), "1234_5678")
+	test(4, int64(123456789), "1_2345_6789")
+	test(2, int64(-12345), "-1_23_45")
+	test(1, int64(123), "1_2_3")
+	test(5, new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil), "1_00000_00000_00000_00000")
+
+	// Without the option, there are no separators.
+	assert.Equal(t, "1000000", writeText(func(w Writer) {
+		require.NoError(t, w.WriteInt(1000000))
+	}))
+
+	for _, n := range []int{0, -1} {
+		_, err := NewTextWriterDigitGroups(&strings.Builder{}, 0, n)
+		var uerr *UsageError
+		assert.True(t, errors.As(err, &uerr), "n=%v", n)
+	}
+}
+
 func TestWriteTextFinish(t *testing.T) {
 	expected := "1\nfoo\n\"bar\"\n{}\n"
 	testTextWriter(t, expected, func(w Writer) {
@@ -320,6 +473,145 @@ func TestWriteTextFinish(t *testing.T) {
 	})
 }
 
+func TestWriteTextFlush(t *testing.T) {
+	buf := strings.Builder{}
+	bw := bufio.NewWriter(&buf)
+	w := NewTextWriter(bw)
+
+	assert.NoError(t, w.BeginList())
+	assert.NoError(t, w.WriteInt(1))
+	assert.Equal(t, "", buf.String())
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "[1", buf.String())
+
+	assert.NoError(t, w.EndList())
+	require.NoError(t, w.Finish())
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "[1]\n", buf.String())
+}
+
+func TestWriteTextValue(t *testing.T) {
+	type payload struct {
+		ID   int      `ion:"id"`
+		Tags []string `ion:"tags,symbol"`
+	}
+
+	expected := "{header:v1,payload:meta::{id:42,tags:[a,b]},rest:[1,\"two\"]}"
+
+	testTextWriter(t, expected, func(w Writer) {
+		assert.NoError(t, w.BeginStruct())
+
+		assert.NoError(t, w.FieldName(NewSymbolTokenFromString("header")))
+		assert.NoError(t, w.WriteSymbolFromString("v1"))
+
+		assert.NoError(t, w.FieldName(NewSymbolTokenFromString("payload")))
+		assert.NoError(t, w.Annotation(NewSymbolTokenFromString("meta")))
+		assert.NoError(t, w.WriteValue(payload{42, []string{"a", "b"}}))
+
+		assert.NoError(t, w.FieldName(NewSymbolTokenFromString("rest")))
+		assert.NoError(t, w.WriteValue([]interface{}{1, "two"}))
+
+		assert.NoError(t, w.EndStruct())
+	})
+}
+
+func TestWriteTextFieldIf(t *testing.T) {
+	expected := "{name:\"x\",tags:[a,b]}"
+
+	testTextWriter(t, expected, func(w Writer) {
+		assert.NoError(t, w.BeginStruct())
+		assert.NoError(t, w.WriteFieldIf(true, "name", "x"))
+		assert.NoError(t, w.WriteFieldIf(false, "id", 42))
+		assert.NoError(t, w.WriteFieldIf(true, "tags", []SymbolToken{NewSymbolTokenFromString("a"), NewSymbolTokenFromString("b")}))
+		assert.NoError(t, w.WriteFieldIf(false, "rest", nil))
+		assert.NoError(t, w.EndStruct())
+	})
+
+	// A field can only be written inside a struct, but a skipped one writes
+	// nothing and so is not an error.
+	w := NewTextWriter(&strings.Builder{})
+	assert.NoError(t, w.WriteFieldIf(false, "id", 42))
+	assert.Error(t, w.WriteFieldIf(true, "id", 42))
+}
+
+func TestWriteTextListFromChan(t *testing.T) {
+	send := func(vals ...interface{}) <-chan interface{} {
+		ch := make(chan interface{})
+		go func() {
+			defer close(ch)
+			for _, v := range vals {
+				ch <- v
+			}
+		}()
+		return ch
+	}
+
+	testTextWriter(t, "{empty:[],vals:[1,\"two\",[3],{four:4},null]}", func(w Writer) {
+		assert.NoError(t, w.BeginStruct())
+		assert.NoError(t, w.FieldName(NewSymbolTokenFromString("empty")))
+		assert.NoError(t, w.WriteListFromChan(send()))
+		assert.NoError(t, w.FieldName(NewSymbolTokenFromString("vals")))
+		assert.NoError(t, w.WriteListFromChan(send(1, "two", []int{3}, map[string]int{"four": 4}, nil)))
+		assert.NoError(t, w.EndStruct())
+	})
+
+	// A value that can't be marshaled stops the list straight away, even
+	// though the producer would go on forever; it stops once done is closed.
+	ch := make(chan interface{})
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ch <- 1
+		ch <- make(chan int)
+		for {
+			select {
+			case ch <- 3:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	buf := strings.Builder{}
+	w := NewTextWriter(&buf)
+	assert.Error(t, w.WriteListFromChan(ch))
+	close(done)
+	<-stopped
+	assert.Error(t, w.Finish())
+}
+
+func TestWriteTextReset(t *testing.T) {
+	doc := func(w Writer) {
+		require.NoError(t, w.BeginStruct())
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("name")))
+		require.NoError(t, w.WriteSymbolFromString("value"))
+		require.NoError(t, w.EndStruct())
+		require.NoError(t, w.WriteInt(42))
+		require.NoError(t, w.Finish())
+	}
+
+	shared := NewSharedSymbolTable("shared", 1, []string{"name"})
+
+	for _, opts := range []TextWriterOpts{0, TextWriterPretty} {
+		fresh := strings.Builder{}
+		doc(NewTextWriterOpts(&fresh, opts, shared))
+
+		w := NewTextWriterOpts(&strings.Builder{}, opts, shared)
+		require.NoError(t, w.WriteInt(1))
+		require.NoError(t, w.BeginStruct())
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("f")))
+		require.NoError(t, w.BeginList())
+		require.NoError(t, w.Annotation(NewSymbolTokenFromString("ann")))
+
+		reused := strings.Builder{}
+		w.Reset(&reused)
+		doc(w)
+		assert.Equal(t, fresh.String(), reused.String())
+	}
+}
+
 func TestWriteTextBadFinish(t *testing.T) {
 	buf := strings.Builder{}
 	w := NewTextWriter(&buf)
@@ -416,3 +708,111 @@ func writeText(f func(Writer)) string {
 
 	return buf.String()
 }
+
+func TestWriteTextCompact(t *testing.T) {
+	vals := []interface{}{
+		map[string]interface{}{"a": 1, "b": []interface{}{"x", 2.5, []byte{1, 2}}},
+		"str",
+		1,
+		NewSymbolTokenFromString("sym"),
+		[]int{1, 2},
+		[]byte("blob"),
+		struct {
+			S []interface{} `ion:"s,sexp"`
+		}{[]interface{}{"q", "r", NewSymbolTokenFromString("+"), 3, []interface{}{"t"}, "u"}},
+		nil,
+	}
+
+	write := func(opts TextWriterOpts) string {
+		buf := strings.Builder{}
+		w := NewTextWriterOpts(&buf, opts|TextWriterQuietFinish)
+		e := NewEncoderOpts(w, EncodeSortMaps)
+		for _, v := range vals {
+			require.NoError(t, e.Encode(v))
+		}
+		require.NoError(t, e.Finish())
+		return buf.String()
+	}
+
+	def := write(0)
+	compact := write(TextWriterCompact)
+
+	assert.Equal(t, "{a:1,b:[\"x\",2.5e+0,{{AQI=}}]}\n\"str\"\n1\nsym\n[1,2]\n{{YmxvYg==}}\n{s:(\"q\" \"r\" '+' 3 (\"t\") \"u\")}\nnull", def)
+	assert.Equal(t, "{a:1,b:[\"x\",2.5e+0,{{AQI=}}]}\"str\"1\nsym\n[1,2]{{YmxvYg==}}{s:(\"q\"\"r\"'+' 3 (\"t\")\"u\")}null", compact)
+	assert.Less(t, len(compact), len(def))
+
+	// Both read back the same.
+	decodeAll := func(in string) []interface{} {
+		var res []interface{}
+		d := NewDecoder(NewReaderString(in))
+		for {
+			v, err := d.Decode()
+			if err == ErrNoInput {
+				return res
+			}
+			require.NoError(t, err)
+			res = append(res, v)
+		}
+	}
+	assert.Equal(t, decodeAll(def), decodeAll(compact))
+
+	// Pretty-printing wins.
+	assert.Equal(t, write(TextWriterPretty), write(TextWriterPretty|TextWriterCompact))
+}
+
+func TestWriteTextHexComments(t *testing.T) {
+	doc := func(w Writer) {
+		require.NoError(t, w.BeginStruct())
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("b")))
+		require.NoError(t, w.WriteBlob([]byte("hi\x00\xFF")))
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("c")))
+		require.NoError(t, w.WriteClob([]byte("ok")))
+		require.NoError(t, w.FieldName(NewSymbolTokenFromString("e")))
+		require.NoError(t, w.WriteBlob(nil))
+		require.NoError(t, w.EndStruct())
+		require.NoError(t, w.BeginList())
+		require.NoError(t, w.WriteBlob([]byte{0x0A}))
+		require.NoError(t, w.WriteInt(1))
+		require.NoError(t, w.EndList())
+		require.NoError(t, w.Finish())
+	}
+
+	write := func(opts TextWriterOpts) string {
+		buf := strings.Builder{}
+		doc(NewTextWriterOpts(&buf, opts|TextWriterQuietFinish))
+		return buf.String()
+	}
+
+	// The canonical form is unaffected by default.
+	def := write(0)
+	assert.Equal(t, "{b:{{aGkA/w==}},c:{{\"ok\"}},e:{{}}}\n[{{Cg==}},1]", def)
+
+	hex := write(TextWriterHexComments)
+	assert.Equal(t, "{b:{{aGkA/w==}} /* 68 69 00 FF */,c:{{\"ok\"}} /* 6F 6B */,e:{{}}}\n[{{Cg==}} /* 0A */,1]", hex)
+
+	// The comments are just comments; the values read back the same.
+	decodeAll := func(in string) []interface{} {
+		var res []interface{}
+		d := NewDecoder(NewReaderString(in))
+		for {
+			v, err := d.Decode()
+			if err == ErrNoInput {
+				return res
+			}
+			require.NoError(t, err, in)
+			res = append(res, v)
+		}
+	}
+	for _, opts := range []TextWriterOpts{TextWriterHexComments, TextWriterHexComments | TextWriterPretty, TextWriterHexComments | TextWriterCompact} {
+		assert.Equal(t, decodeAll(def), decodeAll(write(opts)))
+	}
+
+	// Digit grouping still works alongside.
+	buf := strings.Builder{}
+	w, err := NewTextWriterDigitGroups(&buf, TextWriterHexComments|TextWriterQuietFinish, 3)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt(1234567))
+	require.NoError(t, w.WriteBlob([]byte{1}))
+	require.NoError(t, w.Finish())
+	assert.Equal(t, "1_234_567\n{{AQ==}} /* 01 */", buf.String())
+}
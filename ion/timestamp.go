@@ -153,6 +153,28 @@ func NewTimestamp(dateTime time.Time, precision TimestampPrecision, kind Timezon
 	return Timestamp{dateTime, precision, kind, numDecimalPlacesOfFractionalSeconds}
 }
 
+// NewTimestampFromTime constructs a timestamp from the given time, deriving its
+// TimezoneKind from the time's location using TimezoneKindForTime.
+func NewTimestampFromTime(dateTime time.Time, precision TimestampPrecision) Timestamp {
+	return NewTimestamp(dateTime, precision, TimezoneKindForTime(dateTime))
+}
+
+// TimezoneKindForTime returns the TimezoneKind matching the given time's zone:
+// TimezoneLocal for any non-zero offset, TimezoneUTC for a named zone with a
+// zero offset (such as time.UTC), and TimezoneUnspecified for an unnamed zone
+// with a zero offset, which is how an unknown (-00:00) offset is represented.
+func TimezoneKindForTime(t time.Time) TimezoneKind {
+	zoneName, zoneOffset := t.Zone()
+	switch {
+	case zoneOffset != 0:
+		return TimezoneLocal
+	case zoneName != "":
+		return TimezoneUTC
+	default:
+		return TimezoneUnspecified
+	}
+}
+
 // NewTimestampWithFractionalSeconds constructor
 func NewTimestampWithFractionalSeconds(dateTime time.Time, precision TimestampPrecision, kind TimezoneKind, fractionPrecision uint8) Timestamp {
 	if fractionPrecision > maxFractionalPrecision {
@@ -192,6 +214,190 @@ func NewTimestampFromStr(dateStr string, precision TimestampPrecision, kind Time
 	return NewTimestampWithFractionalSeconds(dateTime, precision, kind, fractionUnits), nil
 }
 
+// TimestampFromISOWeek creates a day-precision timestamp from an ISO 8601 week
+// date, where week is 1-53 and weekday is 1 (Monday) through 7 (Sunday). It
+// returns an error if the year does not have the given week, or if the date
+// falls outside Ion's years 1-9999, as the last days of 9999's last week do.
+func TimestampFromISOWeek(year, week, weekday int) (Timestamp, error) {
+	if year < 1 || year > 9999 {
+		return Timestamp{}, fmt.Errorf("ion: year %v out of range [1, 9999]", year)
+	}
+	if week < 1 || week > 53 {
+		return Timestamp{}, fmt.Errorf("ion: week %v out of range [1, 53]", week)
+	}
+	if weekday < 1 || weekday > 7 {
+		return Timestamp{}, fmt.Errorf("ion: weekday %v out of range [1, 7]", weekday)
+	}
+
+	// January 4th is always in week 1; find the Monday that starts it.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan4.Weekday())+6)%7 + 1
+	date := jan4.AddDate(0, 0, (week-1)*7+weekday-offset)
+
+	if y, w := date.ISOWeek(); y != year || w != week {
+		return Timestamp{}, fmt.Errorf("ion: year %v does not have week %v", year, week)
+	}
+	if date.Year() < 1 || date.Year() > 9999 {
+		return Timestamp{}, fmt.Errorf("ion: date %v out of range [0001-01-01, 9999-12-31]", date.Format("2006-01-02"))
+	}
+
+	return NewDateTimestamp(date, TimestampPrecisionDay), nil
+}
+
+// TimestampFromOrdinal creates a day-precision timestamp from an ordinal date,
+// where day is the day of the year, 1-366. It returns an error if the year does
+// not have the given day, i.e. day 366 of a non-leap year.
+func TimestampFromOrdinal(year, day int) (Timestamp, error) {
+	if year < 1 || year > 9999 {
+		return Timestamp{}, fmt.Errorf("ion: year %v out of range [1, 9999]", year)
+	}
+	if day < 1 || day > 366 {
+		return Timestamp{}, fmt.Errorf("ion: day %v out of range [1, 366]", day)
+	}
+
+	date := time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year {
+		return Timestamp{}, fmt.Errorf("ion: year %v does not have day %v", year, day)
+	}
+
+	return NewDateTimestamp(date, TimestampPrecisionDay), nil
+}
+
+// ParseTimestampLayout parses a non-Ion timestamp string using a layout in the
+// format accepted by time.Parse, e.g. time.RFC1123. The precision of the result
+// is inferred from the elements the layout contains: fractional seconds give
+// nanosecond precision (with as many fractional digits as the layout has),
+// then seconds, minutes, day, and month give the matching precision, padded
+// or not, and otherwise it is year precision. An hour without minutes gives
+// minute precision. If the layout has a time zone, a zero offset gives a UTC
+// timestamp and any other a local one; if not, the timezone is unspecified.
+// time.Parse gives a zone abbreviation it doesn't know a zero offset, so that
+// only UTC and GMT give a UTC timestamp; any other abbreviation with a zero
+// offset gives a timestamp with an unspecified timezone, at the time of day
+// given. It returns an error if the year is not between 1 and 9999, e.g. if
+// the layout has no year.
+func ParseTimestampLayout(layout, value string) (Timestamp, error) {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("ion: invalid timestamp: %v", value)
+	}
+	if t.Year() < 1 || t.Year() > 9999 {
+		return Timestamp{}, fmt.Errorf("ion: year %v out of range [1, 9999]", t.Year())
+	}
+
+	precision, digits := layoutPrecision(layout)
+	if precision <= TimestampPrecisionDay {
+		return NewDateTimestamp(t, precision), nil
+	}
+
+	kind := TimezoneUnspecified
+	if layoutHasZone(layout) {
+		kind = TimezoneLocal
+		if name, off := t.Zone(); off == 0 {
+			kind = TimezoneUnspecified
+			if name == "UTC" || name == "GMT" {
+				kind = TimezoneUTC
+			}
+		}
+	}
+
+	return NewTimestampWithFractionalSeconds(t, precision, kind, digits), nil
+}
+
+// LayoutPrecision infers the precision of timestamps parsed with the given
+// time.Parse layout, along with the number of fractional second digits. The
+// layout is scanned for its elements as time.Parse does, so that unpadded
+// ones, like the day in "Jan 2, 2006", count as well as padded ones. An hour
+// without minutes gives minute precision, since Ion has no hour precision.
+func layoutPrecision(layout string) (TimestampPrecision, uint8) {
+	precision := TimestampPrecisionYear
+	raise := func(p TimestampPrecision) {
+		if p > precision {
+			precision = p
+		}
+	}
+
+	for i := 0; i < len(layout); {
+		// Fractional seconds are a run of 0s or 9s after a '.' or ','.
+		if c := layout[i]; (c == '.' || c == ',') && i+1 < len(layout) && (layout[i+1] == '0' || layout[i+1] == '9') {
+			j := i + 1
+			for j < len(layout) && layout[j] == layout[i+1] {
+				j++
+			}
+			if j == len(layout) || !isDigit(int(layout[j])) {
+				return TimestampPrecisionNanosecond, uint8(j - i - 1)
+			}
+		}
+
+		elem, p := layoutElement(layout[i:])
+		raise(p)
+		i += len(elem)
+	}
+	return precision, 0
+}
+
+// LayoutElements are the elements of a time.Parse layout that give a
+// timestamp's precision, with the precision each gives, longest first where
+// one is a prefix of another; zones are there to be skipped over whole.
+var layoutElements = []struct {
+	elem      string
+	precision TimestampPrecision
+}{
+	{"January", TimestampPrecisionMonth},
+	{"Jan", TimestampPrecisionMonth},
+	{"Monday", TimestampPrecisionYear},
+	{"Mon", TimestampPrecisionYear},
+	{"MST", TimestampPrecisionYear},
+	{"2006", TimestampPrecisionYear},
+	{"06", TimestampPrecisionYear},
+	{"01", TimestampPrecisionMonth},
+	{"15", TimestampPrecisionMinute},
+	{"1", TimestampPrecisionMonth},
+	{"__2", TimestampPrecisionDay},
+	{"_2", TimestampPrecisionDay},
+	{"002", TimestampPrecisionDay},
+	{"02", TimestampPrecisionDay},
+	{"2", TimestampPrecisionDay},
+	{"03", TimestampPrecisionMinute},
+	{"3", TimestampPrecisionMinute},
+	{"04", TimestampPrecisionMinute},
+	{"4", TimestampPrecisionMinute},
+	{"05", TimestampPrecisionSecond},
+	{"5", TimestampPrecisionSecond},
+	{"Z07:00:00", TimestampPrecisionYear},
+	{"-07:00:00", TimestampPrecisionYear},
+	{"Z070000", TimestampPrecisionYear},
+	{"-070000", TimestampPrecisionYear},
+	{"Z07:00", TimestampPrecisionYear},
+	{"-07:00", TimestampPrecisionYear},
+	{"Z0700", TimestampPrecisionYear},
+	{"-0700", TimestampPrecisionYear},
+	{"Z07", TimestampPrecisionYear},
+	{"-07", TimestampPrecisionYear},
+}
+
+// LayoutElement returns the layout element at the start of layout, or its
+// first byte if it doesn't start with one, with the precision it gives.
+func layoutElement(layout string) (string, TimestampPrecision) {
+	for _, e := range layoutElements {
+		if strings.HasPrefix(layout, e.elem) {
+			return e.elem, e.precision
+		}
+	}
+	return layout[:1], TimestampPrecisionYear
+}
+
+// LayoutHasZone returns true if the given time.Parse layout includes a time
+// zone offset or name.
+func layoutHasZone(layout string) bool {
+	for _, z := range []string{"MST", "Z07", "-07"} {
+		if strings.Contains(layout, z) {
+			return true
+		}
+	}
+	return false
+}
+
 func invalidTimestamp(val string) (Timestamp, error) {
 	return Timestamp{}, fmt.Errorf("ion: invalid timestamp: %v", val)
 }
@@ -450,6 +656,20 @@ func (ts Timestamp) GetNumberOfFractionalSeconds() uint8 {
 	return ts.numFractionalSeconds
 }
 
+// UTC returns the timestamp converted to UTC, with its precision and number of
+// fractional seconds unchanged; this differs from calling UTC on the underlying
+// time.Time, which loses both. A timestamp with an unknown local offset (-00:00)
+// is already in UTC and only has its kind changed. A timestamp of day precision
+// or less has no time zone, and is returned as is.
+func (ts Timestamp) UTC() Timestamp {
+	if ts.precision <= TimestampPrecisionDay {
+		return ts
+	}
+	ts.dateTime = ts.dateTime.UTC()
+	ts.kind = TimezoneUTC
+	return ts
+}
+
 // String returns a formatted Timestamp string.
 func (ts Timestamp) String() string {
 	layout := ts.precision.Layout(ts.kind, ts.numFractionalSeconds)
@@ -517,6 +737,20 @@ func (ts Timestamp) String() string {
 	return format
 }
 
+// FormatLayout formats the timestamp's date and time using a layout in the
+// format accepted by time.Format, e.g. time.RFC3339, and returns an error if
+// the result is not a valid Ion timestamp, so that it can safely be written
+// back into Ion, e.g. as the text of a symbol that is later parsed with
+// ParseTimestamp. The layout, not the timestamp's precision, decides which
+// components are written; use String for the canonical Ion form.
+func (ts Timestamp) FormatLayout(layout string) (string, error) {
+	s := ts.dateTime.Format(layout)
+	if _, err := ParseTimestamp(s); err != nil {
+		return "", fmt.Errorf("ion: layout %q gives %q, which is not an Ion timestamp", layout, s)
+	}
+	return s, nil
+}
+
 // Equal figures out if two timestamps are equal for each component.
 func (ts Timestamp) Equal(ts1 Timestamp) bool {
 	_, offset := ts.dateTime.Zone()
@@ -529,6 +763,78 @@ func (ts Timestamp) Equal(ts1 Timestamp) bool {
 		ts.numFractionalSeconds == ts1.numFractionalSeconds
 }
 
+// timestampBinaryVersion is the version byte at the start of the output of
+// Timestamp.MarshalBinary.
+const timestampBinaryVersion = 1
+
+// MarshalBinary implements encoding.BinaryMarshaler, for storing a timestamp
+// with Go serialization such as encoding/gob. The result is not Ion; it is a
+// version byte, the precision, timezone kind, and number of fractional digits,
+// then the instant and its offset as encoded by time.Time.MarshalBinary.
+func (ts Timestamp) MarshalBinary() ([]byte, error) {
+	dt, err := ts.dateTime.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("ion: cannot marshal timestamp %v: %v", ts, err)
+	}
+
+	bs := make([]byte, 0, 4+len(dt))
+	bs = append(bs, timestampBinaryVersion, byte(ts.precision), byte(ts.kind), ts.numFractionalSeconds)
+	return append(bs, dt...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, reading a timestamp
+// written by MarshalBinary.
+func (ts *Timestamp) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("ion: invalid binary timestamp: too short")
+	}
+	if data[0] != timestampBinaryVersion {
+		return fmt.Errorf("ion: invalid binary timestamp: unsupported version %v", data[0])
+	}
+
+	precision, kind, digits := TimestampPrecision(data[1]), TimezoneKind(data[2]), data[3]
+	if precision == TimestampNoPrecision || precision > TimestampPrecisionNanosecond {
+		return fmt.Errorf("ion: invalid binary timestamp: invalid precision %v", data[1])
+	}
+	if kind > TimezoneLocal {
+		return fmt.Errorf("ion: invalid binary timestamp: invalid timezone kind %v", data[2])
+	}
+	if digits > maxFractionalPrecision || (digits > 0 && precision != TimestampPrecisionNanosecond) {
+		return fmt.Errorf("ion: invalid binary timestamp: invalid number of fractional seconds %v", digits)
+	}
+
+	var dt time.Time
+	if err := dt.UnmarshalBinary(data[4:]); err != nil {
+		return fmt.Errorf("ion: invalid binary timestamp: %v", err)
+	}
+
+	*ts = Timestamp{dt, precision, kind, digits}
+	return nil
+}
+
+// EncodeBinaryTimestamp returns the binary Ion encoding of a timestamp's
+// value, without the type descriptor and length that precede it in an Ion
+// stream: the offset, the UTC date and time components up to the timestamp's
+// precision, and any fractional seconds. DecodeBinaryTimestamp reverses it.
+func EncodeBinaryTimestamp(ts Timestamp) []byte {
+	offset, utc := utcTimestamp(ts)
+	return appendTimestamp(make([]byte, 0, timestampLen(offset, utc)), offset, utc)
+}
+
+// DecodeBinaryTimestamp decodes a timestamp value encoded as by
+// EncodeBinaryTimestamp, which must make up the whole of b.
+func DecodeBinaryTimestamp(b []byte) (Timestamp, error) {
+	if len(b) == 0 {
+		return Timestamp{}, &SyntaxError{"invalid timestamp - no offset", 0}
+	}
+
+	bits := bitstream{}
+	bits.InitBytes(b)
+	bits.code = bitcodeTimestamp
+	bits.len = uint64(len(b))
+	return bits.ReadTimestamp()
+}
+
 // TruncatedNanoseconds returns nanoseconds with trailing values removed up to the difference of max fractional precision - time stamp's fractional precision
 // e.g. 123456000 with fractional precision: 3 will get truncated to 123.
 func (ts Timestamp) TruncatedNanoseconds() int {
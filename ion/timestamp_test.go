@@ -16,6 +16,8 @@
 package ion
 
 import (
+	"bytes"
+	"encoding/gob"
 	"testing"
 	"time"
 
@@ -370,3 +372,326 @@ func TestTruncateNanoseconds(t *testing.T) {
 		})
 	}
 }
+
+func TestTimestampFromISOWeek(t *testing.T) {
+	test := func(year, week, weekday int, expected string) {
+		t.Run(expected, func(t *testing.T) {
+			ts, err := TimestampFromISOWeek(year, week, weekday)
+			require.NoError(t, err)
+			assert.Equal(t, MustParseTimestamp(expected), ts)
+			assert.Equal(t, expected+"T", ts.String())
+		})
+	}
+
+	test(2020, 1, 1, "2019-12-30")
+	test(2020, 10, 3, "2020-03-04")
+	test(2020, 53, 7, "2021-01-03")
+	test(2009, 1, 1, "2008-12-29")
+	test(2009, 53, 7, "2010-01-03")
+	test(2021, 52, 5, "2021-12-31")
+	test(1, 1, 1, "0001-01-01")
+	test(9999, 52, 5, "9999-12-31")
+
+	testError := func(year, week, weekday int) {
+		_, err := TimestampFromISOWeek(year, week, weekday)
+		assert.Error(t, err, "%v-W%v-%v", year, week, weekday)
+	}
+
+	testError(2021, 53, 1)
+	testError(2020, 0, 1)
+	testError(2020, 54, 1)
+	testError(2020, 1, 0)
+	testError(2020, 1, 8)
+	testError(0, 1, 1)
+	testError(10000, 1, 1)
+	testError(9999, 52, 6)
+	testError(9999, 52, 7)
+}
+
+func TestTimestampFromOrdinal(t *testing.T) {
+	test := func(year, day int, expected string) {
+		t.Run(expected, func(t *testing.T) {
+			ts, err := TimestampFromOrdinal(year, day)
+			require.NoError(t, err)
+			assert.Equal(t, MustParseTimestamp(expected), ts)
+		})
+	}
+
+	test(2021, 1, "2021-01-01")
+	test(2021, 60, "2021-03-01")
+	test(2020, 60, "2020-02-29")
+	test(2020, 366, "2020-12-31")
+	test(2021, 365, "2021-12-31")
+
+	testError := func(year, day int) {
+		_, err := TimestampFromOrdinal(year, day)
+		assert.Error(t, err, "%v-%v", year, day)
+	}
+
+	testError(2021, 366)
+	testError(2021, 0)
+	testError(2020, 367)
+	testError(0, 1)
+}
+
+func TestParseTimestampLayout(t *testing.T) {
+	test := func(layout, value, expected string) {
+		t.Run(value, func(t *testing.T) {
+			ts, err := ParseTimestampLayout(layout, value)
+			require.NoError(t, err)
+			assert.Equal(t, expected, ts.String())
+		})
+	}
+
+	test(time.RFC1123, "Mon, 02 Jan 2006 15:04:05 GMT", "2006-01-02T15:04:05Z")
+	test(time.RFC1123Z, "Mon, 02 Jan 2006 15:04:05 -0700", "2006-01-02T15:04:05-07:00")
+	test(time.RFC3339Nano, "2006-01-02T15:04:05.12Z", "2006-01-02T15:04:05.120000000Z")
+	test("2006-01-02 15:04:05.000", "2006-01-02 15:04:05.123", "2006-01-02T15:04:05.123-00:00")
+	test("01/02/2006 15:04", "01/02/2006 15:04", "2006-01-02T15:04-00:00")
+	test("01/02/2006", "12/31/1999", "1999-12-31T")
+	test("Jan 2006", "Feb 2020", "2020-02T")
+	test("2006", "2020", "2020T")
+
+	// Unpadded elements count as much as padded ones.
+	test("Jan 2, 2006", "Feb 3, 2020", "2020-02-03T")
+	test("1/2/2006", "12/31/1999", "1999-12-31T")
+	test("1/_2/06", "3/ 7/21", "2021-03-07T")
+	test("3:4:5 PM, Jan 2 2006", "9:30:15 AM, Mar 7 2021", "2021-03-07T09:30:15-00:00")
+	test("2006-002", "2020-032", "2020-02-01T")
+	test("2006.01.02", "2020.01.02", "2020-01-02T")
+
+	// Ion has no hour precision, so an hour alone gives minutes.
+	test("2006-01-02 15h", "2020-01-02 07h", "2020-01-02T07:00-00:00")
+
+	// An unknown zone abbreviation has an unknown offset, not a zero one.
+	test(time.RFC1123, "Mon, 02 Jan 2006 15:04:05 XYZ", "2006-01-02T15:04:05-00:00")
+	test(time.RFC1123, "Mon, 02 Jan 2006 15:04:05 UTC", "2006-01-02T15:04:05Z")
+	test(time.RFC3339, "2006-01-02T15:04:05+00:00", "2006-01-02T15:04:05Z")
+
+	_, err := ParseTimestampLayout(time.RFC1123, "2006-01-02T15:04:05Z")
+	assert.Error(t, err)
+
+	// The year must be in Ion's range, so a layout needs one.
+	_, err = ParseTimestampLayout("Jan 2", "Feb 3")
+	assert.Error(t, err)
+	_, err = ParseTimestampLayout("2006-01-02", "0000-01-02")
+	assert.Error(t, err)
+}
+
+func TestTimestampFormatLayout(t *testing.T) {
+	ts := NewTimestampWithFractionalSeconds(time.Date(2021, 6, 1, 9, 4, 5, 120000000, time.FixedZone("", -7*60*60)),
+		TimestampPrecisionNanosecond, TimezoneLocal, 3)
+
+	test := func(layout, expected string) {
+		t.Run(layout, func(t *testing.T) {
+			s, err := ts.FormatLayout(layout)
+			require.NoError(t, err)
+			assert.Equal(t, expected, s)
+
+			_, err = ParseTimestamp(s)
+			assert.NoError(t, err)
+		})
+	}
+
+	test(time.RFC3339, "2021-06-01T09:04:05-07:00")
+	test(time.RFC3339Nano, "2021-06-01T09:04:05.12-07:00")
+	test("2006-01-02T15:04:05.000000Z07:00", "2021-06-01T09:04:05.120000-07:00")
+	test("2006-01-02T15:04Z07:00", "2021-06-01T09:04-07:00")
+	test("2006-01-02T", "2021-06-01T")
+	test("2006-01T", "2021-06T")
+	test("2006T", "2021T")
+
+	testError := func(layout string) {
+		t.Run(layout, func(t *testing.T) {
+			_, err := ts.FormatLayout(layout)
+			assert.Error(t, err)
+		})
+	}
+
+	testError(time.RFC1123)
+	testError(time.Kitchen)
+	testError("2006-01-02 15:04:05")
+	testError("2006-01-02T15:04:05")
+	testError("01/02/2006")
+}
+
+func TestTimezoneKindForTime(t *testing.T) {
+	test := func(name string, loc *time.Location, expected TimezoneKind) {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, expected, TimezoneKindForTime(time.Date(2021, 6, 1, 12, 0, 0, 0, loc)))
+		})
+	}
+
+	test("utc", time.UTC, TimezoneUTC)
+	test("named zero", time.FixedZone("GMT", 0), TimezoneUTC)
+	test("unnamed zero", time.FixedZone("", 0), TimezoneUnspecified)
+	test("fixed positive", time.FixedZone("", 5*3600+30*60), TimezoneLocal)
+	test("fixed negative", time.FixedZone("EST", -5*3600), TimezoneLocal)
+
+	if ny, err := time.LoadLocation("America/New_York"); err == nil {
+		test("location", ny, TimezoneLocal)
+	}
+}
+
+func TestNewTimestampFromTime(t *testing.T) {
+	test := func(dateTime time.Time, precision TimestampPrecision, expected string) {
+		t.Run(expected, func(t *testing.T) {
+			ts := NewTimestampFromTime(dateTime, precision)
+			assert.Equal(t, expected, ts.String())
+		})
+	}
+
+	test(time.Date(2021, 6, 1, 12, 30, 15, 0, time.UTC), TimestampPrecisionSecond, "2021-06-01T12:30:15Z")
+	test(time.Date(2021, 6, 1, 12, 30, 15, 0, time.FixedZone("", 5*3600+30*60)), TimestampPrecisionMinute, "2021-06-01T12:30+05:30")
+	test(time.Date(2021, 6, 1, 12, 30, 15, 0, time.FixedZone("", -8*3600)), TimestampPrecisionSecond, "2021-06-01T12:30:15-08:00")
+	test(time.Date(2021, 6, 1, 12, 30, 15, 0, time.FixedZone("", 0)), TimestampPrecisionSecond, "2021-06-01T12:30:15-00:00")
+	test(time.Date(2021, 6, 1, 12, 30, 15, 0, time.UTC), TimestampPrecisionDay, "2021-06-01T")
+	test(time.Date(2021, 6, 1, 12, 30, 15, 123456789, time.UTC), TimestampPrecisionNanosecond, "2021-06-01T12:30:15.123456789Z")
+
+	local := time.Date(2021, 6, 1, 12, 30, 15, 0, time.Local)
+	ts := NewTimestampFromTime(local, TimestampPrecisionSecond)
+	assert.Equal(t, TimezoneKindForTime(local), ts.GetTimezoneKind())
+	assert.True(t, local.Equal(ts.GetDateTime()))
+}
+
+func TestTimestampUTC(t *testing.T) {
+	test := func(in, expected string) {
+		t.Run(in, func(t *testing.T) {
+			var ts Timestamp
+			require.NoError(t, UnmarshalString(in, &ts))
+
+			utc := ts.UTC()
+			assert.Equal(t, expected, utc.String())
+			assert.Equal(t, ts.GetPrecision(), utc.GetPrecision())
+			assert.Equal(t, ts.GetNumberOfFractionalSeconds(), utc.GetNumberOfFractionalSeconds())
+			assert.True(t, ts.GetDateTime().Equal(utc.GetDateTime()))
+		})
+	}
+
+	test("2021T", "2021T")
+	test("2021-06T", "2021-06T")
+	test("2021-06-01T", "2021-06-01T")
+	test("2021-06-01T23:30+05:30", "2021-06-01T18:00Z")
+	test("2021-06-01T20:30-04:00", "2021-06-02T00:30Z")
+	test("2021-06-01T12:30:15-00:00", "2021-06-01T12:30:15Z")
+	test("2021-06-01T12:30:15Z", "2021-06-01T12:30:15Z")
+	test("2021-06-01T12:30:15.120+01:00", "2021-06-01T11:30:15.120Z")
+	test("2021-12-31T23:59:59.123456789-08:00", "2022-01-01T07:59:59.123456789Z")
+
+	ts := NewDateTimestamp(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), TimestampPrecisionDay)
+	assert.Equal(t, TimezoneUnspecified, ts.UTC().GetTimezoneKind())
+}
+
+func TestTimestampMarshalBinary(t *testing.T) {
+	test := func(ts Timestamp) {
+		t.Run(ts.String(), func(t *testing.T) {
+			bs, err := ts.MarshalBinary()
+			require.NoError(t, err)
+
+			var out Timestamp
+			require.NoError(t, out.UnmarshalBinary(bs))
+			assert.True(t, ts.Equal(out), "%v != %v", ts, out)
+			assert.Equal(t, ts.String(), out.String())
+
+			// And through encoding/gob, as a struct field.
+			type holder struct {
+				TS Timestamp
+			}
+			buf := bytes.Buffer{}
+			require.NoError(t, gob.NewEncoder(&buf).Encode(holder{ts}))
+			var h holder
+			require.NoError(t, gob.NewDecoder(&buf).Decode(&h))
+			assert.True(t, ts.Equal(h.TS), "%v != %v", ts, h.TS)
+		})
+	}
+
+	parse := func(s string) Timestamp {
+		ts, err := ParseTimestamp(s)
+		require.NoError(t, err)
+		return ts
+	}
+
+	test(parse("2021T"))
+	test(parse("2021-06T"))
+	test(parse("2021-06-01T"))
+	test(parse("2021-06-01T12:30Z"))
+	test(parse("2021-06-01T12:30:15-00:00"))
+	test(parse("2021-06-01T12:30:15+05:30"))
+	test(parse("2021-06-01T12:30:15.0Z"))
+	test(parse("2021-06-01T12:30:15.120-08:00"))
+	test(parse("2021-06-01T12:30:15.123456789+14:00"))
+	test(parse("0001-01-01T00:00:00.000000001Z"))
+	test(parse("9999-12-31T23:59:59.999999999-23:59"))
+
+	var ts Timestamp
+	assert.Error(t, ts.UnmarshalBinary(nil))
+	assert.Error(t, ts.UnmarshalBinary([]byte{2, 1, 0, 0}))
+
+	good, err := parse("2021-06-01T12:30:15.12Z").MarshalBinary()
+	require.NoError(t, err)
+	for i, b := range []byte{0, 9, 9, 10} {
+		bad := append([]byte{}, good...)
+		bad[i] = b
+		assert.Error(t, ts.UnmarshalBinary(bad), "byte %v", i)
+	}
+	assert.Error(t, ts.UnmarshalBinary(good[:len(good)-1]))
+}
+
+func TestEncodeBinaryTimestamp(t *testing.T) {
+	test := func(s string) {
+		t.Run(s, func(t *testing.T) {
+			ts, err := ParseTimestamp(s)
+			require.NoError(t, err)
+
+			bs := EncodeBinaryTimestamp(ts)
+			out, err := DecodeBinaryTimestamp(bs)
+			require.NoError(t, err)
+			assert.True(t, ts.Equal(out), "%v != %v", ts, out)
+			assert.Equal(t, s, out.String())
+
+			// The same bytes follow the type descriptor in a binary Ion stream.
+			buf := bytes.Buffer{}
+			w := NewBinaryWriter(&buf)
+			require.NoError(t, w.WriteTimestamp(ts))
+			require.NoError(t, w.Finish())
+			assert.True(t, bytes.HasSuffix(buf.Bytes(), bs))
+		})
+	}
+
+	test("2021T")
+	test("2021-06T")
+	test("2021-06-01T")
+	test("2021-06-01T12:30Z")
+	test("2021-06-01T12:30-00:00")
+	test("2021-06-01T12:30:15-00:00")
+	test("2021-06-01T12:30:15+05:30")
+	test("2021-06-01T12:30:15.0Z")
+	test("2021-06-01T12:30:15.120-08:00")
+	test("2021-06-01T12:30:15.123456789+14:00")
+	test("0001-01-01T00:00:00.000000001Z")
+	test("9999-12-31T23:59:59.999999999-23:59")
+
+	ts, err := ParseTimestamp("2000-05-06T07:08:09+01:00")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xBC,       // offset: +60
+		0x0F, 0xD0, // year: 2000
+		0x85, // month: 5
+		0x86, // day: 6
+		0x86, // hour: 6 (UTC)
+		0x88, // minute: 8
+		0x89, // second: 9
+	}, EncodeBinaryTimestamp(ts))
+
+	for _, bad := range [][]byte{
+		nil,
+		{0x80},                               // No year.
+		{0x80, 0x0F},                         // Truncated year.
+		{0x80, 0x0F, 0xD0, 0x85, 0x86, 0x86}, // Hour without minute.
+		{0x80, 0x0F, 0xD0, 0x8D},             // Month 13.
+		{0x80, 0x0F, 0xD0, 0x85, 0x86, 0x98, 0x80, 0x80}, // Hour 24.
+	} {
+		_, err := DecodeBinaryTimestamp(bad)
+		assert.Error(t, err, "%x", bad)
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 type token int
@@ -126,6 +128,10 @@ func (t token) String() string {
 	}
 }
 
+// Crlf stands in the tokenizer's buffer for a '\n' that was a \r\n in the
+// input, so that it counts as the two bytes it was.
+const crlf = -2
+
 type tokenizer struct {
 	in     *bufio.Reader
 	buffer []int
@@ -133,6 +139,40 @@ type tokenizer struct {
 	token      token
 	unfinished bool
 	pos        uint64
+
+	// Consumed counts the bytes read from in, including any that have been
+	// peeked at and are waiting in buffer. Bit i of crlfs is set if the i'th
+	// most recently read character was a \r\n normalized to '\n', so that it
+	// goes back into buffer as a crlf, still counting two bytes, if unread.
+	consumed uint64
+	crlfs    uint64
+
+	// TokOffset is the offset, in bytes, of the first character of the
+	// current token.
+	tokOffset uint64
+
+	// If keepTape is set, every character read is recorded in tape (and
+	// removed again if it's unread), for readers that keep trivia or raw
+	// values. tokStart is the offset in tape of the first character of the
+	// current token, and longEnd, if the token is a long string, the offset
+	// just past its last closing quotes, before any comments that follow.
+	keepTape bool
+	tape     []byte
+	tokStart int
+	longEnd  int
+
+	// If stopAtBinary is set, Next reports the end of the input when it finds
+	// a binary version marker, leaving the marker unread for a binary reader
+	// to pick up; atBinary records that it did.
+	stopAtBinary bool
+	atBinary     bool
+
+	// Peeked backs the slices returned by peekN, buf is reused to build the
+	// text of numbers and timestamps, and name holds the text of the last
+	// field name read by ReadFieldName.
+	peeked []int
+	buf    bytes.Buffer
+	name   bytes.Buffer
 }
 
 func tokenizeString(in string) *tokenizer {
@@ -158,6 +198,22 @@ func (t *tokenizer) Pos() uint64 {
 	return t.pos
 }
 
+// Offset returns the number of bytes of input read so far, not counting any
+// that have been peeked at and are waiting in buffer.
+func (t *tokenizer) offset() uint64 {
+	n := t.consumed
+	for _, c := range t.buffer {
+		switch c {
+		case -1:
+		case crlf:
+			n -= 2
+		default:
+			n--
+		}
+	}
+	return n
+}
+
 // Next advances to the next token in the input stream.
 func (t *tokenizer) Next() error {
 	var c int
@@ -172,11 +228,27 @@ func (t *tokenizer) Next() error {
 	if err != nil {
 		return err
 	}
+	t.tokOffset = t.offset()
+	if c != -1 {
+		t.tokOffset--
+	}
+	if t.keepTape {
+		t.tokStart = len(t.tape)
+		if c != -1 {
+			t.tokStart--
+		}
+		t.longEnd = -1
+	}
 
 	switch {
 	case c == -1:
 		return t.ok(tokenEOF, true)
 
+	case c == 0xE0 && t.stopAtBinary && t.atBinaryIVM():
+		t.unread(c)
+		t.atBinary = true
+		return t.ok(tokenEOF, true)
+
 	case c == ':':
 		c2, err := t.peek()
 		if err != nil {
@@ -326,6 +398,22 @@ func (t *tokenizer) ok(tok token, more bool) error {
 	return nil
 }
 
+// Resume forgets that the end of the input was reached, so that tokenizing can
+// continue after more input has been made available.
+func (t *tokenizer) resume() {
+	buf := t.buffer[:0]
+	for _, c := range t.buffer {
+		if c != -1 {
+			buf = append(buf, c)
+		}
+	}
+	t.buffer = buf
+
+	if t.token == tokenEOF {
+		t.unfinished = false
+	}
+}
+
 // SetFinished marks the current token finished (indicating that the caller has
 // chosen to step in to a list, sexp, or struct and Next should not skip over its
 // contents in search of the next token).
@@ -356,16 +444,12 @@ func (t *tokenizer) ReadValue(tok token) (string, error) {
 	var err error
 
 	switch tok {
-	case tokenSymbol:
-		str, err = t.readSymbol()
-	case tokenSymbolQuoted:
-		str, err = t.readQuotedSymbol()
+	case tokenSymbol, tokenSymbolQuoted, tokenString, tokenLongString:
+		ret := strings.Builder{}
+		err = t.readText(tok, &ret)
+		str = ret.String()
 	case tokenSymbolOperator, tokenDot:
 		str, err = t.readOperator()
-	case tokenString:
-		str, err = t.readString()
-	case tokenLongString:
-		str, err = t.readLongString()
 	case tokenBinary:
 		str, err = t.readBinary()
 	case tokenHex:
@@ -384,9 +468,49 @@ func (t *tokenizer) ReadValue(tok token) (string, error) {
 	return str, nil
 }
 
+// ReadFieldName reads the text of a field name token of the given type. The
+// returned slice is reused by the next call.
+func (t *tokenizer) ReadFieldName(tok token) ([]byte, error) {
+	t.name.Reset()
+	if err := t.readText(tok, &t.name); err != nil {
+		return nil, err
+	}
+
+	t.unfinished = false
+	if t.name.Len() == 0 {
+		// Bytes returns nil for a buffer that has never been written to.
+		return []byte{}, nil
+	}
+	return t.name.Bytes(), nil
+}
+
+// A textBuilder accumulates the text of a symbol or string as it is read.
+type textBuilder interface {
+	io.ByteWriter
+	WriteRune(r rune) (int, error)
+}
+
+// ReadText reads the text of a symbol or string token of the given type
+// into w.
+func (t *tokenizer) readText(tok token, w textBuilder) error {
+	switch tok {
+	case tokenSymbol:
+		return t.readSymbolTo(w)
+	case tokenSymbolQuoted:
+		return t.readQuotedSymbolTo(w)
+	case tokenString:
+		return t.readStringTo(w)
+	case tokenLongString:
+		return t.readLongStringTo(w)
+	default:
+		panic(fmt.Sprintf("unsupported token type %v", tok))
+	}
+}
+
 // ReadNumber reads a number and determines the type.
 func (t *tokenizer) ReadNumber() (string, Type, error) {
-	w := strings.Builder{}
+	w := &t.buf
+	w.Reset()
 
 	c, err := t.read()
 	if err != nil {
@@ -404,7 +528,7 @@ func (t *tokenizer) ReadNumber() (string, Type, error) {
 	first := c
 	oldlen := w.Len()
 
-	c, err = t.readDigits(c, &w)
+	c, err = t.readDigits(c, w)
 	if err != nil {
 		return "", NoType, err
 	}
@@ -424,7 +548,7 @@ func (t *tokenizer) ReadNumber() (string, Type, error) {
 		if c, err = t.read(); err != nil {
 			return "", NoType, err
 		}
-		if c, err = t.readDigits(c, &w); err != nil {
+		if c, err = t.readDigits(c, w); err != nil {
 			return "", NoType, err
 		}
 	}
@@ -434,7 +558,7 @@ func (t *tokenizer) ReadNumber() (string, Type, error) {
 		tt = FloatType
 
 		w.WriteByte(byte(c))
-		if c, err = t.readExponent(&w); err != nil {
+		if c, err = t.readExponent(w); err != nil {
 			return "", NoType, err
 		}
 
@@ -442,7 +566,7 @@ func (t *tokenizer) ReadNumber() (string, Type, error) {
 		tt = DecimalType
 
 		w.WriteByte(byte(c))
-		if c, err = t.readExponent(&w); err != nil {
+		if c, err = t.readExponent(w); err != nil {
 			return "", NoType, err
 		}
 	}
@@ -475,6 +599,10 @@ func (t *tokenizer) readExponent(w io.ByteWriter) (int, error) {
 		}
 	}
 
+	// An exponent must have at least one digit.
+	if !isDigit(c) {
+		return 0, t.invalidChar(c)
+	}
 	return t.readDigits(c, w)
 }
 
@@ -493,61 +621,79 @@ func (t *tokenizer) readDigits(c int, w io.ByteWriter) (int, error) {
 // ReadSymbol reads an unquoted symbol value.
 func (t *tokenizer) readSymbol() (string, error) {
 	ret := strings.Builder{}
+	if err := t.readSymbolTo(&ret); err != nil {
+		return "", err
+	}
+	return ret.String(), nil
+}
 
+// ReadSymbolTo reads an unquoted symbol value into ret.
+func (t *tokenizer) readSymbolTo(ret textBuilder) error {
 	c, err := t.peek()
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	for isIdentifierPart(c) {
 		ret.WriteByte(byte(c))
 		_, err = t.read()
 		if err != nil {
-			return "", err
+			return err
 		}
 		c, err = t.peek()
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
 
-	return ret.String(), nil
+	return nil
 }
 
 // ReadQuotedSymbol reads a quoted symbol.
 func (t *tokenizer) readQuotedSymbol() (string, error) {
 	ret := strings.Builder{}
+	if err := t.readQuotedSymbolTo(&ret); err != nil {
+		return "", err
+	}
+	return ret.String(), nil
+}
 
+// ReadQuotedSymbolTo reads a quoted symbol into ret.
+func (t *tokenizer) readQuotedSymbolTo(ret textBuilder) error {
 	for {
 		c, err := t.read()
 		if err != nil {
-			return "", err
+			return err
+		}
+
+		if isProhibitedControlChar(c) {
+			return t.invalidChar(c)
 		}
 
 		switch c {
 		case -1, '\n':
-			return "", t.invalidChar(c)
+			return t.invalidChar(c)
 
 		case '\'':
-			return ret.String(), nil
+			return nil
 
 		case '\\':
 			c, err = t.peek()
 			if err != nil {
-				return "", err
+				return err
 			}
 
 			if c == '\n' {
 				_, err = t.read()
 				if err != nil {
-					return "", err
+					return err
 				}
 				continue
 			}
 
 			r, err := t.readEscapedChar(nonClobText)
 			if err != nil {
-				return "", err
+				return err
 			}
 			ret.WriteRune(r)
 
@@ -580,28 +726,26 @@ func (t *tokenizer) readOperator() (string, error) {
 	return ret.String(), nil
 }
 
-// ReadString reads a quoted string.
-func (t *tokenizer) readString() (string, error) {
-	ret := strings.Builder{}
-
+// ReadStringTo reads a quoted string into ret.
+func (t *tokenizer) readStringTo(ret textBuilder) error {
 	for {
 		c, err := t.read()
 		if err != nil {
-			return "", err
+			return err
 		}
 		// -1 denotes EOF, and new lines are not allowed in short string
 		if c == -1 || c == '\n' || isProhibitedControlChar(c) {
-			return "", t.invalidChar(c)
+			return t.invalidChar(c)
 		}
 
 		switch c {
 		case '"':
-			return ret.String(), nil
+			return nil
 
 		case '\\':
-			err = processBackslashInString(t, &ret)
+			err = processBackslashInString(t, ret)
 			if err != nil {
-				return "", err
+				return err
 			}
 
 		default:
@@ -644,37 +788,35 @@ func (t *tokenizer) readClob() ([]byte, error) {
 	}
 }
 
-// ReadLongString reads a triple-quoted string.
-func (t *tokenizer) readLongString() (string, error) {
-	ret := strings.Builder{}
-
+// ReadLongStringTo reads a triple-quoted string into ret.
+func (t *tokenizer) readLongStringTo(ret textBuilder) error {
 	for {
 		c, err := t.read()
 		if err != nil {
-			return "", err
+			return err
 		}
 		// -1 denotes EOF
 		if c == -1 || isProhibitedControlChar(c) {
-			return "", t.invalidChar(c)
+			return t.invalidChar(c)
 		}
 
 		switch c {
 		case '\'':
 			isEndOfString, isConsumed, err := t.skipEndOfLongString(t.skipCommentsHandler)
 			if err != nil {
-				return "", err
+				return err
 			}
 			if isEndOfString {
-				return ret.String(), nil
+				return nil
 			}
 			if !isConsumed {
 				// No character has been consumed. It is a single '.
 				ret.WriteByte(byte(c))
 			}
 		case '\\':
-			err = processBackslashInString(t, &ret)
+			err = processBackslashInString(t, ret)
 			if err != nil {
-				return "", err
+				return err
 			}
 
 		default:
@@ -765,12 +907,26 @@ func (t *tokenizer) readEscapedChar(isClob bool) (rune, error) {
 		if isClob {
 			return 0, t.invalidChar('U')
 		}
-		return t.readHexEscapeSeq(8)
+		r, err := t.readHexEscapeSeq(8)
+		if err != nil {
+			return 0, err
+		}
+		if r > utf8.MaxRune || utf16.IsSurrogate(r) {
+			return 0, &SyntaxError{fmt.Sprintf("invalid code point U+%X in escape sequence", r), t.pos - 10}
+		}
+		return r, nil
 	case 'u':
 		if isClob {
 			return 0, t.invalidChar('u')
 		}
-		return t.readHexEscapeSeq(4)
+		r, err := t.readHexEscapeSeq(4)
+		if err != nil {
+			return 0, err
+		}
+		if utf16.IsSurrogate(r) {
+			return t.readLowSurrogate(r)
+		}
+		return r, nil
 	case 'x':
 		return t.readHexEscapeSeq(2)
 	}
@@ -778,6 +934,26 @@ func (t *tokenizer) readEscapedChar(isClob bool) (rune, error) {
 	return 0, &SyntaxError{fmt.Sprintf("bad escape sequence '\\%c'", c), t.pos - 2}
 }
 
+// ReadLowSurrogate reads the \u escape of the low surrogate that must follow
+// the escaped high surrogate hi, returning the code point the pair encodes.
+func (t *tokenizer) readLowSurrogate(hi rune) (rune, error) {
+	start := t.pos - 6
+
+	if cs, err := t.peekN(2); err == nil && cs[0] == '\\' && cs[1] == 'u' {
+		t.read()
+		t.read()
+		lo, err := t.readHexEscapeSeq(4)
+		if err != nil {
+			return 0, err
+		}
+		if r := utf16.DecodeRune(hi, lo); r != utf8.RuneError {
+			return r, nil
+		}
+	}
+
+	return 0, &SyntaxError{fmt.Sprintf("unpaired surrogate U+%X in escape sequence", hi), start}
+}
+
 func (t *tokenizer) readHexEscapeSeq(length int) (rune, error) {
 	val := rune(0)
 
@@ -867,10 +1043,15 @@ func (t *tokenizer) readRadix(isRadixMarker, isValidForRadix matcher) (string, e
 	if nextChar == '_' {
 		return "", t.invalidChar(c)
 	}
+	prefix := w.Len()
 	c, err = t.readRadixDigits(isValidForRadix, &w)
 	if err != nil {
 		return "", err
 	}
+	if w.Len() == prefix {
+		// There must be at least one digit after the 0x or 0b.
+		return "", t.invalidChar(c)
+	}
 
 	ok, err := t.isStopChar(c)
 	if err != nil {
@@ -914,9 +1095,10 @@ func (t *tokenizer) readRadixDigits(isValidForRadix matcher, w io.ByteWriter) (i
 }
 
 func (t *tokenizer) readTimestamp() (string, error) {
-	w := strings.Builder{}
+	w := &t.buf
+	w.Reset()
 
-	c, err := t.readTimestampDigits(4, &w)
+	c, err := t.readTimestampDigits(4, w)
 	if err != nil {
 		return "", err
 	}
@@ -930,7 +1112,7 @@ func (t *tokenizer) readTimestamp() (string, error) {
 	}
 	w.WriteByte('-')
 
-	if c, err = t.readTimestampDigits(2, &w); err != nil {
+	if c, err = t.readTimestampDigits(2, w); err != nil {
 		return "", err
 	}
 	if c == 'T' {
@@ -943,12 +1125,12 @@ func (t *tokenizer) readTimestamp() (string, error) {
 	}
 	w.WriteByte('-')
 
-	if c, err = t.readTimestampDigits(2, &w); err != nil {
+	if c, err = t.readTimestampDigits(2, w); err != nil {
 		return "", err
 	}
 	if c != 'T' {
 		// yyyy-mm-dd
-		return t.readTimestampFinish(c, &w)
+		return t.readTimestampFinish(c, w)
 	}
 	w.WriteByte('T')
 
@@ -957,14 +1139,14 @@ func (t *tokenizer) readTimestamp() (string, error) {
 	}
 	if !isDigit(c) {
 		// yyyy-mm-ddT(+hh:mm)?
-		if c, err = t.readTimestampOffset(c, &w); err != nil {
+		if c, err = t.readTimestampOffset(c, w); err != nil {
 			return "", err
 		}
-		return t.readTimestampFinish(c, &w)
+		return t.readTimestampFinish(c, w)
 	}
 	w.WriteByte(byte(c))
 
-	if c, err = t.readTimestampDigits(1, &w); err != nil {
+	if c, err = t.readTimestampDigits(1, w); err != nil {
 		return "", err
 	}
 	if c != ':' {
@@ -972,27 +1154,27 @@ func (t *tokenizer) readTimestamp() (string, error) {
 	}
 	w.WriteByte(':')
 
-	if c, err = t.readTimestampDigits(2, &w); err != nil {
+	if c, err = t.readTimestampDigits(2, w); err != nil {
 		return "", err
 	}
 	if c != ':' {
 		// yyyy-mm-ddThh:mmZ
-		if c, err = t.readTimestampOffsetOrZ(c, &w); err != nil {
+		if c, err = t.readTimestampOffsetOrZ(c, w); err != nil {
 			return "", err
 		}
-		return t.readTimestampFinish(c, &w)
+		return t.readTimestampFinish(c, w)
 	}
 	w.WriteByte(':')
 
-	if c, err = t.readTimestampDigits(2, &w); err != nil {
+	if c, err = t.readTimestampDigits(2, w); err != nil {
 		return "", err
 	}
 	if c != '.' {
 		// yyyy-mm-ddThh:mm:ssZ
-		if c, err = t.readTimestampOffsetOrZ(c, &w); err != nil {
+		if c, err = t.readTimestampOffsetOrZ(c, w); err != nil {
 			return "", err
 		}
-		return t.readTimestampFinish(c, &w)
+		return t.readTimestampFinish(c, w)
 	}
 	w.WriteByte('.')
 
@@ -1001,15 +1183,15 @@ func (t *tokenizer) readTimestamp() (string, error) {
 		return "", err
 	}
 	if isDigit(c) {
-		if c, err = t.readDigits(c, &w); err != nil {
+		if c, err = t.readDigits(c, w); err != nil {
 			return "", err
 		}
 	}
 
-	if c, err = t.readTimestampOffsetOrZ(c, &w); err != nil {
+	if c, err = t.readTimestampOffsetOrZ(c, w); err != nil {
 		return "", err
 	}
-	return t.readTimestampFinish(c, &w)
+	return t.readTimestampFinish(c, w)
 }
 
 func (t *tokenizer) readTimestampOffsetOrZ(c int, w io.ByteWriter) (int, error) {
@@ -1324,7 +1506,8 @@ func (t *tokenizer) skipN(n int) error {
 // because of an EOF (or other error), it returns the bytes it was
 // able to peek at along with the error.
 func (t *tokenizer) peekN(n int) ([]int, error) {
-	var ret []int
+	// The returned slice is reused by the next call; callers only look.
+	ret := t.peeked[:0]
 	var err error
 
 	// Read ahead.
@@ -1348,6 +1531,7 @@ func (t *tokenizer) peekN(n int) ([]int, error) {
 	for i := len(ret) - 1; i >= 0; i-- {
 		t.unread(ret[i])
 	}
+	t.peeked = ret
 
 	return ret, err
 }
@@ -1357,7 +1541,10 @@ func (t *tokenizer) peekN(n int) ([]int, error) {
 func (t *tokenizer) peek() (int, error) {
 	if len(t.buffer) > 0 {
 		// Short-circuit and peek from the buffer.
-		return t.buffer[len(t.buffer)-1], nil
+		if c := t.buffer[len(t.buffer)-1]; c != crlf {
+			return c, nil
+		}
+		return '\n', nil
 	}
 
 	c, err := t.read()
@@ -1369,16 +1556,49 @@ func (t *tokenizer) peek() (int, error) {
 	return c, nil
 }
 
+// AtBinaryIVM returns true if the 0xE0 byte just read starts a binary version
+// marker.
+func (t *tokenizer) atBinaryIVM() bool {
+	next := t.pending()
+	if len(next) < 3 {
+		bs, err := t.in.Peek(3 - len(next))
+		if err != nil {
+			return false
+		}
+		next = append(next, bs...)
+	}
+	return next[0] == 0x01 && next[1] == 0x00 && next[2] == 0xEA
+}
+
+// Pending returns the bytes that have been peeked at but not yet read, in
+// the order they will be read.
+func (t *tokenizer) pending() []byte {
+	bs := make([]byte, 0, len(t.buffer))
+	for i := len(t.buffer) - 1; i >= 0 && t.buffer[i] != -1; i-- {
+		if t.buffer[i] == crlf {
+			bs = append(bs, '\r', '\n')
+		} else {
+			bs = append(bs, byte(t.buffer[i]))
+		}
+	}
+	return bs
+}
+
 // Read reads a byte of input from the underlying reader. EOF is
 // returned as (-1, nil) rather than (0, io.EOF), because I find it
 // easier to reason about that way. Newlines are normalized to '\n'.
 func (t *tokenizer) read() (int, error) {
 	t.pos++
+	t.crlfs <<= 1
 	if len(t.buffer) > 0 {
 		// We've already peeked ahead; read from our buffer.
 		c := t.buffer[len(t.buffer)-1]
 		t.buffer = t.buffer[:len(t.buffer)-1]
-		return c, nil
+		if c == crlf {
+			t.crlfs |= 1
+			return t.record('\n'), nil
+		}
+		return t.record(c), nil
 	}
 
 	c, err := t.in.ReadByte()
@@ -1388,6 +1608,7 @@ func (t *tokenizer) read() (int, error) {
 	if err != nil {
 		return 0, &IOError{err}
 	}
+	t.consumed++
 
 	// Normalize \r and \r\n to just \n.
 	if c == '\r' {
@@ -1402,17 +1623,35 @@ func (t *tokenizer) read() (int, error) {
 			if err != nil {
 				return 0, err
 			}
+			t.consumed++
+			t.crlfs |= 1
 		}
-		return '\n', nil
+		return t.record('\n'), nil
 	}
 
-	return int(c), nil
+	return t.record(int(c)), nil
+}
+
+// Record adds the character c, just read, to the tape if the tokenizer is
+// keeping one, and returns it.
+func (t *tokenizer) record(c int) int {
+	if t.keepTape && c != -1 {
+		t.tape = append(t.tape, byte(c))
+	}
+	return c
 }
 
 // Unread pushes a character (or -1) back into the input stream to
 // be read again later.
 func (t *tokenizer) unread(c int) {
 	t.pos--
+	if t.keepTape && c != -1 {
+		t.tape = t.tape[:len(t.tape)-1]
+	}
+	if t.crlfs&1 != 0 {
+		c = crlf
+	}
+	t.crlfs >>= 1
 	t.buffer = append(t.buffer, c)
 }
 
@@ -1443,7 +1682,7 @@ func isASCII(c int) bool {
 	return c < 0x80
 }
 
-func processBackslashInString(t *tokenizer, sb *strings.Builder) error {
+func processBackslashInString(t *tokenizer, sb textBuilder) error {
 	c, err := t.peek()
 	if err != nil {
 		return err
@@ -0,0 +1,114 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import "strconv"
+
+// TransformFunc is called by Transform for each value in a document, with the
+// reader positioned on the value. path locates the value: it holds the name of
+// each struct field, or the index, in decimal, of each list or sexp element,
+// leading to it, and is empty for a top-level value. A field whose name has
+// unknown text has an empty name. path is only valid until fn returns.
+//
+// fn returns true if it handled the value: it may have written something to w
+// in its place, or nothing at all to drop it. A struct field's value is
+// written by fn with its field name and annotations, if it is written at all.
+// fn returns false, leaving the value unread, to have Transform copy it to w
+// instead; a container is then copied by calling fn again for each of its
+// elements.
+type TransformFunc func(path []string, r Reader, w Writer) (handled bool, err error)
+
+// Transform walks every value in the document read by r, top-level values and
+// the values in containers alike, calling fn for each, and writes the document
+// to w with whatever changes fn makes, copying the values it doesn't handle
+// unchanged. It is a building block for pipelines that redact or migrate data
+// without decoding it all to Go values. If fn returns an error, Transform
+// stops and returns it. It does not finish w.
+//
+//     // Redact every field named "ssn", wherever it is.
+//     err := Transform(r, w, func(path []string, r Reader, w Writer) (bool, error) {
+//         if len(path) == 0 || path[len(path)-1] != "ssn" {
+//             return false, nil
+//         }
+//         if err := w.FieldName(NewSymbolTokenFromString("ssn")); err != nil {
+//             return true, err
+//         }
+//         return true, w.WriteString("***")
+//     })
+//
+func Transform(r Reader, w Writer, fn TransformFunc) error {
+	t := transformer{r: r, w: w, fn: fn}
+	for r.Next() {
+		if err := t.value(nil); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+// A transformer holds the state of a call to Transform.
+type transformer struct {
+	r    Reader
+	w    Writer
+	fn   TransformFunc
+	path []string
+}
+
+// Value offers the reader's current value to fn and copies it, after its
+// field name if it has one, if fn doesn't handle it.
+func (t *transformer) value(fieldName *SymbolToken) error {
+	handled, err := t.fn(t.path, t.r, t.w)
+	if err != nil || handled {
+		return err
+	}
+
+	if fieldName != nil {
+		if err := t.w.FieldName(portableToken(*fieldName)); err != nil {
+			return err
+		}
+	}
+
+	if !t.r.IsNull() {
+		switch t.r.Type() {
+		case ListType, SexpType, StructType:
+			return t.container()
+		}
+	}
+	return copyValue(t.r, t.w)
+}
+
+// Container copies the reader's current container value, offering each of its
+// elements to fn in turn.
+func (t *transformer) container() error {
+	if err := copyAnnotations(t.r, t.w); err != nil {
+		return err
+	}
+
+	return copyContainer(t.r, t.w, func(i int, name *SymbolToken) error {
+		elem := strconv.Itoa(i)
+		if name != nil {
+			elem = ""
+			if name.Text != nil {
+				elem = *name.Text
+			}
+		}
+
+		t.path = append(t.path, elem)
+		err := t.value(name)
+		t.path = t.path[:len(t.path)-1]
+		return err
+	})
+}
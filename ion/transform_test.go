@@ -0,0 +1,124 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transformText(t *testing.T, in string, fn TransformFunc) string {
+	buf := strings.Builder{}
+	w := NewTextWriterOpts(&buf, TextWriterQuietFinish)
+	require.NoError(t, Transform(NewReaderString(in), w, fn))
+	require.NoError(t, w.Finish())
+	return buf.String()
+}
+
+func TestTransformDropField(t *testing.T) {
+	out := transformText(t, `{a:1, secret:"x", b:{secret:[1], c:2}} a::[{secret:3}]`, func(path []string, r Reader, w Writer) (bool, error) {
+		return len(path) > 0 && path[len(path)-1] == "secret", nil
+	})
+	assert.Equal(t, "{a:1,b:{c:2}}\na::[{}]", out)
+}
+
+func TestTransformRewriteValue(t *testing.T) {
+	out := transformText(t, `{name:"apple", qty:3} {name:"pear", qty:null.int} [4, (5 6)]`, func(path []string, r Reader, w Writer) (bool, error) {
+		if len(path) == 1 && path[0] == "qty" {
+			if err := w.FieldName(NewSymbolTokenFromString("quantity")); err != nil {
+				return true, err
+			}
+			if r.IsNull() {
+				return true, w.WriteInt(0)
+			}
+			val, err := r.Int64Value()
+			if err != nil {
+				return true, err
+			}
+			return true, w.WriteInt(*val * 10)
+		}
+		if r.Type() == IntType && len(path) == 2 {
+			return true, w.WriteString(strings.Join(path, "/"))
+		}
+		return false, nil
+	})
+	assert.Equal(t, "{name:\"apple\",quantity:30}\n{name:\"pear\",quantity:0}\n[4,(\"1/0\" \"1/1\")]", out)
+}
+
+func TestTransformPaths(t *testing.T) {
+	var paths []string
+	out := transformText(t, `x::1 {a:[b, {c:d}], $0:e} ()`, func(path []string, r Reader, w Writer) (bool, error) {
+		paths = append(paths, "/"+strings.Join(path, "/"))
+		return false, nil
+	})
+	assert.Equal(t, "x::1\n{a:[b,{c:d}],$0:e}\n()", out)
+	assert.Equal(t, []string{"/", "/", "/a", "/a/0", "/a/1", "/a/1/c", "/", "/"}, paths)
+}
+
+func TestTransformBinary(t *testing.T) {
+	in := bytes.Buffer{}
+	w := NewBinaryWriter(&in)
+	require.NoError(t, w.Annotation(NewSymbolTokenFromString("rec")))
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("id")))
+	require.NoError(t, w.WriteInt(7))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("password")))
+	require.NoError(t, w.WriteString("hunter2"))
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("tags")))
+	require.NoError(t, w.BeginList())
+	require.NoError(t, w.WriteSymbolFromString("a"))
+	require.NoError(t, w.EndList())
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.Finish())
+
+	out := bytes.Buffer{}
+	w = NewBinaryWriter(&out)
+	require.NoError(t, Transform(NewReaderBytes(in.Bytes()), w, func(path []string, r Reader, w Writer) (bool, error) {
+		return len(path) == 1 && path[0] == "password", nil
+	}))
+	require.NoError(t, w.Finish())
+
+	val, err := DecodeTree(&out)
+	require.NoError(t, err)
+	tags := val.(map[string]interface{})["tags"].([]interface{})
+	assert.Equal(t, "a", *tags[0].(*SymbolToken).Text)
+	assert.Equal(t, 7, val.(map[string]interface{})["id"])
+	assert.NotContains(t, val.(map[string]interface{}), "password")
+}
+
+func TestTransformError(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	err := Transform(NewReaderString("[1, 2, 3] 4"), NewTextWriter(&strings.Builder{}), func(path []string, r Reader, w Writer) (bool, error) {
+		calls++
+		if len(path) == 1 && path[0] == "1" {
+			return true, boom
+		}
+		return false, nil
+	})
+	assert.Equal(t, boom, err)
+	assert.Equal(t, 3, calls)
+
+	err = Transform(NewReaderString("[1, "), NewTextWriter(&strings.Builder{}), func(path []string, r Reader, w Writer) (bool, error) {
+		return false, nil
+	})
+	assert.Error(t, err)
+}
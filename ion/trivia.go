@@ -0,0 +1,180 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bufio"
+	"io"
+)
+
+// A TriviaReader is a text Reader that keeps the trivia--comments and
+// whitespace--found between top-level values, so that a document can be
+// rewritten with a TriviaWriter without losing them. This is meant for tools
+// that edit Ion files, like config files, by hand: values that are left
+// alone keep their comments and the blank lines around them.
+//
+// Only trivia between top-level values is kept; within a value, comments are
+// dropped and whitespace is normalized as usual. Line endings are normalized
+// to "\n". A top-level local symbol table is dropped, and the trivia either
+// side of it runs together; a TriviaWriter writes symbols by their text, so it
+// has no need of it.
+type TriviaReader interface {
+	Reader
+
+	// Trivia returns the comments and whitespace preceding the current
+	// top-level value. Once Next has returned false at the top level, it
+	// returns the trivia at the end of the stream.
+	Trivia() string
+}
+
+// NewTriviaReader creates a new TriviaReader for the given text Ion.
+func NewTriviaReader(in io.Reader) TriviaReader {
+	r := newTextReaderBuf(bufio.NewReader(in), nil, nil).(*textReader)
+	r.keepTrivia = true
+	r.tok.keepTape = true
+	return r
+}
+
+// A TriviaWriter is a text Writer that can write the trivia kept by a
+// TriviaReader between top-level values.
+type TriviaWriter interface {
+	Writer
+
+	// Trivia sets the comments and whitespace to write before the next
+	// top-level value, in place of the usual separator. Set before Finish,
+	// it is written at the end of the stream in place of the final newline.
+	// It is an error to call Trivia inside a container, or with anything
+	// other than comments and whitespace. The trivia is written verbatim, so
+	// it is up to the caller to make sure it separates values that need it.
+	Trivia(s string) error
+}
+
+// NewTriviaWriter creates a new TriviaWriter that writes text Ion.
+func NewTriviaWriter(out io.Writer) TriviaWriter {
+	return NewTextWriter(out).(*textWriter)
+}
+
+// Trivia returns the trivia preceding the current top-level value.
+func (t *textReader) Trivia() string {
+	return t.trivia
+}
+
+// OnTrivia works out the trivia preceding the top-level value (or end of
+// stream) that starts at the given offset in the tape, which holds everything
+// read since the previous top-level value started.
+func (t *textReader) onTrivia(start int) {
+	seg := t.tok.tape[:start]
+
+	if t.started {
+		// The segment holds the previous value followed by its trivia.
+		n, err := valueLen(seg)
+		if err != nil || n > len(seg) {
+			n = len(seg)
+		}
+		seg = seg[n:]
+	}
+	t.trivia = string(seg)
+	t.started = true
+
+	// Drop everything before the new value from the tape.
+	t.tok.tape = append(t.tok.tape[:0], t.tok.tape[start:]...)
+	t.tok.tokStart -= start
+	t.tok.longEnd -= start
+	t.rawStart -= start
+}
+
+// DropSystemValue removes the system value that starts at the given offset
+// from the tape, so that the trivia either side of it runs together.
+func (t *textReader) dropSystemValue(start int) {
+	n, err := valueLen(t.tok.tape[start:])
+	if err != nil || start+n > len(t.tok.tape) {
+		n = len(t.tok.tape) - start
+	}
+	t.tok.tape = append(t.tok.tape[:start], t.tok.tape[start+n:]...)
+	t.tok.tokStart -= n
+}
+
+// ValueLen returns the length of the (possibly annotated) text Ion value at the
+// start of bs.
+func valueLen(bs []byte) (int, error) {
+	t := tokenizeBytes(bs)
+	for {
+		if err := t.Next(); err != nil {
+			return 0, err
+		}
+
+		tok := t.Token()
+		switch tok {
+		case tokenSymbol, tokenSymbolQuoted:
+			val, err := t.ReadValue(tok)
+			if err != nil {
+				return 0, err
+			}
+			end := t.Pos()
+
+			if tok == tokenSymbol && val == "null" {
+				ok, err := t.SkipDot()
+				if err != nil {
+					return 0, err
+				}
+				if ok {
+					if _, err := t.readSymbol(); err != nil {
+						return 0, err
+					}
+					end = t.Pos()
+				}
+			}
+
+			ok, _, err := t.SkipDoubleColon()
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				return int(end), nil
+			}
+			// That was an annotation; keep going.
+
+		default:
+			if !t.unfinished {
+				return int(t.Pos()), nil
+			}
+			if _, err := t.skipValueBody(); err != nil {
+				return 0, err
+			}
+			// Not counting the character following the value.
+			return int(t.Pos() - 1), nil
+		}
+	}
+}
+
+// Trivia sets the trivia to write before the next top-level value.
+func (w *textWriter) Trivia(s string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.ctx.peek() != ctxAtTopLevel {
+		return &UsageError{"Writer.Trivia", "not at top level"}
+	}
+
+	t := tokenizeString(s)
+	c, _, err := t.skipWhitespace()
+	if err != nil || c != -1 {
+		return &UsageError{"Writer.Trivia", "trivia must be only comments and whitespace"}
+	}
+
+	w.trivia = &s
+	return nil
+}
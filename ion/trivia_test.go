@@ -0,0 +1,174 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriviaReader(t *testing.T) {
+	in := "// head\na::1\n\n// two\n{b:2, c:[1, /*x*/ 2]} // tail\n  null.int\n'q'::\"s\" 1.5e0 (x y)/*e*/2020T\n// end"
+	r := NewTriviaReader(strings.NewReader(in))
+
+	var trivia []string
+	for r.Next() {
+		trivia = append(trivia, r.Trivia())
+	}
+	require.NoError(t, r.Err())
+	trivia = append(trivia, r.Trivia())
+
+	assert.Equal(t, []string{"// head\n", "\n\n// two\n", " // tail\n  ", "\n", " ", " ", "/*e*/", "\n// end"}, trivia)
+}
+
+func TestTriviaReaderPeekType(t *testing.T) {
+	r := NewTriviaReader(strings.NewReader("// one\n1 /* two */ 2"))
+	require.True(t, r.Next())
+	typ, err := r.PeekType()
+	require.NoError(t, err)
+	assert.Equal(t, IntType, typ)
+	assert.Equal(t, "// one\n", r.Trivia())
+
+	require.True(t, r.Next())
+	assert.Equal(t, " /* two */ ", r.Trivia())
+	assert.False(t, r.Next())
+}
+
+func TestTriviaReaderSymbolTable(t *testing.T) {
+	in := "// c1\n$ion_1_0\n$ion_symbol_table::{symbols:[\"a\"]} // c2\n$10 b"
+	r := NewTriviaReader(strings.NewReader(in))
+
+	// The version marker and symbol table are dropped, and the trivia either
+	// side of them runs together.
+	require.True(t, r.Next())
+	assert.Equal(t, "// c1\n\n // c2\n", r.Trivia())
+	sym, err := r.SymbolValue()
+	require.NoError(t, err)
+	assert.Equal(t, "a", *sym.Text)
+
+	require.True(t, r.Next())
+	assert.Equal(t, " ", r.Trivia())
+	assert.False(t, r.Next())
+}
+
+func TestTriviaRoundTrip(t *testing.T) {
+	test := func(in string) {
+		t.Run(in, func(t *testing.T) {
+			r := NewTriviaReader(strings.NewReader(in))
+			buf := strings.Builder{}
+			w := NewTriviaWriter(&buf)
+
+			for r.Next() {
+				require.NoError(t, w.Trivia(r.Trivia()))
+				copyTopLevelValue(t, r, w)
+			}
+			require.NoError(t, r.Err())
+			require.NoError(t, w.Trivia(r.Trivia()))
+			require.NoError(t, w.Finish())
+
+			assert.Equal(t, in, buf.String())
+		})
+	}
+
+	test("")
+	test("1")
+	test("1 2")
+	test("// only a comment\n")
+	test("// head\na::1\n\n\n// two\n{b:2,c:[1,2]} // tail\n  null.int\nq::\"s\" 1.5e+0 (x y)/*e*/2020T\n// end")
+	test("/* a */ hello /* b */ \"world\"\n\n")
+}
+
+func TestTriviaWriterErrors(t *testing.T) {
+	w := NewTriviaWriter(&strings.Builder{})
+	assert.Error(t, w.Trivia("// comment\n1"))
+	assert.Error(t, w.Trivia("/* unterminated"))
+
+	require.NoError(t, w.BeginList())
+	assert.Error(t, w.Trivia(" "))
+}
+
+// copyTopLevelValue copies the reader's current value to the writer.
+func copyTopLevelValue(t *testing.T, r Reader, w Writer) {
+	an, err := r.Annotations()
+	require.NoError(t, err)
+	if len(an) > 0 {
+		require.NoError(t, w.Annotations(an...))
+	}
+
+	if r.IsNull() {
+		require.NoError(t, w.WriteNullType(r.Type()))
+		return
+	}
+
+	switch r.Type() {
+	case IntType:
+		val, err := r.Int64Value()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteInt(*val))
+
+	case FloatType:
+		val, err := r.FloatValue()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteFloat(*val))
+
+	case DecimalType:
+		val, err := r.DecimalValue()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteDecimal(val))
+
+	case TimestampType:
+		val, err := r.TimestampValue()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteTimestamp(*val))
+
+	case SymbolType:
+		val, err := r.SymbolValue()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteSymbol(*val))
+
+	case StringType:
+		val, err := r.StringValue()
+		require.NoError(t, err)
+		require.NoError(t, w.WriteString(*val))
+
+	case ListType:
+		require.NoError(t, r.StepIn())
+		require.NoError(t, w.BeginList())
+		writeFromReaderToWriter(t, r, w)
+		require.NoError(t, r.StepOut())
+		require.NoError(t, w.EndList())
+
+	case SexpType:
+		require.NoError(t, r.StepIn())
+		require.NoError(t, w.BeginSexp())
+		writeFromReaderToWriter(t, r, w)
+		require.NoError(t, r.StepOut())
+		require.NoError(t, w.EndSexp())
+
+	case StructType:
+		require.NoError(t, r.StepIn())
+		require.NoError(t, w.BeginStruct())
+		writeFromReaderToWriter(t, r, w)
+		require.NoError(t, r.StepOut())
+		require.NoError(t, w.EndStruct())
+
+	default:
+		t.Fatalf("unexpected type %v", r.Type())
+	}
+}
@@ -144,3 +144,30 @@ func (i IntSize) String() string {
 		return fmt.Sprintf("<unknown size %v>", uint8(i))
 	}
 }
+
+// NullForm represents how a null value was spelled.
+type NullForm uint8
+
+const (
+	// NotNull is the form of a value that isn't a null.
+	NotNull NullForm = iota
+	// BareNull is the form of an untyped null written as plain `null`.
+	BareNull
+	// TypedNull is the form of a null written with its type, like `null.null` or
+	// `null.int`. Binary Ion nulls always have this form.
+	TypedNull
+)
+
+// String implements fmt.Stringer for NullForm.
+func (f NullForm) String() string {
+	switch f {
+	case NotNull:
+		return "<not null>"
+	case BareNull:
+		return "null"
+	case TypedNull:
+		return "null.<type>"
+	default:
+		return fmt.Sprintf("<unknown null form %v>", uint8(f))
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeNames maps Go struct types to the names that identify them in an Ion
+// document. An Encoder given one with WithStructTypeNames annotates every
+// struct it writes with its type's name, and a Decoder given one with
+// WithStructTypeNames uses that annotation to pick the Go type to decode a
+// struct to when all it has is an interface, making it possible to decode a
+// stream of different record types into, say, a []Event.
+type TypeNames struct {
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}
+
+// NewTypeNames creates a TypeNames that registers the type of each of the
+// given values, which must be structs or pointers to structs, under its Go
+// type name, e.g. "OrderPlaced" for a main.OrderPlaced. Use Register to give a
+// type a different name, for example if two packages' types share a name.
+func NewTypeNames(vs ...interface{}) *TypeNames {
+	n := &TypeNames{
+		byName: map[string]reflect.Type{},
+		byType: map[reflect.Type]string{},
+	}
+	for _, v := range vs {
+		t := structTypeOf(v)
+		n.register(t.Name(), t)
+	}
+	return n
+}
+
+// Register registers the type of v, which must be a struct or a pointer to a
+// struct, under the given name, replacing any name it had before and any type
+// registered under that name before. It returns n.
+func (n *TypeNames) Register(name string, v interface{}) *TypeNames {
+	t := structTypeOf(v)
+	if old, ok := n.byType[t]; ok {
+		delete(n.byName, old)
+	}
+	if old, ok := n.byName[name]; ok {
+		delete(n.byType, old)
+	}
+	n.register(name, t)
+	return n
+}
+
+func (n *TypeNames) register(name string, t reflect.Type) {
+	if name == "" {
+		panic(fmt.Sprintf("ion: cannot register %v without a name", t))
+	}
+	n.byName[name] = t
+	n.byType[t] = name
+}
+
+// NameFor returns the name to annotate a struct of the given type with: its
+// registered name if it has one, or its Go type name, which is empty for an
+// anonymous struct.
+func (n *TypeNames) nameFor(t reflect.Type) string {
+	if name, ok := n.byType[t]; ok {
+		return name
+	}
+	return t.Name()
+}
+
+// TypeFor returns the type registered under the first of the given annotations
+// that names one, or nil if none do.
+func (n *TypeNames) typeFor(as []SymbolToken) reflect.Type {
+	for _, a := range as {
+		if a.Text == nil {
+			continue
+		}
+		if t, ok := n.byName[*a.Text]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// Without returns the given annotations minus any that name a registered type.
+func (n *TypeNames) without(as []SymbolToken) []SymbolToken {
+	var res []SymbolToken
+	for _, a := range as {
+		if a.Text != nil {
+			if _, ok := n.byName[*a.Text]; ok {
+				continue
+			}
+		}
+		res = append(res, a)
+	}
+	return res
+}
+
+// StructTypeOf returns the struct type of v, which must be a struct or a
+// pointer to one.
+func structTypeOf(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("ion: cannot register type name for %v, which is not a struct", reflect.TypeOf(v)))
+	}
+	return t
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tnEvent interface {
+	eventID() int
+}
+
+type tnAddress struct {
+	City string `ion:"city"`
+}
+
+type tnCreated struct {
+	ID      int       `ion:"id"`
+	Address tnAddress `ion:"address"`
+}
+
+func (c tnCreated) eventID() int { return c.ID }
+
+type tnDeleted struct {
+	ID     int    `ion:"id"`
+	Reason string `ion:"reason"`
+}
+
+func (d *tnDeleted) eventID() int { return d.ID }
+
+type tnTagged struct {
+	Annotations []SymbolToken `ion:",annotations"`
+	City        string        `ion:"city"`
+}
+
+func TestStructTypeNamesEncode(t *testing.T) {
+	names := NewTypeNames(tnCreated{}).Register("deleted", tnDeleted{})
+
+	test := func(v interface{}, eval string) {
+		t.Run(eval, func(t *testing.T) {
+			buf := strings.Builder{}
+			e := NewTextEncoder(&buf).WithStructTypeNames(names)
+			require.NoError(t, e.Encode(v))
+			require.NoError(t, e.Finish())
+			assert.Equal(t, eval, strings.TrimSpace(buf.String()))
+		})
+	}
+
+	test(tnCreated{1, tnAddress{"Oslo"}}, "tnCreated::{id:1,address:tnAddress::{city:\"Oslo\"}}")
+	test(&tnDeleted{2, "dup"}, "deleted::{id:2,reason:\"dup\"}")
+	test([]tnEvent{tnCreated{ID: 1}, &tnDeleted{ID: 2}}, "[tnCreated::{id:1,address:tnAddress::{city:\"\"}},deleted::{id:2,reason:\"\"}]")
+
+	// Unregistered structs are named after their Go type; anonymous structs
+	// and structs written as other Ion types are not annotated.
+	test(tnAddress{"Oslo"}, "tnAddress::{city:\"Oslo\"}")
+	test(struct{ A int }{1}, "{A:1}")
+	test(struct{ D *Decimal }{MustParseDecimal("1.5")}, "{D:1.5}")
+
+	// Explicit annotations come before the wrapped struct's type name.
+	wrapped := struct {
+		Annotations []SymbolToken `ion:",annotations"`
+		Value       tnAddress
+	}{[]SymbolToken{NewSymbolTokenFromString("home")}, tnAddress{"Rome"}}
+	test(wrapped, "home::tnAddress::{city:\"Rome\"}")
+
+	// Without the option, nothing is annotated.
+	buf := strings.Builder{}
+	require.NoError(t, NewTextEncoder(&buf).WithStructTypeNames(nil).Encode(tnCreated{ID: 1}))
+	assert.Equal(t, "{id:1,address:{city:\"\"}}", strings.TrimSpace(buf.String()))
+}
+
+func TestStructTypeNamesRoundTrip(t *testing.T) {
+	names := NewTypeNames(tnCreated{}, tnAddress{}).Register("deleted", tnDeleted{})
+	events := []tnEvent{
+		tnCreated{1, tnAddress{"Oslo"}},
+		&tnDeleted{1, "dup"},
+		tnCreated{2, tnAddress{"Rome"}},
+	}
+
+	buf := bytes.Buffer{}
+	e := NewBinaryEncoder(&buf).WithStructTypeNames(names)
+	require.NoError(t, e.Encode(events))
+	require.NoError(t, e.Encode(map[string]interface{}{"last": events[1]}))
+	require.NoError(t, e.Encode(struct {
+		Annotations []SymbolToken `ion:",annotations"`
+		Value       tnAddress
+	}{[]SymbolToken{NewSymbolTokenFromString("audit")}, tnAddress{"Oslo"}}))
+	require.NoError(t, e.Finish())
+
+	d := NewDecoder(NewReaderBytes(buf.Bytes())).WithStructTypeNames(names)
+
+	var decoded []tnEvent
+	require.NoError(t, d.DecodeTo(&decoded))
+	assert.Equal(t, events, decoded)
+
+	// Decode picks the registered type for nested structs too.
+	v, err := d.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"last": tnDeleted{1, "dup"}}, v)
+
+	// The type name is not decoded into an annotations field.
+	var tagged tnTagged
+	require.NoError(t, d.DecodeTo(&tagged))
+	assert.Equal(t, "Oslo", tagged.City)
+	require.Len(t, tagged.Annotations, 1)
+	assert.Equal(t, "audit", *tagged.Annotations[0].Text)
+
+	// Unregistered names are ignored, and a registered type that does not
+	// implement the target interface is an error.
+	var ev tnEvent
+	require.Error(t, UnmarshalString("tnAddress::{city:\"Oslo\"}", &ev))
+	d = NewDecoder(NewReaderString("tnAddress::{city:\"Oslo\"}")).WithStructTypeNames(names)
+	err = d.DecodeTo(&ev)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot decode ion.tnAddress to ion.tnEvent")
+
+	d = NewDecoder(NewReaderString("unknown::{city:\"Oslo\"}")).WithStructTypeNames(names)
+	v, err = d.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"city": newString("Oslo")}, v)
+}
+
+func TestTypeNamesRegister(t *testing.T) {
+	names := NewTypeNames(tnCreated{})
+	names.Register("created", &tnCreated{})
+	assert.Equal(t, "created", names.nameFor(reflect.TypeOf(tnCreated{})))
+	assert.Nil(t, names.typeFor([]SymbolToken{NewSymbolTokenFromString("tnCreated")}))
+
+	// Reusing a name moves it to the new type.
+	names.Register("created", tnDeleted{})
+	assert.Equal(t, "tnCreated", names.nameFor(reflect.TypeOf(tnCreated{})))
+	assert.Equal(t, reflect.TypeOf(tnDeleted{}), names.typeFor([]SymbolToken{NewSymbolTokenFromString("created")}))
+
+	assert.Panics(t, func() { NewTypeNames(1) })
+	assert.Panics(t, func() { NewTypeNames(struct{}{}) })
+}
@@ -20,9 +20,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"reflect"
 	"strconv"
-	"strings"
 )
 
 var (
@@ -76,6 +77,51 @@ var typesAcceptableKinds = map[Type][]reflect.Kind{
 //     }
 //     fmt.Println(val) // prints out: {10 [age]}
 //
+// A struct may hold the fields that match none of its own in a map field
+// tagged `ion:",remaining"`, which must have string keys. Each such field is
+// decoded into the map's value type as any other value would be, so the map
+// may be a map[string]interface{}, which takes anything, or a typed map such as
+// map[string]Extension for a record with a fixed header and any number of
+// extension fields of one shape. A field whose value can't be decoded into the
+// value type is an error, naming the field, just as it would be for a declared
+// field; it is not skipped. Marshal writes the map's entries back out as fields
+// of the struct.
+//
+//     type record struct {
+//         ID    int                  `ion:"id"`
+//         Extra map[string]extension `ion:",remaining"`
+//     }
+//
+// A struct field tagged with the "required" option, e.g. `ion:"id,required"`,
+// must be present in the Ion struct being decoded, or Unmarshal returns an error
+// naming it. A field whose value is null counts as present.
+//
+// Ion ints, floats, and decimals may also be unmarshalled into a json.Number,
+// which keeps every digit of an int or decimal. A decimal is written in plain
+// notation unless it has a positive exponent, e.g. 1.50 becomes "1.50" and
+// 15d2 becomes "15e2"; a float nan or infinity, which JSON cannot represent,
+// is an error.
+//
+// Ion ints and decimals may also be unmarshalled into a big.Rat, exactly.
+//
+// Ion decimals may also be unmarshalled into a float32 or float64, rounding to
+// the nearest value; see Decimal.Float64. A decimal too large for the type is
+// an error, and one too small for it becomes zero. A Decoder with
+// WithStrictFloatNarrowing instead returns an error for a float or decimal
+// that a float32 can't hold exactly.
+//
+// Ion timestamps may also be unmarshalled into any type implementing
+// TimestampSetter, such as an application's own date or time type.
+//
+// Ion strings and symbols may also be unmarshalled into a net.IP, url.URL,
+// netip.Addr, netip.AddrPort, or netip.Prefix, parsing their text as
+// MarshalText writes it; text that doesn't parse is an error.
+//
+// Ion clobs may also be unmarshalled into a string, which holds the clob's
+// bytes unchanged. A clob's characters are ASCII, but it may hold any byte,
+// written \xHH in text, so the string is not necessarily valid UTF-8. Marshal
+// a string field tagged `ion:",clob"` to write it back as a clob.
+//
 //     Go native type                                  Ion Type
 //   --------------------------                     ---------------
 //     nil/interface{}                                 null
@@ -86,12 +132,33 @@ var typesAcceptableKinds = map[Type][]reflect.Kind{
 //     ion.Timestamp/interface{}                       timestamp
 //     string/interface{}                              symbol
 //     string/interface{}                              string
-//     []byte/[]interface{}{}                          clob
+//     []byte/string/[]interface{}{}                   clob
 //     []byte/[]interface{}{}                          blob
 //     []interface{}{}                                 list
 //     []interface{}{}                                 sexp
 //     map[string]interface{}{}/struct/interface{}     struct
 //
+// Each element of a []interface{}, and each value of a map[string]interface{},
+// is decoded according to its own Ion type, so a list may freely mix types:
+//
+//     Ion type          Go type of the element
+//   -------------     ------------------------------------------------------
+//     null (any)        nil
+//     bool              bool
+//     int               int if it fits in 32 bits, else int64, else *big.Int
+//     float             float64
+//     decimal           ion.Decimal
+//     timestamp         ion.Timestamp
+//     string            string
+//     symbol            *ion.SymbolToken
+//     blob, clob        []byte
+//     list, sexp        []interface{}, decoded as by Decoder.Decode
+//     struct            map[string]interface{}, decoded as by Decoder.Decode
+//
+// A value decoded into a bare interface{}, rather than into an element of a
+// typed slice or map, is decoded as by Decoder.Decode, which holds floats,
+// decimals, timestamps, and strings by pointer.
+//
 func Unmarshal(data []byte, v interface{}, ssts ...SharedSymbolTable) error {
 	catalog := NewCatalog(ssts...)
 	return NewDecoder(NewReaderCat(bytes.NewReader(data), catalog)).DecodeTo(v)
@@ -110,18 +177,260 @@ func UnmarshalFrom(r Reader, v interface{}) error {
 	return d.DecodeTo(v)
 }
 
+// DecodeStruct steps in to the struct the reader is positioned on and calls fn
+// for each of its fields, in order, with the reader positioned on the field's
+// value. fn may read the value however it likes, stepping in to it or calling
+// DecodeStruct again if it's a nested struct, or leave it unread to skip it. A
+// field whose name has unknown text is passed an empty name. If fn returns an
+// error, or reading the struct fails, DecodeStruct stops and returns that
+// error. Either way it steps back out of the struct, leaving the reader
+// positioned after it. A null struct has no fields.
+//
+//     err := DecodeStruct(r, func(field string, r Reader) error {
+//         switch field {
+//         case "id":
+//             id, err := r.Int64Value()
+//             if err != nil || id == nil {
+//                 return err
+//             }
+//             rec.ID = *id
+//         case "name":
+//             name, err := r.StringValue()
+//             if err != nil || name == nil {
+//                 return err
+//             }
+//             rec.Name = *name
+//         }
+//         return nil
+//     })
+//
+func DecodeStruct(r Reader, fn func(field string, r Reader) error) error {
+	if r.Type() != StructType {
+		return &UsageError{"DecodeStruct", fmt.Sprintf("cannot decode a %v as a struct", r.Type())}
+	}
+	if r.IsNull() {
+		return nil
+	}
+
+	if err := r.StepIn(); err != nil {
+		return err
+	}
+
+	for r.Next() {
+		name, err := r.FieldName()
+		if err != nil {
+			r.StepOut()
+			return err
+		}
+
+		field := ""
+		if name != nil && name.Text != nil {
+			field = *name.Text
+		}
+
+		if err := fn(field, r); err != nil {
+			r.StepOut()
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		r.StepOut()
+		return err
+	}
+
+	return r.StepOut()
+}
+
+// ReadAll decodes every top-level value in the given Ion stream (text or
+// binary) into a T, returning them in order.
+//
+//     vals, err := ReadAll[int](strings.NewReader("1 2 3"))
+//     fmt.Println(vals) // prints out: [1 2 3]
+//
+func ReadAll[T any](r io.Reader) ([]T, error) {
+	d := NewDecoder(NewReader(r))
+
+	var vals []T
+	for {
+		var v T
+		if err := d.DecodeTo(&v); err != nil {
+			if err == ErrNoInput {
+				return vals, nil
+			}
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+}
+
+// DecodeTree decodes the first top-level value in the given Ion stream (text
+// or binary) without any expectations about what it's going to get, as
+// Decoder.Decode does: structs become map[string]interface{}s, lists and sexps
+// become []interface{}s, and so on. It returns ErrNoInput if the stream holds
+// no values.
+//
+//     val, err := DecodeTree(strings.NewReader(`{name:"apple", tags:[a, b]}`))
+//     m := val.(map[string]interface{})
+//
+func DecodeTree(r io.Reader) (interface{}, error) {
+	return NewDecoder(NewReader(r)).Decode()
+}
+
+// DecodeTreeAll decodes every top-level value in the given Ion stream (text or
+// binary) as DecodeTree does, returning them in order.
+func DecodeTreeAll(r io.Reader) ([]interface{}, error) {
+	d := NewDecoder(NewReader(r))
+
+	var vals []interface{}
+	for {
+		v, err := d.Decode()
+		if err != nil {
+			if err == ErrNoInput {
+				return vals, nil
+			}
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+}
+
+// ReadValueAs decodes the value the reader is positioned on into a T, as
+// Unmarshal would, rather than reading the next one. It saves declaring a
+// variable to decode into when picking values out of a stream read by hand,
+// such as the value of a field in a DecodeStruct callback. Afterwards, Next
+// moves on to the value following the one decoded.
+//
+//     err := DecodeStruct(r, func(field string, r Reader) error {
+//         var err error
+//         if field == "tags" {
+//             rec.Tags, err = ReadValueAs[[]string](r)
+//         }
+//         return err
+//     })
+//
+func ReadValueAs[T any](r Reader) (T, error) {
+	var v T
+	if r.Type() == NoType {
+		return v, &UsageError{"ReadValueAs", "reader is not positioned on a value"}
+	}
+
+	d := Decoder{r: r}
+	err := d.decodeTo(reflect.ValueOf(&v))
+	return v, err
+}
+
+// DecoderOpts holds bit-flag options for a Decoder.
+type DecoderOpts uint
+
+const (
+	// DecodeTypeAnnotations instructs the decoder to use the "$go_" annotations
+	// written by an Encoder with EncodeTypeAnnotations to reconstruct the Go
+	// type of values decoded into an interface{}. The annotations themselves are
+	// not reported in ",annotations" fields.
+	DecodeTypeAnnotations DecoderOpts = 1 << iota
+
+	// DecodeMultiMaps instructs the decoder to keep every value of a repeated
+	// struct field when decoding into a map whose values are slices, such as
+	// map[string][]interface{}: each field's value is decoded as an element and
+	// appended to the slice for its name, in order. Without it (and for maps of
+	// non-slice values) a repeated field name keeps only its last value, and a
+	// slice-valued map decodes each field's value, which must be a list or sexp,
+	// into the slice.
+	DecodeMultiMaps
+)
+
+// ValidValuer is the interface implemented by string-based enum types that
+// restrict which values they may hold. When an Ion symbol or string is decoded
+// into such a type, the decoder returns an error unless the text is one of its
+// ValidValues, rather than accepting whatever the data contains. ValidValues
+// is called on the type's zero value.
+//
+//     type color string
+//
+//     func (color) ValidValues() []string { return []string{"red", "green"} }
+//
+type ValidValuer interface {
+	ValidValues() []string
+}
+
+var validValuerType = reflect.TypeOf((*ValidValuer)(nil)).Elem()
+
+// TimestampSetter is the interface implemented by types, typically a user's
+// own timestamp type, that can set themselves from an Ion timestamp. When a
+// non-null Ion timestamp is decoded into such a type, the decoder calls
+// SetFromTimestamp with the full-fidelity Timestamp, including its precision
+// and timezone kind, rather than reporting that the type is unsupported. A null
+// timestamp sets the value to its zero value without calling the method.
+//
+// The hook is consulted before the decoder's built-in handling of timestamps,
+// and only for Ion timestamps; other Ion types are decoded into the type as
+// usual. The decoder does not use encoding.TextUnmarshaler, so a type that
+// implements both is set through SetFromTimestamp alone.
+type TimestampSetter interface {
+	SetFromTimestamp(ts Timestamp) error
+}
+
+var timestampSetterType = reflect.TypeOf((*TimestampSetter)(nil)).Elem()
+
 // A Decoder decodes go values from an Ion reader.
 type Decoder struct {
-	r Reader
+	r    Reader
+	opts DecoderOpts
+
+	timestampLayouts []string
+	typeNames        *TypeNames
+	strictFloats     bool
 }
 
 // NewDecoder creates a new decoder.
 func NewDecoder(r Reader) *Decoder {
+	return NewDecoderOpts(r, 0)
+}
+
+// NewDecoderOpts creates a new decoder with the specified options.
+func NewDecoderOpts(r Reader, opts DecoderOpts) *Decoder {
 	return &Decoder{
-		r: r,
+		r:    r,
+		opts: opts,
 	}
 }
 
+// WithTimestampLayouts registers additional timestamp layouts, in the format
+// accepted by time.Parse, for decoding Ion strings into Timestamp or time.Time
+// values; by default only Ion timestamp values can be. A string is first
+// parsed as an Ion timestamp, then with each layout in turn until one
+// succeeds. See ParseTimestampLayout for how the precision is inferred.
+// It returns d.
+func (d *Decoder) WithTimestampLayouts(layouts ...string) *Decoder {
+	d.timestampLayouts = append(d.timestampLayouts, layouts...)
+	return d
+}
+
+// WithStructTypeNames instructs the decoder to decode an Ion struct whose
+// annotations include a name registered in names, such as one written by an
+// Encoder with WithStructTypeNames, to a value of the registered type when
+// decoding it to an interface{}, e.g. by Decode, or to another interface type
+// that the registered type or a pointer to it implements; in the latter case
+// the value is decoded to a pointer only if the type itself does not
+// implement the interface. The first annotation that names a registered type
+// wins. Structs decoded to concrete types are decoded as usual, whatever their
+// annotations, but registered names are left out of the annotations decoded
+// into an `ion:",annotations"` field. It returns d.
+func (d *Decoder) WithStructTypeNames(names *TypeNames) *Decoder {
+	d.typeNames = names
+	return d
+}
+
+// WithStrictFloatNarrowing instructs the decoder to return an error when an
+// Ion float or decimal decoded into a float32 can't be represented exactly in
+// one, rather than rounding it to the nearest float32. A decimal must equal the
+// float32 exactly, so e.g. 0.5 decodes but 0.1 does not; nan and the infinities
+// always decode. It returns d.
+func (d *Decoder) WithStrictFloatNarrowing() *Decoder {
+	d.strictFloats = true
+	return d
+}
+
 // NewTextDecoder creates a new text decoder. Well, a decoder that uses a reader with
 // no shared symbol tables, it'll work to read binary too if the binary doesn't reference
 // any shared symbol tables.
@@ -131,7 +440,10 @@ func NewTextDecoder(in io.Reader) *Decoder {
 
 // Decode decodes a value from the underlying Ion reader without any expectations
 // about what it's going to get. Structs become map[string]interface{}s, Lists and
-// Sexps become []interface{}s.
+// Sexps become []interface{}s. Scalars become the same types as the elements of
+// a []interface{} passed to Unmarshal, except that floats, decimals, timestamps,
+// and strings are returned by pointer: *float64, *Decimal, *Timestamp, and
+// *string. This applies to the contents of the containers too.
 func (d *Decoder) Decode() (interface{}, error) {
 	if !d.r.Next() {
 		if d.r.Err() != nil {
@@ -149,6 +461,10 @@ func (d *Decoder) decode() (interface{}, error) {
 		return nil, nil
 	}
 
+	if val, ok, err := d.decodeGoType(); ok || err != nil {
+		return val, err
+	}
+
 	switch d.r.Type() {
 	case BoolType:
 		val, err := d.r.BoolValue()
@@ -179,6 +495,11 @@ func (d *Decoder) decode() (interface{}, error) {
 		return d.r.ByteValue()
 
 	case StructType:
+		if v, ok, err := d.decodeNamedStruct(emptyInterfaceType); err != nil {
+			return nil, err
+		} else if ok {
+			return v.Interface(), nil
+		}
 		return d.decodeMap()
 
 	case ListType, SexpType:
@@ -189,6 +510,32 @@ func (d *Decoder) decode() (interface{}, error) {
 	}
 }
 
+// DecodeGoType decodes the current value to the Go type named by its type
+// annotation, if it has one and DecodeTypeAnnotations is set.
+func (d *Decoder) decodeGoType() (interface{}, bool, error) {
+	if d.opts&DecodeTypeAnnotations == 0 {
+		return nil, false, nil
+	}
+
+	as, err := d.r.Annotations()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, a := range as {
+		if a.Text == nil {
+			continue
+		}
+		if t, ok := goTypeAnnotations[*a.Text]; ok {
+			v := reflect.New(t).Elem()
+			if err := d.decodeTo(v); err != nil {
+				return nil, false, err
+			}
+			return v.Interface(), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
 func (d *Decoder) decodeInt() (interface{}, error) {
 	size, err := d.r.IntSize()
 	if err != nil {
@@ -232,7 +579,7 @@ func (d *Decoder) decodeMap() (map[string]interface{}, error) {
 			name := fieldName.Text
 			value, err := d.decode()
 			if err != nil {
-				return nil, err
+				return nil, withPathElement(err, PathElement{Field: *name, Index: -1})
 			}
 			result[*name] = value
 		}
@@ -256,10 +603,13 @@ func (d *Decoder) decodeSlice() ([]interface{}, error) {
 	for d.r.Next() {
 		value, err := d.decode()
 		if err != nil {
-			return nil, err
+			return nil, withPathElement(err, PathElement{Index: len(result)})
 		}
 		result = append(result, value)
 	}
+	if err := d.r.Err(); err != nil {
+		return nil, withPathElement(err, PathElement{Index: len(result)})
+	}
 
 	if err := d.r.StepOut(); err != nil {
 		return nil, err
@@ -271,22 +621,76 @@ func (d *Decoder) decodeSlice() ([]interface{}, error) {
 // DecodeTo decodes an Ion value from the underlying Ion reader into the
 // value provided.
 func (d *Decoder) DecodeTo(v interface{}) error {
+	defer d.keepRaw(v)()
+
+	rv, err := d.next(v)
+	if err != nil {
+		return err
+	}
+	return d.decodeTo(rv)
+}
+
+// DecodeFields decodes the next Ion struct from the underlying Ion reader into
+// the struct or map provided, but only the fields whose names are listed. Other
+// fields are skipped by the reader without being materialized, which makes this
+// considerably cheaper than DecodeTo when only a few fields of a wide struct
+// are needed.
+func (d *Decoder) DecodeFields(v interface{}, fields ...string) error {
+	defer d.keepRaw(v)()
+
+	rv, err := d.next(v)
+	if err != nil {
+		return err
+	}
+
+	if d.r.Type() != StructType || d.r.IsNull() {
+		return d.decodeTo(rv)
+	}
+
+	selected := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		selected[f] = true
+	}
+
+	rv = indirect(rv, false)
+	switch rv.Kind() {
+	case reflect.Struct:
+		return d.decodeStructToStruct(rv, selected)
+	case reflect.Map:
+		return d.decodeStructToMap(rv, selected)
+	}
+	return fmt.Errorf("ion: cannot decode struct fields to %v", rv.Type().String())
+}
+
+// KeepRaw has the reader keep its input for decoding into a RawValue while
+// decoding into v, if v can hold one, returning a func that stops it again.
+func (d *Decoder) keepRaw(v interface{}) func() {
+	rr, ok := d.r.(rawReader)
+	if !ok || v == nil || !hasRawValue(reflect.TypeOf(v)) {
+		return func() {}
+	}
+	rr.keepRaw(true)
+	return func() { rr.keepRaw(false) }
+}
+
+// Next checks that v is a non-nil pointer and moves the underlying reader to
+// the next value.
+func (d *Decoder) next(v interface{}) (reflect.Value, error) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr {
-		return errors.New("ion: v must be a pointer")
+		return rv, errors.New("ion: v must be a pointer")
 	}
 	if rv.IsNil() {
-		return errors.New("ion: v must not be nil")
+		return rv, errors.New("ion: v must not be nil")
 	}
 
 	if !d.r.Next() {
 		if d.r.Err() != nil {
-			return d.r.Err()
+			return rv, d.r.Err()
 		}
-		return ErrNoInput
+		return rv, ErrNoInput
 	}
-
-	return d.decodeTo(rv)
+	return rv, nil
 }
 
 func (d *Decoder) decodeTo(v reflect.Value) error {
@@ -294,9 +698,23 @@ func (d *Decoder) decodeTo(v reflect.Value) error {
 		// Don't actually have anywhere to put this value; skip it.
 		return nil
 	}
-
 	isNull := d.r.IsNull()
+
+	// Hand over the reader's freshly-allocated decimal rather than copying it
+	// into a newly-allocated one; this makes decoding []*Decimal much cheaper.
+	if !isNull && d.r.Type() == DecimalType && v.Kind() == reflect.Ptr && v.IsNil() && v.Type().Elem() == decimalType {
+		val, err := d.r.DecimalValue()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(val))
+		return nil
+	}
+
 	v = indirect(v, isNull)
+	if v.Type() == rawValueType {
+		return d.decodeRawValueTo(v)
+	}
 	if isNull {
 		v.Set(reflect.Zero(v.Type()))
 		if v.Type().Kind() == reflect.Struct {
@@ -305,6 +723,21 @@ func (d *Decoder) decodeTo(v reflect.Value) error {
 		return nil
 	}
 
+	if textTypes[v.Type()] {
+		return d.decodeTextValueTo(v)
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		val, ok, err := d.decodeGoType()
+		if err != nil {
+			return err
+		}
+		if ok {
+			v.Set(reflect.ValueOf(val))
+			return nil
+		}
+	}
+
 	switch d.r.Type() {
 	case BoolType:
 		return d.decodeBoolTo(v)
@@ -406,6 +839,16 @@ func (d *Decoder) decodeIntTo(v reflect.Value) error {
 		v.SetUint(uiv)
 		return nil
 
+	case reflect.String:
+		if v.Type() == jsonNumberType {
+			val, err := d.r.BigIntValue()
+			if err != nil {
+				return err
+			}
+			v.SetString(val.String())
+			return nil
+		}
+
 	case reflect.Struct:
 		if v.Type() == bigIntType {
 			val, err := d.r.BigIntValue()
@@ -417,6 +860,16 @@ func (d *Decoder) decodeIntTo(v reflect.Value) error {
 			}
 			return nil
 		}
+		if v.Type() == ratType {
+			val, err := d.r.BigIntValue()
+			if err != nil {
+				return err
+			}
+			if val != nil {
+				v.Set(reflect.ValueOf(*new(big.Rat).SetInt(val)))
+			}
+			return nil
+		}
 		return d.decodeToStructWithAnnotation(v, typesAcceptableKinds[IntType]...)
 
 	case reflect.Interface:
@@ -443,13 +896,24 @@ func (d *Decoder) decodeFloatTo(v reflect.Value) error {
 		if v.OverflowFloat(*val) {
 			return fmt.Errorf("ion: value %v won't fit in type %v", *val, v.Type().String())
 		}
+		if d.strictFloats && v.Kind() == reflect.Float32 && !math.IsNaN(*val) && float64(float32(*val)) != *val {
+			return fmt.Errorf("ion: value %v can't be represented exactly in type %v", *val, v.Type().String())
+		}
 		v.SetFloat(*val)
 		return nil
 
+	case reflect.String:
+		if v.Type() == jsonNumberType {
+			if math.IsNaN(*val) || math.IsInf(*val, 0) {
+				return fmt.Errorf("ion: cannot decode float %v to %v", *val, v.Type().String())
+			}
+			v.SetString(strconv.FormatFloat(*val, 'g', -1, 64))
+			return nil
+		}
+
 	case reflect.Struct:
 		if v.Type() == decimalType {
-			flt := strconv.FormatFloat(*val, 'g', -1, 64)
-			dec, err := ParseDecimal(strings.Replace(flt, "e", "d", 1))
+			dec, err := NewDecimalFromFloat(*val)
 			if err != nil {
 				return err
 			}
@@ -478,13 +942,40 @@ func (d *Decoder) decodeDecimalTo(v reflect.Value) error {
 	}
 
 	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := val.Float64()
+		if math.IsInf(f, 0) || v.OverflowFloat(f) {
+			return fmt.Errorf("ion: value %v won't fit in type %v", val, v.Type().String())
+		}
+		if d.strictFloats && v.Kind() == reflect.Float32 && !isFloat32(val, float32(f)) {
+			return fmt.Errorf("ion: value %v can't be represented exactly in type %v", val, v.Type().String())
+		}
+		v.SetFloat(f)
+		return nil
+
+	case reflect.String:
+		if v.Type() == jsonNumberType {
+			v.SetString(jsonNumberForDecimal(val))
+			return nil
+		}
+
 	case reflect.Struct:
 		if v.Type() == decimalType {
 			if val != nil {
-				v.Set(reflect.ValueOf(*val))
+				v.Set(reflect.ValueOf(val).Elem())
 			}
 			return d.attachAnnotations(v)
 		}
+		if v.Type() == ratType {
+			if val != nil {
+				r, err := val.rat()
+				if err != nil {
+					return err
+				}
+				v.Set(reflect.ValueOf(*r))
+			}
+			return nil
+		}
 		return d.decodeToStructWithAnnotation(v, decimalType.Kind())
 
 	case reflect.Interface:
@@ -498,12 +989,50 @@ func (d *Decoder) decodeDecimalTo(v reflect.Value) error {
 	return fmt.Errorf("ion: cannot decode decimal to %v", v.Type().String())
 }
 
+// IsFloat32 returns true if the decimal d is exactly equal to f.
+func isFloat32(d *Decimal, f float32) bool {
+	if f == 0 {
+		return d.Sign() == 0
+	}
+	// A float32 has at most a few hundred decimal digits, so one too large
+	// to expand can't be equal to it.
+	r, err := d.rat()
+	if err != nil {
+		return false
+	}
+	return new(big.Rat).SetFloat64(float64(f)).Cmp(r) == 0
+}
+
+// JSONNumberForDecimal formats a decimal as a json.Number, keeping all of its
+// digits: in plain notation if it has a fractional part, and otherwise with an
+// exponent if it has one, so that e.g. 1d100 doesn't become a hundred zeros.
+// A fractional part too long for PlainString to write out, as in 1d-300000000,
+// gets an exponent too, since PlainString's fallback isn't valid JSON.
+func jsonNumberForDecimal(d *Decimal) string {
+	coef, exp := d.CoEx()
+	if exp <= 0 && exp >= -maxExpandedExponent {
+		return d.PlainString()
+	}
+	if d.isNegZero {
+		return fmt.Sprintf("-0e%d", exp)
+	}
+	return fmt.Sprintf("%ve%d", coef, exp)
+}
+
 func (d *Decoder) decodeTimestampTo(v reflect.Value) error {
 	val, err := d.r.TimestampValue()
 	if err != nil {
 		return err
 	}
 
+	if setter, ok := timestampSetterFor(v); ok {
+		if val == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		return setter.SetFromTimestamp(*val)
+	}
+
 	switch v.Kind() {
 	case reflect.Struct:
 		switch v.Type() {
@@ -541,13 +1070,16 @@ func (d *Decoder) decodeSymbolTo(v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.String:
 		if val != nil {
+			if err := checkValidValue(v.Type(), *val.Text); err != nil {
+				return err
+			}
 			v.SetString(*val.Text)
 		}
 		return nil
 
 	case reflect.Struct:
 		if v.Type() == symbolType {
-			v.Set(reflect.ValueOf(val))
+			v.Set(reflect.ValueOf(*val))
 			return d.attachAnnotations(v)
 		}
 		return d.decodeToStructWithAnnotation(v, symbolType.Kind())
@@ -570,11 +1102,26 @@ func (d *Decoder) decodeStringTo(v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.String:
 		if val != nil {
+			if err := checkValidValue(v.Type(), *val); err != nil {
+				return err
+			}
 			v.SetString(*val)
 		}
 		return nil
 
 	case reflect.Struct:
+		if len(d.timestampLayouts) > 0 && (v.Type() == timestampType || v.Type() == nativeTimeType) {
+			ts, err := d.parseTimestamp(*val)
+			if err != nil {
+				return err
+			}
+			if v.Type() == timestampType {
+				v.Set(reflect.ValueOf(ts))
+			} else {
+				v.Set(reflect.ValueOf(ts.GetDateTime()))
+			}
+			return nil
+		}
 		return d.decodeToStructWithAnnotation(v, reflect.String)
 
 	case reflect.Interface:
@@ -586,6 +1133,57 @@ func (d *Decoder) decodeStringTo(v reflect.Value) error {
 	return fmt.Errorf("ion: cannot decode string to %v", v.Type().String())
 }
 
+// TimestampSetterFor returns v as a TimestampSetter if it implements the
+// interface, directly or through its address.
+func timestampSetterFor(v reflect.Value) (TimestampSetter, bool) {
+	if v.Kind() == reflect.Interface {
+		return nil, false
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(timestampSetterType) {
+		return v.Addr().Interface().(TimestampSetter), true
+	}
+	if v.Type().Implements(timestampSetterType) {
+		return v.Interface().(TimestampSetter), true
+	}
+	return nil, false
+}
+
+// CheckValidValue returns an error if t implements ValidValuer, directly or
+// through a pointer, and s is not one of its valid values.
+func checkValidValue(t reflect.Type, s string) error {
+	var vv ValidValuer
+	switch {
+	case t.Implements(validValuerType):
+		vv = reflect.Zero(t).Interface().(ValidValuer)
+	case reflect.PtrTo(t).Implements(validValuerType):
+		vv = reflect.New(t).Interface().(ValidValuer)
+	default:
+		return nil
+	}
+
+	valid := vv.ValidValues()
+	for _, v := range valid {
+		if v == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("ion: %q is not a valid %v, expected one of %q", s, t.String(), valid)
+}
+
+// ParseTimestamp parses a string as an Ion timestamp or, failing that, with
+// the first of the decoder's timestamp layouts that fits.
+func (d *Decoder) parseTimestamp(val string) (Timestamp, error) {
+	if ts, err := ParseTimestamp(val); err == nil {
+		return ts, nil
+	}
+	for _, layout := range d.timestampLayouts {
+		if ts, err := ParseTimestampLayout(layout, val); err == nil {
+			return ts, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("ion: cannot decode %q to a timestamp", val)
+}
+
 func (d *Decoder) decodeLobTo(v reflect.Value) error {
 	val, err := d.r.ByteValue()
 	if err != nil {
@@ -608,6 +1206,12 @@ func (d *Decoder) decodeLobTo(v reflect.Value) error {
 			return nil
 		}
 
+	case reflect.String:
+		if d.r.Type() == ClobType {
+			v.SetString(string(val))
+			return nil
+		}
+
 	case reflect.Struct:
 		return d.decodeToStructWithAnnotation(v, typesAcceptableKinds[BlobType]...)
 
@@ -623,12 +1227,18 @@ func (d *Decoder) decodeLobTo(v reflect.Value) error {
 func (d *Decoder) decodeStructTo(v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.Struct:
-		return d.decodeStructToStruct(v)
+		return d.decodeStructToStruct(v, nil)
 
 	case reflect.Map:
-		return d.decodeStructToMap(v)
+		return d.decodeStructToMap(v, nil)
 
 	case reflect.Interface:
+		if nv, ok, err := d.decodeNamedStruct(v.Type()); ok || err != nil {
+			if err == nil {
+				v.Set(nv)
+			}
+			return err
+		}
 		if v.NumMethod() == 0 {
 			m, err := d.decodeMap()
 			if err != nil {
@@ -641,8 +1251,42 @@ func (d *Decoder) decodeStructTo(v reflect.Value) error {
 	return fmt.Errorf("ion: cannot decode struct to %v", v.Type().String())
 }
 
-func (d *Decoder) decodeStructToStruct(v reflect.Value) error {
-	fields := fieldsFor(v.Type())
+// DecodeNamedStruct decodes the current struct to a new value of the type its
+// annotations name in d.typeNames, or a pointer to one, that can be assigned to
+// an interface of type it. It returns false if there is no such type.
+func (d *Decoder) decodeNamedStruct(it reflect.Type) (reflect.Value, bool, error) {
+	if d.typeNames == nil {
+		return reflect.Value{}, false, nil
+	}
+
+	as, err := d.r.Annotations()
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	t := d.typeNames.typeFor(as)
+	if t == nil {
+		return reflect.Value{}, false, nil
+	}
+
+	ptr := reflect.New(t)
+	v := ptr.Elem()
+	if !t.Implements(it) {
+		if !ptr.Type().Implements(it) {
+			return reflect.Value{}, false, fmt.Errorf("ion: cannot decode %v to %v", t, it)
+		}
+		v = ptr
+	}
+	if err := d.decodeStructToStruct(ptr.Elem(), nil); err != nil {
+		return reflect.Value{}, false, err
+	}
+	return v, true, nil
+}
+
+// DecodeStructToStruct decodes the fields of the current Ion struct into v. If
+// selected is non-nil, fields whose names are not in it are skipped.
+func (d *Decoder) decodeStructToStruct(v reflect.Value, selected map[string]bool) error {
+	sfs := structFieldsFor(v.Type())
+	fields := sfs.list
 
 	err := d.attachAnnotations(v)
 	if err != nil {
@@ -653,41 +1297,77 @@ func (d *Decoder) decodeStructToStruct(v reflect.Value) error {
 		return err
 	}
 
+	var seen map[*field]bool
+	for i := range fields {
+		if fields[i].required {
+			seen = map[*field]bool{}
+			break
+		}
+	}
+
 	for d.r.Next() {
 		fieldName, err := d.r.FieldName()
 		if err != nil {
 			return err
 		}
 		if fieldName != nil && fieldName.Text != nil {
-			field := findField(fields, *fieldName.Text)
+			if selected != nil && !selected[*fieldName.Text] {
+				continue
+			}
+
+			field := sfs.find(*fieldName.Text)
 			if field != nil {
+				if seen != nil {
+					seen[field] = true
+				}
+
 				subv, err := findSubvalue(v, field)
 				if err != nil {
 					return err
 				}
 
 				if err := d.decodeTo(subv); err != nil {
-					return err
+					return withPathElement(err, PathElement{Field: *fieldName.Text, Index: -1})
+				}
+			} else if sfs.remaining != nil {
+				if err := d.decodeRemainingField(v, sfs.remaining, *fieldName.Text); err != nil {
+					return withPathElement(err, PathElement{Field: *fieldName.Text, Index: -1})
 				}
 			}
 		}
 	}
+	if err := d.r.Err(); err != nil {
+		return err
+	}
+
+	for i := range fields {
+		f := &fields[i]
+		if f.required && !seen[f] && (selected == nil || selected[f.name]) {
+			return fmt.Errorf("ion: required field %q is missing from %v", f.name, v.Type())
+		}
+	}
 
 	return d.r.StepOut()
 }
 
-func findField(fields []field, name string) *field {
-	var f *field
-	for i := range fields {
-		ff := &fields[i]
-		if ff.name == name {
-			return ff
-		}
-		if f == nil && strings.EqualFold(ff.name, name) {
-			f = ff
-		}
+// DecodeRemainingField decodes the current value, a field of the Ion struct
+// that matches none of v's fields, into the map held by v's remaining field f,
+// under the given name.
+func (d *Decoder) decodeRemainingField(v reflect.Value, f *field, name string) error {
+	m, err := findSubvalue(v, f)
+	if err != nil {
+		return err
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := d.decodeTo(elem); err != nil {
+		return err
 	}
-	return f
+	m.SetMapIndex(reflect.ValueOf(name).Convert(m.Type().Key()), elem)
+	return nil
 }
 
 func findSubvalue(v reflect.Value, f *field) (reflect.Value, error) {
@@ -706,7 +1386,9 @@ func findSubvalue(v reflect.Value, f *field) (reflect.Value, error) {
 	return v, nil
 }
 
-func (d *Decoder) decodeStructToMap(v reflect.Value) error {
+// DecodeStructToMap decodes the fields of the current Ion struct into the map v.
+// If selected is non-nil, fields whose names are not in it are skipped.
+func (d *Decoder) decodeStructToMap(v reflect.Value, selected map[string]bool) error {
 	t := v.Type()
 	switch t.Key().Kind() {
 	case reflect.String:
@@ -718,12 +1400,19 @@ func (d *Decoder) decodeStructToMap(v reflect.Value) error {
 		v.Set(reflect.MakeMap(t))
 	}
 
+	multi := d.opts&DecodeMultiMaps != 0 &&
+		t.Elem().Kind() == reflect.Slice && t.Elem().Elem().Kind() != reflect.Uint8
+	seen := map[string]bool{}
+
 	if err := d.r.StepIn(); err != nil {
 		return err
 	}
 
 	for d.r.Next() {
 		subv := reflect.New(t.Elem()).Elem()
+		if multi {
+			subv = reflect.New(t.Elem().Elem()).Elem()
+		}
 
 		fieldName, err := d.r.FieldName()
 		if err != nil {
@@ -732,9 +1421,12 @@ func (d *Decoder) decodeStructToMap(v reflect.Value) error {
 
 		if fieldName != nil && fieldName.Text != nil {
 			fieldNameText := *fieldName.Text
+			if selected != nil && !selected[fieldNameText] {
+				continue
+			}
 
 			if err := d.decodeTo(subv); err != nil {
-				return err
+				return withPathElement(err, PathElement{Field: fieldNameText, Index: -1})
 			}
 
 			var kv reflect.Value
@@ -745,6 +1437,17 @@ func (d *Decoder) decodeStructToMap(v reflect.Value) error {
 				panic(fmt.Sprintf("the key for map to hold field name must be of type string. Found: %v", t.Key().Kind().String()))
 			}
 
+			if multi {
+				// Start afresh the first time a name is seen, rather than
+				// appending to whatever was in the map already.
+				vals := reflect.Zero(t.Elem())
+				if seen[fieldNameText] {
+					vals = v.MapIndex(kv)
+				}
+				seen[fieldNameText] = true
+				subv = reflect.Append(vals, subv)
+			}
+
 			if kv.IsValid() {
 				v.SetMapIndex(kv, subv)
 			}
@@ -803,12 +1506,15 @@ func (d *Decoder) decodeSliceTo(v reflect.Value) error {
 
 		if i < v.Len() {
 			if err := d.decodeTo(v.Index(i)); err != nil {
-				return err
+				return withPathElement(err, PathElement{Index: i})
 			}
 		}
 
 		i++
 	}
+	if err := d.r.Err(); err != nil {
+		return withPathElement(err, PathElement{Index: i})
+	}
 
 	if err := d.r.StepOut(); err != nil {
 		return err
@@ -877,20 +1583,13 @@ func (d *Decoder) decodeToStructWithAnnotation(v reflect.Value, valueAcceptableK
 	}
 
 	fields := fieldsFor(v.Type())
-	for _, field := range fields {
-		if !field.annotations {
-			field := findField(fields, field.name)
-			if field != nil {
-				subValue, err := findSubvalue(v, field)
-				if err != nil {
-					return err
-				}
-
-				if err := d.decodeTo(subValue); err != nil {
-					return err
-				}
-				break
+	for i := range fields {
+		if !fields[i].annotations {
+			subValue, err := findSubvalue(v, &fields[i])
+			if err != nil {
+				return err
 			}
+			return d.decodeTo(subValue)
 		}
 	}
 	return nil
@@ -909,6 +1608,12 @@ func (d *Decoder) attachAnnotations(v reflect.Value) error {
 			if err != nil {
 				return err
 			}
+			if d.opts&DecodeTypeAnnotations != 0 {
+				annotations = withoutGoTypeAnnotations(annotations)
+			}
+			if d.typeNames != nil {
+				annotations = d.typeNames.without(annotations)
+			}
 			subValue.Set(reflect.ValueOf(annotations))
 			break
 		}
@@ -916,6 +1621,18 @@ func (d *Decoder) attachAnnotations(v reflect.Value) error {
 	return nil
 }
 
+// WithoutGoTypeAnnotations returns the given annotations minus any written by
+// EncodeTypeAnnotations.
+func withoutGoTypeAnnotations(as []SymbolToken) []SymbolToken {
+	var res []SymbolToken
+	for _, a := range as {
+		if !isGoTypeAnnotation(a) {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
 // expected struct for decoding Ion values must have only 2 fields: one has `ion:",annotation"`
 // tag, and the other field must be of a type where Ion value can be decoded to.
 func isValidAnnotatableStruct(v reflect.Value, listofkinds []reflect.Kind) (bool, error) {
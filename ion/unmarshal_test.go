@@ -17,9 +17,13 @@ package ion
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -68,6 +72,52 @@ func TestUnmarshalBoolPtr(t *testing.T) {
 	test("true", true)
 }
 
+func TestUnmarshalPointerFields(t *testing.T) {
+	type optional struct {
+		I   *int      `ion:"i"`
+		S   *string   `ion:"s"`
+		B   *bool     `ion:"b"`
+		P   **int     `ion:"p"`
+		Sub *optional `ion:"sub"`
+	}
+
+	var val optional
+	require.NoError(t, UnmarshalString(`{i:42,s:"str",b:true,p:7,sub:{i:1}}`, &val))
+	require.NotNil(t, val.I)
+	assert.Equal(t, 42, *val.I)
+	require.NotNil(t, val.S)
+	assert.Equal(t, "str", *val.S)
+	require.NotNil(t, val.B)
+	assert.True(t, *val.B)
+	require.NotNil(t, val.P)
+	require.NotNil(t, *val.P)
+	assert.Equal(t, 7, **val.P)
+	require.NotNil(t, val.Sub)
+	require.NotNil(t, val.Sub.I)
+	assert.Equal(t, 1, *val.Sub.I)
+	assert.Nil(t, val.Sub.S)
+
+	// Nulls reset pointers to nil; as with encoding/json, an existing pointer
+	// to a pointer is followed and the innermost one is reset.
+	require.NoError(t, UnmarshalString(`{i:null.int,s:null,b:null.bool,p:null,sub:null.struct}`, &val))
+	assert.Nil(t, val.I)
+	assert.Nil(t, val.S)
+	assert.Nil(t, val.B)
+	require.NotNil(t, val.P)
+	assert.Nil(t, *val.P)
+	assert.Nil(t, val.Sub)
+
+	// Pointers round trip through Marshal.
+	i, s := 3, "x"
+	in := optional{I: &i, S: &s, Sub: &optional{}}
+	bs, err := MarshalBinary(in)
+	require.NoError(t, err)
+
+	var out optional
+	require.NoError(t, Unmarshal(bs, &out))
+	assert.Equal(t, in, out)
+}
+
 func TestUnmarshalInt(t *testing.T) {
 	testInt8 := func(str string, eval int8) {
 		t.Run(str, func(t *testing.T) {
@@ -741,3 +791,1055 @@ var symbolTokenWith = NewSymbolTokenFromString("with")
 var symbolTokenMultiple = NewSymbolTokenFromString("multiple")
 var symbolTokenAnnotations = NewSymbolTokenFromString("annotations")
 var annotations = []SymbolToken{symbolTokenWith, symbolTokenMultiple, symbolTokenAnnotations}
+
+func TestReadAll(t *testing.T) {
+	ints, err := ReadAll[int](bytes.NewReader([]byte("1 2 3")))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, ints)
+
+	empty, err := ReadAll[string](bytes.NewReader(nil))
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = ReadAll[int](bytes.NewReader([]byte("1 \"two\"")))
+	assert.Error(t, err)
+
+	type item struct {
+		Name string `ion:"name"`
+		Qty  int    `ion:"qty"`
+	}
+
+	items := []item{{"apple", 3}, {"pear", 0}}
+
+	bin := bytes.Buffer{}
+	enc := NewBinaryEncoder(&bin)
+	for _, v := range items {
+		require.NoError(t, enc.Encode(v))
+	}
+	require.NoError(t, enc.Finish())
+
+	val, err := ReadAll[item](&bin)
+	require.NoError(t, err)
+	assert.Equal(t, items, val)
+}
+
+func TestDecodeTree(t *testing.T) {
+	in := `a::{
+		n: null, b: true, i: 1, big: 9223372036854775808, f: 1.5e0, d: 1.50, ts: 2020-01-02T,
+		s: "str", sym: sym, blob: {{AQI=}}, clob: {{"hi"}},
+		list: [1, [2, (3 + x)]], nested: {inner: {}}, nt: null.string
+	}`
+
+	val, err := DecodeTree(strings.NewReader(in))
+	require.NoError(t, err)
+
+	m, ok := val.(map[string]interface{})
+	require.True(t, ok, "expected a map, got %T", val)
+	assert.Nil(t, m["n"])
+	assert.Equal(t, true, m["b"])
+	assert.Equal(t, 1, m["i"])
+	assert.Equal(t, new(big.Int).SetUint64(math.MaxInt64+1), m["big"])
+	assert.Equal(t, 1.5, *m["f"].(*float64))
+	assert.Equal(t, MustParseDecimal("1.50"), m["d"])
+	assert.True(t, cmpTimestamps(*m["ts"].(*Timestamp), NewDateTimestamp(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), TimestampPrecisionDay)))
+	assert.Equal(t, "str", *m["s"].(*string))
+	assert.Equal(t, "sym", *m["sym"].(*SymbolToken).Text)
+	assert.Equal(t, []byte{1, 2}, m["blob"])
+	assert.Equal(t, []byte("hi"), m["clob"])
+	assert.Nil(t, m["nt"])
+
+	list := m["list"].([]interface{})
+	require.Len(t, list, 2)
+	assert.Equal(t, 1, list[0])
+	inner := list[1].([]interface{})
+	require.Len(t, inner, 2)
+	assert.Equal(t, 2, inner[0])
+	sexp := inner[1].([]interface{})
+	require.Len(t, sexp, 3)
+	assert.Equal(t, 3, sexp[0])
+	assert.Equal(t, "+", *sexp[1].(*SymbolToken).Text)
+	assert.Equal(t, "x", *sexp[2].(*SymbolToken).Text)
+
+	assert.Equal(t, map[string]interface{}{"inner": map[string]interface{}{}}, m["nested"])
+
+	// Only the first value is decoded.
+	val, err = DecodeTree(strings.NewReader("1 2"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	_, err = DecodeTree(strings.NewReader(""))
+	assert.Equal(t, ErrNoInput, err)
+
+	_, err = DecodeTree(strings.NewReader("[1,"))
+	assert.Error(t, err)
+}
+
+func TestDecodeTreeAll(t *testing.T) {
+	bin := bytes.Buffer{}
+	w := NewBinaryWriter(&bin)
+	require.NoError(t, w.WriteInt(1))
+	require.NoError(t, w.BeginList())
+	require.NoError(t, w.WriteBool(false))
+	require.NoError(t, w.WriteNull())
+	require.NoError(t, w.EndList())
+	require.NoError(t, w.BeginStruct())
+	require.NoError(t, w.FieldName(NewSymbolTokenFromString("a")))
+	require.NoError(t, w.WriteInt(2))
+	require.NoError(t, w.EndStruct())
+	require.NoError(t, w.Finish())
+
+	vals, err := DecodeTreeAll(&bin)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, []interface{}{false, nil}, map[string]interface{}{"a": 2}}, vals)
+
+	vals, err = DecodeTreeAll(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, vals)
+
+	_, err = DecodeTreeAll(strings.NewReader("1 {a:"))
+	assert.Error(t, err)
+}
+
+func TestReadValueAs(t *testing.T) {
+	type item struct {
+		Name string `ion:"name"`
+		Qty  int    `ion:"qty"`
+	}
+
+	r := NewReaderString(`42 "str" {name:"apple", qty:3} [1.5e0, 2.5e0] {item:{name:"pear", qty:1}, n:7} null.int 1`)
+
+	require.True(t, r.Next())
+	i, err := ReadValueAs[int](r)
+	require.NoError(t, err)
+	assert.Equal(t, 42, i)
+
+	// The value can be read again, as another type.
+	i64, err := ReadValueAs[int64](r)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), i64)
+
+	require.True(t, r.Next())
+	s, err := ReadValueAs[string](r)
+	require.NoError(t, err)
+	assert.Equal(t, "str", s)
+
+	require.True(t, r.Next())
+	it, err := ReadValueAs[item](r)
+	require.NoError(t, err)
+	assert.Equal(t, item{"apple", 3}, it)
+
+	require.True(t, r.Next())
+	fs, err := ReadValueAs[[]float64](r)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1.5, 2.5}, fs)
+
+	// Values partway through a stream read by hand.
+	require.True(t, r.Next())
+	var nested item
+	var n *int
+	require.NoError(t, DecodeStruct(r, func(field string, r Reader) error {
+		var err error
+		switch field {
+		case "item":
+			nested, err = ReadValueAs[item](r)
+		case "n":
+			n, err = ReadValueAs[*int](r)
+		}
+		return err
+	}))
+	assert.Equal(t, item{"pear", 1}, nested)
+	assert.Equal(t, 7, *n)
+
+	require.True(t, r.Next())
+	n, err = ReadValueAs[*int](r)
+	require.NoError(t, err)
+	assert.Nil(t, n)
+
+	// A value that doesn't fit T is an error.
+	require.True(t, r.Next())
+	_, err = ReadValueAs[string](r)
+	assert.Error(t, err)
+	assert.False(t, r.Next())
+
+	_, err = ReadValueAs[int](r)
+	assert.Error(t, err)
+	_, err = ReadValueAs[int](NewReaderString("1"))
+	assert.Error(t, err)
+}
+
+func TestDecodeFields(t *testing.T) {
+	type record struct {
+		ID    int      `ion:"id"`
+		Name  string   `ion:"name"`
+		Tags  []string `ion:"tags"`
+		Blob  []byte   `ion:"blob"`
+		Score float64  `ion:"score"`
+	}
+
+	ion := `{id:7,name:"widget",tags:["a","b"],blob:{{AQID}},score:1.5e0} {id:8,name:"gadget"} null.struct`
+
+	d := NewDecoder(NewReaderString(ion))
+
+	var r record
+	require.NoError(t, d.DecodeFields(&r, "id", "score"))
+	assert.Equal(t, record{ID: 7, Score: 1.5}, r)
+
+	m := map[string]interface{}{}
+	require.NoError(t, d.DecodeFields(&m, "name"))
+	assert.Equal(t, map[string]interface{}{"name": "gadget"}, m)
+
+	var p *record
+	require.NoError(t, d.DecodeFields(&p, "id"))
+	assert.Nil(t, p)
+
+	assert.Equal(t, ErrNoInput, d.DecodeFields(&r, "id"))
+
+	var i int
+	assert.Error(t, NewDecoder(NewReaderString("{id:1}")).DecodeFields(&i, "id"))
+}
+
+func BenchmarkDecodeFields(b *testing.B) {
+	type wide struct {
+		ID     int           `ion:"id"`
+		Name   string        `ion:"name"`
+		Values []int         `ion:"values"`
+		Nested []interface{} `ion:"nested"`
+		Data   []byte        `ion:"data"`
+	}
+
+	values := make([]int, 1000)
+	nested := make([]interface{}, 100)
+	for i := range values {
+		values[i] = i
+	}
+	for i := range nested {
+		nested[i] = map[string]interface{}{"a": i, "b": "text"}
+	}
+
+	data, err := MarshalBinary(wide{1, "name", values, nested, make([]byte, 4096)})
+	require.NoError(b, err)
+
+	b.Run("DecodeTo", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			var w wide
+			if err := NewDecoder(NewReaderBytes(data)).DecodeTo(&w); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("DecodeFields", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			var w wide
+			if err := NewDecoder(NewReaderBytes(data)).DecodeFields(&w, "id", "name"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestFloatDecimalFidelity(t *testing.T) {
+	floats := []string{"0e0", "-0e0", "1e0", "1.5e0", "-2.5e-3", "1e308", "+inf", "-inf", "nan"}
+	decimals := []string{"0.", "-0.", "0d-1", "1.", "1.0", "1.5", "-2.5d-3", "1d308", "123456789012345678901234567890.1"}
+
+	test := func(name string, in string, eval Type) {
+		t.Run(name+"/"+in, func(t *testing.T) {
+			for _, marshal := range []func(interface{}) ([]byte, error){
+				MarshalText,
+				func(v interface{}) ([]byte, error) { return MarshalBinary(v) },
+			} {
+				var v interface{}
+				require.NoError(t, UnmarshalString(in, &v))
+
+				data, err := marshal(v)
+				require.NoError(t, err)
+
+				r := NewReaderBytes(data)
+				require.True(t, r.Next())
+				assert.Equal(t, eval, r.Type())
+
+				// And once more through a reader-to-writer copy.
+				buf := bytes.Buffer{}
+				w := NewTextWriter(&buf)
+				writeFromReaderToWriter(t, NewReaderBytes(data), w)
+				require.NoError(t, w.Finish())
+
+				r = NewReaderBytes(buf.Bytes())
+				require.True(t, r.Next())
+				assert.Equal(t, eval, r.Type())
+			}
+		})
+	}
+
+	for _, f := range floats {
+		test("float", f, FloatType)
+	}
+	for _, d := range decimals {
+		test("decimal", d, DecimalType)
+	}
+}
+
+func TestDecodeMultiMaps(t *testing.T) {
+	data := "{a:1, b:[2], a:3, 'c':null, a:4} {b:5}"
+
+	d := NewDecoderOpts(NewReaderString(data), DecodeMultiMaps)
+
+	m := map[string][]interface{}{"z": {0}}
+	require.NoError(t, d.DecodeTo(&m))
+	assert.Equal(t, map[string][]interface{}{
+		"a": {1, 3, 4},
+		"b": {[]interface{}{2}},
+		"c": {nil},
+		"z": {0},
+	}, m)
+
+	// Fields repeated across structs don't accumulate.
+	require.NoError(t, d.DecodeTo(&m))
+	assert.Equal(t, []interface{}{5}, m["b"])
+
+	// Typed values work too.
+	var im map[string][]int
+	d = NewDecoderOpts(NewReaderString("{a:1, a:2, b:3}"), DecodeMultiMaps)
+	require.NoError(t, d.DecodeTo(&im))
+	assert.Equal(t, map[string][]int{"a": {1, 2}, "b": {3}}, im)
+
+	// Without the option, the last value wins for single-value maps, and
+	// slice-valued maps decode lists.
+	var sm map[string]int
+	require.NoError(t, UnmarshalString("{a:1, a:2}", &sm))
+	assert.Equal(t, map[string]int{"a": 2}, sm)
+
+	var lm map[string][]int
+	require.NoError(t, UnmarshalString("{a:[1, 2]}", &lm))
+	assert.Equal(t, map[string][]int{"a": {1, 2}}, lm)
+}
+
+func TestDecodeTimestampLayouts(t *testing.T) {
+	data := `"2020-01-02T03:04Z" "Thu, 02 Jan 2020 03:04:05 GMT" "01/02/2020" 2020-01-02T`
+
+	var ts []Timestamp
+	d := NewDecoder(NewReaderString(data)).WithTimestampLayouts(time.RFC1123, "01/02/2006")
+	for {
+		var val Timestamp
+		err := d.DecodeTo(&val)
+		if err == ErrNoInput {
+			break
+		}
+		require.NoError(t, err)
+		ts = append(ts, val)
+	}
+
+	require.Len(t, ts, 4)
+	assert.Equal(t, "2020-01-02T03:04Z", ts[0].String())
+	assert.Equal(t, "2020-01-02T03:04:05Z", ts[1].String())
+	assert.Equal(t, TimestampPrecisionDay, ts[2].GetPrecision())
+	assert.Equal(t, "2020-01-02T", ts[3].String())
+
+	var tt time.Time
+	d = NewDecoder(NewReaderString(`"Thu, 02 Jan 2020 03:04:05 GMT"`)).WithTimestampLayouts(time.RFC1123)
+	require.NoError(t, d.DecodeTo(&tt))
+	assert.True(t, tt.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	// Strings that fit none of the layouts are errors.
+	d = NewDecoder(NewReaderString(`"yesterday"`)).WithTimestampLayouts(time.RFC1123)
+	assert.Error(t, d.DecodeTo(&tt))
+
+	// By default, only Ion timestamps decode to timestamps.
+	assert.Error(t, UnmarshalString(`"2020-01-02T03:04Z"`, &tt))
+}
+
+func BenchmarkDecodeDecimalList(b *testing.B) {
+	decs := make([]*Decimal, 1000000)
+	for i := range decs {
+		decs[i] = NewDecimalInt(int64(i))
+		decs[i] = decs[i].ShiftR(2)
+	}
+
+	bin, err := MarshalBinary(decs)
+	require.NoError(b, err)
+	text, err := MarshalText(decs)
+	require.NoError(b, err)
+
+	bench := func(name string, data []byte) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				var res []*Decimal
+				if err := Unmarshal(data, &res); err != nil {
+					b.Fatal(err)
+				}
+				if len(res) != len(decs) {
+					b.Fatalf("expected %v decimals, got %v", len(decs), len(res))
+				}
+			}
+		})
+	}
+
+	bench("binary", bin)
+	bench("text", text)
+}
+
+func TestUnmarshalRequiredFields(t *testing.T) {
+	type inner struct {
+		Code int `ion:"code,required"`
+	}
+	type record struct {
+		ID    string  `ion:"id,required"`
+		Name  *string `ion:"name,required"`
+		Note  string  `ion:"note"`
+		Inner *inner  `ion:"inner"`
+	}
+
+	test := func(str string, missing string) {
+		t.Run(str, func(t *testing.T) {
+			var r record
+			err := UnmarshalString(str, &r)
+			if missing == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), fmt.Sprintf("%q", missing))
+			}
+		})
+	}
+
+	test(`{id:"a",name:"b"}`, "")
+	test(`{id:"a",name:"b",note:"c",inner:{code:1}}`, "")
+	test(`{name:"b",id:"a",extra:1}`, "")
+	test(`{name:"b"}`, "id")
+	test(`{id:"a"}`, "name")
+	test(`{}`, "id")
+	test(`{id:"a",name:"b",inner:{}}`, "code")
+
+	// A null counts as present; so does a null struct for optional fields.
+	test(`{id:null,name:null.string}`, "")
+	test(`{id:"a",name:"b",inner:null.struct}`, "")
+
+	// Only the selected fields are checked by DecodeFields.
+	var r record
+	require.NoError(t, NewDecoder(NewReaderString(`{id:"a"}`)).DecodeFields(&r, "id"))
+	assert.Equal(t, "a", r.ID)
+	assert.Error(t, NewDecoder(NewReaderString(`{note:"a"}`)).DecodeFields(&r, "id", "note"))
+}
+
+func TestUnmarshalRemainingFields(t *testing.T) {
+	type extension struct {
+		Kind  string `ion:"kind"`
+		Value int    `ion:"value"`
+	}
+	type header struct {
+		Version int `ion:"version"`
+	}
+	type record struct {
+		header
+		ID    string               `ion:"id"`
+		Extra map[string]extension `ion:",remaining"`
+	}
+
+	var r record
+	require.NoError(t, UnmarshalString(`{id:"a",x:{kind:"k",value:1},version:2,y:{value:3}}`, &r))
+	assert.Equal(t, record{
+		header: header{Version: 2},
+		ID:     "a",
+		Extra: map[string]extension{
+			"x": {Kind: "k", Value: 1},
+			"y": {Value: 3},
+		},
+	}, r)
+
+	// A field that doesn't fit the map's value type is an error naming it.
+	r = record{}
+	err := UnmarshalString(`{id:"a",x:{kind:"k"},y:5}`, &r)
+	require.Error(t, err)
+	var derr *DecodeError
+	require.True(t, errors.As(err, &derr))
+	assert.Equal(t, []PathElement{{Field: "y", Index: -1}}, derr.Path)
+
+	// A map[string]interface{} takes any value.
+	type loose struct {
+		ID    string                 `ion:"id"`
+		Extra map[string]interface{} `ion:",remaining"`
+	}
+	var l loose
+	require.NoError(t, UnmarshalString(`{id:"a",n:1,s:"two",l:[3]}`, &l))
+	assert.Equal(t, "a", l.ID)
+	assert.Equal(t, map[string]interface{}{"n": 1, "s": "two", "l": []interface{}{3}}, l.Extra)
+
+	// The map is left nil if every field has a place of its own.
+	l = loose{}
+	require.NoError(t, UnmarshalString(`{id:"a"}`, &l))
+	assert.Nil(t, l.Extra)
+}
+
+func TestDecodeTimeAnyPrecision(t *testing.T) {
+	type record struct {
+		T time.Time  `ion:"t"`
+		P *time.Time `ion:"p"`
+	}
+
+	test := func(str string, eval time.Time) {
+		t.Run(str, func(t *testing.T) {
+			text := fmt.Sprintf("{t:%v,p:%v}", str, str)
+
+			ts := MustParseTimestamp(str)
+			bin, err := MarshalBinary(map[string]interface{}{"t": ts, "p": ts})
+			require.NoError(t, err)
+
+			for _, in := range [][]byte{[]byte(text), bin} {
+				var r record
+				require.NoError(t, Unmarshal(in, &r))
+				require.NotNil(t, r.P)
+
+				for _, val := range []time.Time{r.T, *r.P} {
+					assert.True(t, eval.Equal(val), "expected %v, got %v", eval, val)
+					_, eoff := eval.Zone()
+					_, off := val.Zone()
+					assert.Equal(t, eoff, off)
+				}
+			}
+		})
+	}
+
+	test("2007T", time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC))
+	test("2007-05T", time.Date(2007, 5, 1, 0, 0, 0, 0, time.UTC))
+	test("2007-05-06", time.Date(2007, 5, 6, 0, 0, 0, 0, time.UTC))
+	test("2007-05-06T", time.Date(2007, 5, 6, 0, 0, 0, 0, time.UTC))
+	test("2007-05-06T07:08Z", time.Date(2007, 5, 6, 7, 8, 0, 0, time.UTC))
+	test("2007-05-06T07:08-00:00", time.Date(2007, 5, 6, 7, 8, 0, 0, time.UTC))
+	test("2007-05-06T07:08:09+01:30", time.Date(2007, 5, 6, 7, 8, 9, 0, time.FixedZone("", 90*60)))
+	test("2007-05-06T07:08:09.120-07:00", time.Date(2007, 5, 6, 7, 8, 9, 120000000, time.FixedZone("", -7*60*60)))
+	test("2007-05-06T07:08:09.123456789Z", time.Date(2007, 5, 6, 7, 8, 9, 123456789, time.UTC))
+}
+
+func TestDecodeErrorPath(t *testing.T) {
+	type leaf struct {
+		Count int `ion:"count"`
+	}
+	type branch struct {
+		Leaves []leaf `ion:"leaves"`
+	}
+	type tree struct {
+		Name     string            `ion:"name"`
+		Branches map[string]branch `ion:"branches"`
+	}
+
+	var val tree
+	err := UnmarshalString(`{name:"t",branches:{left:{leaves:[{count:1},{count:"two"}]}}}`, &val)
+	require.Error(t, err)
+
+	var de *DecodeError
+	require.True(t, errors.As(err, &de))
+	assert.Equal(t, []PathElement{
+		{Field: "branches", Index: -1},
+		{Field: "left", Index: -1},
+		{Field: "leaves", Index: -1},
+		{Index: 1},
+		{Field: "count", Index: -1},
+	}, de.Path)
+	assert.Contains(t, err.Error(), "branches.left.leaves[1].count")
+
+	// The path is also tracked when decoding to interface{}.
+	_, err = NewDecoder(NewReaderString(`{a:[1,{b:(x 2 {{ bad }})}]}`)).Decode()
+	require.Error(t, err)
+	require.True(t, errors.As(err, &de))
+	assert.Equal(t, "a[1].b[2]", formatPath(de.Path))
+
+	// The underlying error is still available.
+	assert.Equal(t, de.Err, errors.Unwrap(err))
+	assert.NotContains(t, de.Err.Error(), "cannot decode value at")
+
+	// Errors at the top level have no path.
+	var i int
+	err = UnmarshalString(`"str"`, &i)
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &de))
+}
+
+func TestUnmarshalRat(t *testing.T) {
+	test := func(in string, expected *big.Rat) {
+		t.Run(in, func(t *testing.T) {
+			var r big.Rat
+			require.NoError(t, UnmarshalString(in, &r))
+			assert.Equal(t, 0, expected.Cmp(&r), "expected %v, got %v", expected, &r)
+		})
+	}
+
+	test("0.", big.NewRat(0, 1))
+	test("-1.25", big.NewRat(-5, 4))
+	test("0.1", big.NewRat(1, 10))
+	test("1d3", big.NewRat(1000, 1))
+	test("-3d-2", big.NewRat(-3, 100))
+	test("12345678901234567890.000001", new(big.Rat).SetFrac(
+		new(big.Int).Add(new(big.Int).Mul(MustParseDecimal("12345678901234567890").n, big.NewInt(1000000)), big.NewInt(1)),
+		big.NewInt(1000000)))
+	test("42", big.NewRat(42, 1))
+	test("-123456789012345678901234567890", new(big.Rat).SetInt(MustParseDecimal("-123456789012345678901234567890").n))
+
+	var v struct {
+		A *big.Rat `ion:"a"`
+		B *big.Rat `ion:"b"`
+	}
+	require.NoError(t, UnmarshalString("{a:2.5, b:null.decimal}", &v))
+	assert.Equal(t, "5/2", v.A.RatString())
+	assert.Nil(t, v.B)
+
+	var r big.Rat
+	assert.Error(t, UnmarshalString("2.5e0", &r))
+
+	// Exponents too large to expand are rejected before building the power
+	// of ten, which for these would take seconds and hundreds of megabytes.
+	assert.Error(t, UnmarshalString("1d-300000000", &r))
+	assert.Error(t, UnmarshalString("1d2147483647", &r))
+	err := UnmarshalString("{a:1d-300000000}", &v)
+	var de *DecodeError
+	require.True(t, errors.As(err, &de))
+	assert.Equal(t, []PathElement{{Field: "a", Index: -1}}, de.Path)
+
+	// Rationals round-trip exactly through decimals when they terminate.
+	orig := big.NewRat(-123, 64)
+	bs, err := MarshalBinary(orig)
+	require.NoError(t, err)
+	require.NoError(t, Unmarshal(bs, &r))
+	assert.Equal(t, 0, orig.Cmp(&r))
+}
+
+func TestUnmarshalDecimalToFloat(t *testing.T) {
+	test := func(in string, expected float64) {
+		t.Run(in, func(t *testing.T) {
+			var f float64
+			require.NoError(t, UnmarshalString(in, &f))
+			assert.Equal(t, expected, f)
+
+			bs, err := MarshalBinary(MustParseDecimal(in))
+			require.NoError(t, err)
+			var f2 float64
+			require.NoError(t, Unmarshal(bs, &f2))
+			assert.Equal(t, expected, f2)
+		})
+	}
+
+	test("1.5", 1.5)
+	test("-2.5d3", -2500)
+	test("1d-2000000000", 0)
+
+	var f32 float32
+	require.NoError(t, UnmarshalString("0.1", &f32))
+	assert.Equal(t, float32(0.1), f32)
+
+	// Too large for the target type.
+	for _, in := range []string{"1d2000000000", "-1d2147483647", "1d309", "1d39"} {
+		assert.Error(t, UnmarshalString(in, &f32), in)
+	}
+	var f float64
+	for _, in := range []string{"1d2000000000", "-1d2147483647", "1d309"} {
+		assert.Error(t, UnmarshalString(in, &f), in)
+	}
+}
+
+func TestDecodeStrictFloatNarrowing(t *testing.T) {
+	type vec struct {
+		X float32 `ion:"x"`
+		Y float64 `ion:"y"`
+	}
+
+	decode := func(in string, strict bool) (vec, error) {
+		d := NewDecoder(NewReaderString(in))
+		if strict {
+			d = d.WithStrictFloatNarrowing()
+		}
+		var v vec
+		err := d.DecodeTo(&v)
+		return v, err
+	}
+
+	exact := func(in string, expected float32) {
+		t.Run(in, func(t *testing.T) {
+			v, err := decode("{x:"+in+", y:0.1}", true)
+			require.NoError(t, err)
+			assert.Equal(t, expected, v.X)
+			assert.Equal(t, 0.1, v.Y)
+		})
+	}
+
+	exact("0.5e0", 0.5)
+	exact("-1.25e0", -1.25)
+	exact("16777216e0", 16777216)
+	exact("0.5", 0.5)
+	exact("-0.", 0)
+	exact("0d-5", 0)
+	exact("1.25d2", 125)
+	exact("3.0517578125e-5", 1.0/32768)
+	exact("+inf", float32(math.Inf(1)))
+
+	inexact := func(in string, expected float32) {
+		t.Run(in, func(t *testing.T) {
+			v, err := decode("{x:"+in+"}", false)
+			require.NoError(t, err)
+			assert.Equal(t, expected, v.X)
+
+			_, err = decode("{x:"+in+"}", true)
+			assert.Error(t, err)
+		})
+	}
+
+	inexact("0.1e0", 0.1)
+	inexact("16777217e0", 16777216)
+	inexact("1e-50", 0)
+	inexact("0.1", 0.1)
+	inexact("16777217.", 16777216)
+	inexact("1.000000000000000000001", 1)
+	inexact("1d-2000000000", 0)
+
+	v, err := decode("{x:nan}", true)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(float64(v.X)))
+}
+
+func TestUnmarshalClobToString(t *testing.T) {
+	type doc struct {
+		Body string `ion:"body,clob"`
+		Note string `ion:"note,clob"`
+	}
+
+	var v doc
+	require.NoError(t, UnmarshalString(`{body:{{"hello \x00 world"}}, note:null.clob}`, &v))
+	assert.Equal(t, "hello \x00 world", v.Body)
+	assert.Equal(t, "", v.Note)
+
+	// Blobs hold binary data, not characters, so can't be decoded into strings.
+	var s string
+	assert.Error(t, UnmarshalString(`{{aGVsbG8=}}`, &s))
+
+	test := func(name string, marshal func(interface{}) ([]byte, error)) {
+		t.Run(name, func(t *testing.T) {
+			orig := doc{Body: "line one\nline two\xff", Note: "n"}
+			bs, err := marshal(orig)
+			require.NoError(t, err)
+
+			r := NewReaderBytes(bs)
+			require.True(t, r.Next())
+			require.NoError(t, r.StepIn())
+			require.True(t, r.Next())
+			assert.Equal(t, ClobType, r.Type())
+			require.NoError(t, r.StepOut())
+
+			var got doc
+			require.NoError(t, Unmarshal(bs, &got))
+			assert.Equal(t, orig, got)
+		})
+	}
+	test("text", MarshalText)
+	test("binary", func(v interface{}) ([]byte, error) { return MarshalBinary(v) })
+}
+
+func TestUnmarshalJSONNumber(t *testing.T) {
+	test := func(in string, eval json.Number) {
+		t.Run(in, func(t *testing.T) {
+			var n json.Number
+			require.NoError(t, UnmarshalString(in, &n))
+			assert.Equal(t, eval, n)
+
+			// Every result must be something encoding/json accepts.
+			if n != "" {
+				var f float64
+				assert.NoError(t, json.Unmarshal([]byte(n), &f))
+			}
+		})
+	}
+
+	test("0", "0")
+	test("-12", "-12")
+	test("123456789012345678901234567890", "123456789012345678901234567890")
+	test("1.", "1")
+	test("1.50", "1.50")
+	test("-0.0", "-0.0")
+	test("0.0025", "0.0025")
+	test("15d2", "15e2")
+	test("-0d2", "-0e2")
+	test("1.5e0", "1.5")
+	test("1e30", "1e+30")
+	test("1d-300000000", "1e-300000000")
+	test("-0d-2147483648", "-0e-2147483648")
+	test("null.int", "")
+
+	var v struct {
+		A json.Number `ion:"a"`
+		B []json.Number
+	}
+	require.NoError(t, UnmarshalString("{a:12.0, B:[1, 2.5, 3e0]}", &v))
+	assert.Equal(t, json.Number("12.0"), v.A)
+	assert.Equal(t, []json.Number{"1", "2.5", "3"}, v.B)
+
+	var n json.Number
+	assert.Error(t, UnmarshalString("nan", &n))
+	assert.Error(t, UnmarshalString("+inf", &n))
+
+	// A json.Number round-trips through Ion without losing digits.
+	for _, in := range []json.Number{"7", "-98765432109876543210", "3.14159265358979323846", "1.5e300"} {
+		bs, err := MarshalBinary(in)
+		require.NoError(t, err)
+		var out json.Number
+		require.NoError(t, Unmarshal(bs, &out))
+		if in == "1.5e300" {
+			assert.Equal(t, json.Number("15e299"), out)
+		} else {
+			assert.Equal(t, in, out)
+		}
+	}
+}
+
+type civilDate struct {
+	Year, Month, Day int
+}
+
+func (c *civilDate) SetFromTimestamp(ts Timestamp) error {
+	if ts.GetPrecision() != TimestampPrecisionDay {
+		return fmt.Errorf("civilDate: want day precision, got %v", ts.GetPrecision())
+	}
+	t := ts.GetDateTime()
+	*c = civilDate{t.Year(), int(t.Month()), t.Day()}
+	return nil
+}
+
+func TestUnmarshalTimestampSetter(t *testing.T) {
+	var c civilDate
+	require.NoError(t, UnmarshalString("2021-06-15", &c))
+	assert.Equal(t, civilDate{2021, 6, 15}, c)
+
+	// Errors from the hook are passed back to the caller.
+	err := UnmarshalString("2021-06-15T12:00Z", &c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "want day precision")
+
+	// Null sets the zero value without calling the hook.
+	require.NoError(t, UnmarshalString("null.timestamp", &c))
+	assert.Equal(t, civilDate{}, c)
+
+	var v struct {
+		Born  civilDate    `ion:"born"`
+		Dates []civilDate  `ion:"dates"`
+		Next  *civilDate   `ion:"next"`
+		Other []*civilDate `ion:"other"`
+	}
+	require.NoError(t, UnmarshalString("{born:1990-01-02, dates:[2020-01-01, 2020-12-31], next:2022-02-03, other:[null, 2023-04-05]}", &v))
+	assert.Equal(t, civilDate{1990, 1, 2}, v.Born)
+	assert.Equal(t, []civilDate{{2020, 1, 1}, {2020, 12, 31}}, v.Dates)
+	assert.Equal(t, &civilDate{2022, 2, 3}, v.Next)
+	assert.Equal(t, []*civilDate{nil, {2023, 4, 5}}, v.Other)
+
+	// Only timestamps go through the hook.
+	assert.Error(t, UnmarshalString("\"2021-06-15\"", &c))
+}
+
+type color string
+
+func (color) ValidValues() []string { return []string{"red", "green", "blue"} }
+func (color) IonType() Type         { return SymbolType }
+
+type size string
+
+func (*size) ValidValues() []string { return []string{"S", "M", "L"} }
+
+func TestUnmarshalValidValues(t *testing.T) {
+	var c color
+	require.NoError(t, UnmarshalString("red", &c))
+	assert.Equal(t, color("red"), c)
+	require.NoError(t, UnmarshalString(`"green"`, &c))
+	assert.Equal(t, color("green"), c)
+
+	err := UnmarshalString("purple", &c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"purple"`)
+	assert.Equal(t, color("green"), c)
+	assert.Error(t, UnmarshalString(`"Red"`, &c))
+
+	// Null leaves the zero value, whether or not it's in the valid set.
+	require.NoError(t, UnmarshalString("null.symbol", &c))
+	assert.Equal(t, color(""), c)
+
+	var s size
+	require.NoError(t, UnmarshalString("M", &s))
+	assert.Equal(t, size("M"), s)
+	assert.Error(t, UnmarshalString("XL", &s))
+
+	var v struct {
+		Colors []color `ion:"colors"`
+		Size   *size   `ion:"size"`
+	}
+	require.NoError(t, UnmarshalString("{colors:[red, blue], size:L}", &v))
+	assert.Equal(t, []color{"red", "blue"}, v.Colors)
+	assert.Equal(t, size("L"), *v.Size)
+	assert.Error(t, UnmarshalString("{colors:[red, pink]}", &v))
+	assert.Error(t, UnmarshalString("{size:XS}", &v))
+
+	// Plain strings are unaffected.
+	var str string
+	require.NoError(t, UnmarshalString("purple", &str))
+
+	// Enums round-trip as symbols.
+	out, err := MarshalText([]color{"red", "blue"})
+	require.NoError(t, err)
+	assert.Equal(t, "[red,blue]", string(out))
+	var cs []color
+	require.NoError(t, UnmarshalString(string(out), &cs))
+	assert.Equal(t, []color{"red", "blue"}, cs)
+}
+
+func TestDecodeStruct(t *testing.T) {
+	type record struct {
+		id   int64
+		name string
+		tags []string
+	}
+
+	decode := func(r Reader) (record, error) {
+		rec := record{}
+		err := DecodeStruct(r, func(field string, r Reader) error {
+			switch field {
+			case "id":
+				val, err := r.Int64Value()
+				if err != nil {
+					return err
+				}
+				rec.id = *val
+			case "name":
+				val, err := r.StringValue()
+				if err != nil {
+					return err
+				}
+				rec.name = *val
+			case "tags":
+				if err := r.StepIn(); err != nil {
+					return err
+				}
+				for r.Next() {
+					val, err := r.StringValue()
+					if err != nil {
+						return err
+					}
+					rec.tags = append(rec.tags, *val)
+				}
+				return r.StepOut()
+			case "bad":
+				return errors.New("bad field")
+			}
+			return nil
+		})
+		return rec, err
+	}
+
+	r := NewReaderString(`{id:1, skipped:{a:[1, 2]}, name:"one", tags:["x", "y"]} {name:"two", id:2} null.struct after`)
+
+	require.True(t, r.Next())
+	rec, err := decode(r)
+	require.NoError(t, err)
+	assert.Equal(t, record{1, "one", []string{"x", "y"}}, rec)
+
+	require.True(t, r.Next())
+	rec, err = decode(r)
+	require.NoError(t, err)
+	assert.Equal(t, record{id: 2, name: "two"}, rec)
+
+	require.True(t, r.Next())
+	rec, err = decode(r)
+	require.NoError(t, err)
+	assert.Equal(t, record{}, rec)
+
+	require.True(t, r.Next())
+	_, err = decode(r)
+	var uerr *UsageError
+	assert.True(t, errors.As(err, &uerr))
+
+	// An error from fn stops decoding, leaving the reader after the struct.
+	r = NewReaderString(`{id:1, bad:true, name:"never"} next`)
+	require.True(t, r.Next())
+	rec, err = decode(r)
+	assert.EqualError(t, err, "bad field")
+	assert.Equal(t, record{id: 1}, rec)
+	require.True(t, r.Next())
+	assert.Equal(t, SymbolType, r.Type())
+
+	// The same goes for binary.
+	bs, err := MarshalBinary(map[string]interface{}{"id": 7, "name": "seven"})
+	require.NoError(t, err)
+	r = NewReaderBytes(bs)
+	require.True(t, r.Next())
+	rec, err = decode(r)
+	require.NoError(t, err)
+	assert.Equal(t, record{id: 7, name: "seven"}, rec)
+	assert.False(t, r.Next())
+	assert.NoError(t, r.Err())
+}
+
+func TestUnmarshalHeterogeneousList(t *testing.T) {
+	var vals []interface{}
+	require.NoError(t, UnmarshalString(`[1, "two", 2021-06-01T12:30Z, {a:1, b:"bee"}, null, null.string, 3.5e0, 4.5, sym, {{AQI=}}, true, 5000000000, 123456789012345678901234567890]`, &vals))
+	require.Len(t, vals, 13)
+
+	ts, err := ParseTimestamp("2021-06-01T12:30Z")
+	require.NoError(t, err)
+	bi, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	require.True(t, ok)
+	sym := NewSymbolTokenFromString("sym")
+	bee := "bee"
+
+	assert.Equal(t, 1, vals[0])
+	assert.Equal(t, "two", vals[1])
+	require.IsType(t, Timestamp{}, vals[2])
+	assert.True(t, ts.Equal(vals[2].(Timestamp)))
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": &bee}, vals[3])
+	assert.Nil(t, vals[4])
+	assert.Nil(t, vals[5])
+	assert.Equal(t, 3.5, vals[6])
+	assert.Equal(t, *MustParseDecimal("4.5"), vals[7])
+	assert.Equal(t, &sym, vals[8])
+	assert.Equal(t, []byte{1, 2}, vals[9])
+	assert.Equal(t, true, vals[10])
+	assert.Equal(t, int64(5000000000), vals[11])
+	assert.Equal(t, bi, vals[12])
+
+	// The same goes for binary.
+	bs, err := MarshalBinary(vals)
+	require.NoError(t, err)
+	var bvals []interface{}
+	require.NoError(t, Unmarshal(bs, &bvals))
+	require.Len(t, bvals, 13)
+	assert.Equal(t, "two", bvals[1])
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": &bee}, bvals[3])
+	assert.Nil(t, bvals[4])
+	assert.Equal(t, 3.5, bvals[6])
+}
+
+func BenchmarkDecodeStructs(b *testing.B) {
+	type item struct {
+		SKU      string  `ion:"sku"`
+		Quantity int     `ion:"quantity"`
+		Price    float64 `ion:"price"`
+	}
+	type order struct {
+		ID       int    `ion:"id"`
+		Customer string `ion:"customer"`
+		Items    []item `ion:"items"`
+	}
+
+	buf := bytes.Buffer{}
+	e := NewBinaryEncoder(&buf)
+	for i := 0; i < 100; i++ {
+		require.NoError(b, e.Encode(order{i, "someone", []item{{"a", 1, 1.5}, {"b", 2, 2.5}}}))
+	}
+	require.NoError(b, e.Finish())
+	data := buf.Bytes()
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		d := NewDecoder(NewReaderBytes(data))
+		for {
+			var o order
+			if err := d.DecodeTo(&o); err == ErrNoInput {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
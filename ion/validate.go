@@ -0,0 +1,238 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A ValidationError is one of the problems found by ValidateAll: Err, found
+// at byte Offset of the data, on the given Line, counting from 1. Line is 0
+// for binary data, which has no lines.
+type ValidationError struct {
+	Offset uint64
+	Line   int
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("ion: invalid data at offset %v: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("ion: invalid data at line %v (offset %v): %v", e.Line, e.Offset, e.Err)
+}
+
+// Unwrap returns the problem found.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateAll reads through all of the given Ion data (text or binary),
+// including every scalar value, and returns every problem it finds, in order,
+// as ValidationErrors, or nil if the data is valid. Errors that carry an offset
+// (SyntaxError, UnexpectedTokenError, etc) also have it adjusted to be
+// relative to the start of data. An error that doesn't carry one, such as an
+// invalid timestamp, is placed at the end of the value that caused it.
+//
+// A reader cannot continue past an error, so after each one ValidateAll
+// restarts reading at a heuristically-chosen point: the next line for text,
+// or the next binary version marker for binary. Recovery is best-effort; it
+// may miss problems that follow an error, or report spurious ones (e.g. when
+// restarting in the middle of a container or without the symbol table that
+// was in effect).
+func ValidateAll(data []byte) []error {
+	var errs []error
+
+	binary := bytes.HasPrefix(data, []byte{0xE0, 0x01, 0x00, 0xEA})
+
+	start := uint64(0)
+	for start < uint64(len(data)) {
+		r := NewReaderBytes(data[start:])
+		err := validate(r)
+		if err == nil {
+			break
+		}
+
+		// A text reader counts \r\n as one character, so its offsets are
+		// converted to count bytes.
+		var off uint64
+		if o, ok := offsetOf(err); ok {
+			if !binary {
+				o = byteOffset(data[start:], o)
+			}
+			off = start + o
+			setOffset(err, off)
+		} else {
+			off = start + uint64(r.BytesConsumed())
+		}
+
+		line := 0
+		if !binary {
+			line = lineAt(data, off)
+		}
+		errs = append(errs, &ValidationError{Offset: off, Line: line, Err: err})
+
+		// An error at the version marker that reading restarted from would
+		// just be found again, so look past it.
+		next := resumeOffset(data, off, binary)
+		if next <= start {
+			next = resumeOffset(data, start+1, binary)
+		}
+		start = next
+	}
+
+	return errs
+}
+
+// Validate reads every value (recursively) from the reader, returning the
+// first error found.
+func validate(r Reader) error {
+	for r.Next() {
+		if r.IsInStruct() {
+			if _, err := r.FieldName(); err != nil {
+				return err
+			}
+		}
+		if _, err := r.Annotations(); err != nil {
+			return err
+		}
+		if r.IsNull() {
+			continue
+		}
+
+		var err error
+		switch r.Type() {
+		case StructType, ListType, SexpType:
+			if err := r.StepIn(); err != nil {
+				return err
+			}
+			if err := validate(r); err != nil {
+				return err
+			}
+			err = r.StepOut()
+
+		case BoolType:
+			_, err = r.BoolValue()
+		case IntType:
+			_, err = r.BigIntValue()
+		case FloatType:
+			_, err = r.FloatValue()
+		case DecimalType:
+			_, err = r.DecimalValue()
+		case TimestampType:
+			_, err = r.TimestampValue()
+		case SymbolType:
+			_, err = r.SymbolValue()
+		case StringType:
+			_, err = r.StringValue()
+		case ClobType, BlobType:
+			_, err = r.ByteValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.Err()
+}
+
+// ByteOffset converts off, an offset into text data as a text reader counts
+// it, with \r\n as a single newline, into an offset in bytes.
+func byteOffset(data []byte, off uint64) uint64 {
+	i := uint64(0)
+	for ; off > 0 && i < uint64(len(data)); off-- {
+		if data[i] == '\r' && i+1 < uint64(len(data)) && data[i+1] == '\n' {
+			i++
+		}
+		i++
+	}
+	return i + off
+}
+
+// LineAt returns the line, counting from 1, of the byte at off in text data.
+// \n, \r\n, and a lone \r each end a line.
+func lineAt(data []byte, off uint64) int {
+	if off > uint64(len(data)) {
+		off = uint64(len(data))
+	}
+	line := 1
+	for i, c := range data[:off] {
+		if c == '\n' || (c == '\r' && (i+1 == len(data) || data[i+1] != '\n')) {
+			line++
+		}
+	}
+	return line
+}
+
+// ResumeOffset picks where to restart validating after an error at off: the
+// first binary version marker at or after it, or the start of the next line.
+func resumeOffset(data []byte, off uint64, binary bool) uint64 {
+	if off >= uint64(len(data)) {
+		return uint64(len(data))
+	}
+
+	if binary {
+		i := bytes.Index(data[off:], []byte{0xE0, 0x01, 0x00, 0xEA})
+		if i < 0 {
+			return uint64(len(data))
+		}
+		return off + uint64(i)
+	}
+
+	i := bytes.IndexAny(data[off:], "\r\n")
+	if i < 0 {
+		return uint64(len(data))
+	}
+	return off + uint64(i) + 1
+}
+
+// OffsetOf returns the offset at which the given error occurred, if known.
+func offsetOf(err error) (uint64, bool) {
+	switch e := err.(type) {
+	case *SyntaxError:
+		return e.Offset, true
+	case *UnexpectedEOFError:
+		return e.Offset, true
+	case *UnsupportedVersionError:
+		return e.Offset, true
+	case *InvalidTagByteError:
+		return e.Offset, true
+	case *UnexpectedRuneError:
+		return e.Offset, true
+	case *UnexpectedTokenError:
+		return e.Offset, true
+	}
+	return 0, false
+}
+
+// SetOffset sets the offset of an error for which offsetOf returns true.
+func setOffset(err error, off uint64) {
+	switch e := err.(type) {
+	case *SyntaxError:
+		e.Offset = off
+	case *UnexpectedEOFError:
+		e.Offset = off
+	case *UnsupportedVersionError:
+		e.Offset = off
+	case *InvalidTagByteError:
+		e.Offset = off
+	case *UnexpectedRuneError:
+		e.Offset = off
+	case *UnexpectedTokenError:
+		e.Offset = off
+	}
+}
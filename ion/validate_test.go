@@ -0,0 +1,108 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ion
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAllValid(t *testing.T) {
+	assert.Nil(t, ValidateAll([]byte("1 foo::{a:[b, (c d)]} \"str\"")))
+	assert.Nil(t, ValidateAll(nil))
+
+	bin, err := MarshalBinary(map[string]interface{}{"a": []int{1, 2}})
+	require.NoError(t, err)
+	assert.Nil(t, ValidateAll(bin))
+}
+
+func TestValidateAllText(t *testing.T) {
+	data := "ok\n" +
+		"[1, 2,, 3]\r\n" + // offset 9
+		"ok\n" +
+		"{a:1 b:2}\n" + // offset 22
+		"2000-13-01T\n" + // no offset of its own, so at the end, 39
+		"[1, 2] ,\n" + // offset 47
+		"ok"
+
+	errs := ValidateAll([]byte(data))
+	require.Equal(t, 4, len(errs), "%v", errs)
+
+	for i, e := range []struct {
+		off  uint64
+		line int
+	}{{9, 2}, {22, 4}, {39, 5}, {47, 6}} {
+		var verr *ValidationError
+		require.True(t, errors.As(errs[i], &verr), "%v", errs[i])
+		assert.Equal(t, e.off, verr.Offset, "%v", errs[i])
+		assert.Equal(t, e.line, verr.Line, "%v", errs[i])
+
+		if off, ok := offsetOf(verr.Err); ok {
+			assert.Equal(t, e.off, off, "%v", errs[i])
+		}
+	}
+	assert.Contains(t, errs[0].Error(), "line 2")
+}
+
+func TestValidateAllScalars(t *testing.T) {
+	// Each of these is only found by reading the value itself.
+	data := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x64, 0x80, 0x0F, 0xD0, 0x8D, // 2000-13T
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x42, 0x00, 0x00, // float of invalid length
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x82, 0xFF, 0xFE, // invalid UTF-8
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x21, 0x01, // 1
+	}
+
+	errs := ValidateAll(data)
+	require.Equal(t, 3, len(errs), "%v", errs)
+	for i, eoff := range []uint64{9, 14, 23} {
+		var verr *ValidationError
+		require.True(t, errors.As(errs[i], &verr), "%v", errs[i])
+		assert.Equal(t, eoff, verr.Offset, "%v", errs[i])
+		assert.Equal(t, 0, verr.Line)
+	}
+}
+
+func TestValidateAllBinary(t *testing.T) {
+	data := []byte{
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x21, 0x01, // 1
+		0xF0,                   // invalid tag
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x21, 0x02, // 2
+		0x2F,                   // null.int
+		0xE0, 0x01, 0x00, 0xEA, // $ion_1_0
+		0x83, 'a', // truncated string
+	}
+
+	errs := ValidateAll(data)
+	require.Equal(t, 2, len(errs), "%v", errs)
+
+	var tagErr *InvalidTagByteError
+	require.True(t, errors.As(errs[0], &tagErr), "%v", errs[0])
+	assert.Equal(t, uint64(6), tagErr.Offset)
+
+	var eofErr *UnexpectedEOFError
+	require.True(t, errors.As(errs[1], &eofErr), "%v", errs[1])
+	assert.Equal(t, uint64(len(data)), eofErr.Offset)
+}
@@ -67,7 +67,10 @@ type Writer interface {
 	// FieldName sets the field name for the next value written.
 	FieldName(val SymbolToken) error
 
-	// Annotation adds a single annotation to the next value written.
+	// Annotation adds a single annotation to the next value written. An
+	// annotation with text is written with exactly that text, even if it is
+	// empty or looks like a symbol ID such as $0; one without text is written
+	// by its symbol ID.
 	Annotation(val SymbolToken) error
 
 	// Annotations adds multiple annotations to the next value written.
@@ -79,6 +82,11 @@ type Writer interface {
 	// WriteNullType writes a null value with a type qualifier, e.g. null.bool.
 	WriteNullType(t Type) error
 
+	// WriteNullWithTypeAndAnnotations writes a null value with a type
+	// qualifier and the given annotations, e.g. foo::null.int, after any
+	// annotations already added. Pass NullType for an untyped null.
+	WriteNullWithTypeAndAnnotations(t Type, annotations ...string) error
+
 	// WriteBool writes a boolean value.
 	WriteBool(val bool) error
 
@@ -94,7 +102,9 @@ type Writer interface {
 	// WriteFloat writes a floating-point value.
 	WriteFloat(val float64) error
 
-	// WriteDecimal writes an arbitrary-precision decimal value.
+	// WriteDecimal writes an arbitrary-precision decimal value. Ion decimals
+	// are always finite; a nil or zero-value Decimal, which holds no value at
+	// all, is rejected with an error rather than written as invalid Ion.
 	WriteDecimal(val *Decimal) error
 
 	// WriteTimestamp writes a timestamp value.
@@ -115,6 +125,29 @@ type Writer interface {
 	// WriteBlob writes a blob value.
 	WriteBlob(val []byte) error
 
+	// WriteValue marshals a Go value, as MarshalTo does, and writes it at the
+	// current position, using any field name and annotations already set. It
+	// lets hand-written structure be mixed with marshaled values.
+	WriteValue(v interface{}) error
+
+	// WriteFieldIf writes a field of the struct being written, with the given
+	// name and a value marshaled as by WriteValue, if cond is true, and does
+	// nothing if it is false. It saves wrapping FieldName and the write that
+	// must follow it in an if statement for each optional field.
+	WriteFieldIf(cond bool, name string, v interface{}) error
+
+	// WriteListFromChan writes a list holding each value received from ch,
+	// marshaled as by WriteValue, in order, ending the list once ch is closed.
+	// It lets a producer stream a list of unknown length without first
+	// collecting it in a slice, though a binary writer still buffers the
+	// list's encoding until it ends, as it does for any container. If a value
+	// can't be written, WriteListFromChan returns the error straight away,
+	// leaving the list unfinished and the rest of ch unread. Nothing receives
+	// from ch after it returns, so the producer must then stop sending, e.g.
+	// by also selecting on a done channel that the caller closes once
+	// WriteListFromChan returns, or it will block forever.
+	WriteListFromChan(ch <-chan interface{}) error
+
 	// BeginList begins writing a list value.
 	BeginList() error
 
@@ -136,8 +169,59 @@ type Writer interface {
 	// Finish finishes writing values and flushes any buffered data.
 	Finish() error
 
+	// Flush writes any values that are buffered in memory to the underlying
+	// io.Writer without finishing the current datagram, and flushes the
+	// io.Writer itself if it has a Flush method (e.g. a bufio.Writer). A text
+	// writer writes values as it goes, so everything written so far is
+	// flushed, including the start of a container that has not been ended
+	// yet. A binary writer can only write a value once its length is known,
+	// so it flushes completed top-level values and holds on to a top-level
+	// container until it has been ended.
+	Flush() error
+
 	// IsInStruct indicates if we are currently writing a struct or not.
 	IsInStruct() bool
+
+	// Reset discards all of the writer's state, including any values that have
+	// not been finished and the symbols it has collected, and directs its output
+	// to out. This lets one writer be reused for many documents, each written
+	// exactly as a fresh writer with the same options would write it.
+	Reset(out io.Writer)
+}
+
+// WriteFieldIf implements Writer.WriteFieldIf over w's other methods.
+func writeFieldIf(w Writer, cond bool, name string, v interface{}) error {
+	if !cond {
+		return nil
+	}
+	if err := w.FieldName(NewSymbolTokenFromString(name)); err != nil {
+		return err
+	}
+	return w.WriteValue(v)
+}
+
+// WriteListFromChan implements Writer.WriteListFromChan over w's other methods.
+func writeListFromChan(w Writer, ch <-chan interface{}) error {
+	if err := w.BeginList(); err != nil {
+		return err
+	}
+	for v := range ch {
+		if err := w.WriteValue(v); err != nil {
+			return err
+		}
+	}
+	return w.EndList()
+}
+
+// WriteNullWithTypeAndAnnotations implements
+// Writer.WriteNullWithTypeAndAnnotations over w's other methods.
+func writeNullWithTypeAndAnnotations(w Writer, t Type, annotations []string) error {
+	for _, a := range annotations {
+		if err := w.Annotation(NewSymbolTokenFromString(a)); err != nil {
+			return err
+		}
+	}
+	return w.WriteNullType(t)
 }
 
 // A writer holds shared stuff for all writers.
@@ -192,8 +276,24 @@ func (w *writer) IsInStruct() bool {
 	return w.ctx.peek() == ctxInStruct
 }
 
+// flushOut flushes the underlying io.Writer, if it supports flushing.
+func (w *writer) flushOut() error {
+	if f, ok := w.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
 // Clear clears field name and annotations after writing a value.
 func (w *writer) clear() {
 	w.fieldName = nil
 	w.annotations = nil
 }
+
+// Reset clears the state shared by all writers and directs output to out.
+func (w *writer) reset(out io.Writer) {
+	w.out = out
+	w.ctx.arr = w.ctx.arr[:0]
+	w.err = nil
+	w.clear()
+}